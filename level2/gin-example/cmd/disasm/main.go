@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"level2/gin-example/internal/bytecode"
+	"level2/gin-example/internal/registry"
+	"level2/network"
+)
+
+// 反汇编合约字节码，在 level2 目录下运行：
+//
+//	go run ./gin-example/cmd/disasm 0x135765bEC9A17B12841389a727092552598ed6D5
+//	go run ./gin-example/cmd/disasm -network mainnet 0x...
+//	go run ./gin-example/cmd/disasm -rpc http://127.0.0.1:8545 0x...
+//	go run ./gin-example/cmd/disasm -code 0x6080...
+func main() {
+	rpcURL := flag.String("rpc", os.Getenv("ETH_RPC_URL"), "以太坊节点 RPC 地址，不传时使用 networks.json 中的网络")
+	networkName := flag.String("network", "", "networks.json 中的网络名，默认使用配置的默认网络")
+	block := flag.Int64("block", -1, "区块号，默认最新区块")
+	codeHex := flag.String("code", "", "直接反汇编这段十六进制字节码，不连接节点")
+	summary := flag.Bool("summary", false, "只输出选择器和代理信息，不输出指令列表")
+	flag.Parse()
+
+	code, err := loadCode(*rpcURL, *networkName, *block, *codeHex, flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	reg := registry.New()
+	for _, dir := range []string{".", "./pkg", "../task2/artifacts"} {
+		if err := reg.LoadDir(dir); err != nil && !os.IsNotExist(err) {
+			log.Fatal(err)
+		}
+	}
+	analysis := bytecode.Analyze(code, reg.ABIs()...)
+
+	if !*summary {
+		fmt.Print(bytecode.FormatText(analysis.Instructions))
+		fmt.Println()
+	}
+	fmt.Printf("代码长度: %d 字节\n", analysis.Size)
+	if analysis.Metadata != nil {
+		fmt.Printf("solc: %s  ipfs: %s\n", analysis.Metadata.Solc, analysis.Metadata.IPFS)
+	}
+	fmt.Println("函数选择器:")
+	for _, sel := range analysis.Selectors {
+		fmt.Printf("  %s  %v\n", sel.Selector, sel.Signatures)
+	}
+	if analysis.Proxy != nil {
+		fmt.Printf("代理合约: %s", analysis.Proxy.Kind)
+		if analysis.Proxy.Implementation != nil {
+			fmt.Printf("  实现地址: %s", analysis.Proxy.Implementation.Hex())
+		}
+		fmt.Println()
+	}
+	if analysis.HasSelfDestruct {
+		fmt.Println("警告: 包含 SELFDESTRUCT")
+	}
+}
+
+func loadCode(rpcURL, networkName string, block int64, codeHex, address string) ([]byte, error) {
+	if codeHex != "" {
+		return hexutil.Decode(codeHex)
+	}
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("usage: disasm [-rpc url | -network name] [-block n] <address> | disasm -code 0x...")
+	}
+	client, err := dial(rpcURL, networkName)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	var blockNumber *big.Int
+	if block >= 0 {
+		blockNumber = big.NewInt(block)
+	}
+	return client.CodeAt(context.Background(), common.HexToAddress(address), blockNumber)
+}
+
+// dial 优先使用 -rpc，否则按 networks.json 连接（会检查链 ID）
+func dial(rpcURL, networkName string) (*ethclient.Client, error) {
+	if rpcURL != "" {
+		return ethclient.Dial(rpcURL)
+	}
+	cfg, err := network.Load("")
+	if err != nil {
+		return nil, err
+	}
+	if networkName == "" {
+		networkName = cfg.Default
+	}
+	n, ok := cfg.Networks[networkName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", network.ErrUnknownNetwork, networkName)
+	}
+	if n.Disabled != "" {
+		return nil, fmt.Errorf("network %s: %s", networkName, n.Disabled)
+	}
+	pool, err := network.Dial(context.Background(), n)
+	if err != nil {
+		return nil, err
+	}
+	return pool.Client(), nil
+}
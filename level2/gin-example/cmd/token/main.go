@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"level2/gin-example/internal/auth"
+)
+
+// 用服务端相同的 JWT 配置（JWT_HS256_SECRET / JWT_ES256_KEY_FILE 等环境变量）签发令牌，
+// 用于运维和本地调试，在 level2 目录下运行：
+//
+//	JWT_HS256_SECRET=... go run ./gin-example/cmd/token -user alice -roles admin -wallets 0xabc...,0xdef...
+func main() {
+	user := flag.String("user", "", "用户 ID")
+	roles := flag.String("roles", "", "角色，逗号分隔：viewer、operator、treasurer、admin")
+	wallets := flag.String("wallets", "", "绑定的钱包地址，逗号分隔")
+	flag.Parse()
+	if *user == "" {
+		log.Fatal("-user is required")
+	}
+	if os.Getenv("JWT_HS256_SECRET") == "" && os.Getenv("JWT_ES256_KEY_FILE") == "" {
+		log.Fatal("set JWT_HS256_SECRET or JWT_ES256_KEY_FILE to the same key the server uses")
+	}
+
+	cfg, err := auth.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	manager, err := auth.NewManager(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sub := auth.Subject{UserID: *user, Roles: split(*roles)}
+	for _, w := range split(*wallets) {
+		if !common.IsHexAddress(w) {
+			log.Fatalf("invalid wallet address %q", w)
+		}
+		sub.Wallets = append(sub.Wallets, common.HexToAddress(w))
+	}
+	pair, err := manager.Issue(sub)
+	if err != nil {
+		log.Fatal(err)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(pair)
+}
+
+func split(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -1,27 +1,97 @@
 package main
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/apikey"
+	"level2/gin-example/internal/auth"
+	"level2/gin-example/internal/vault"
 	"level2/gin-example/internal/web"
+	"level2/gin-example/internal/web/middleware"
+	"level2/network"
 	"log"
+	"os"
+	"strings"
 )
 
 func main() {
-	// 连接到 Infura 并创建 UserHandler
-	infuraURL := "https://sepolia.infura.io/v3/5cfcf36740804b5f92e934d6a2ba77c8"
-	userHandler, err := web.NewUserHandler(infuraURL)
+	// 读取 networks.json（可用环境变量覆盖），连接所有网络并检查链 ID
+	cfg, err := network.Load("")
 	if err != nil {
-		log.Fatal("Failed to connect to Infura:", err)
+		log.Fatal("Failed to load network config:", err)
+	}
+	pools, err := network.DialAll(context.Background(), cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to networks:", err)
+	}
+	defer pools.Close()
+
+	// JWT 配置从环境变量读取，见 auth.ConfigFromEnv
+	jwtConfig, err := auth.ConfigFromEnv()
+	if err != nil {
+		log.Fatal("Failed to load jwt config:", err)
+	}
+	tokens, err := auth.NewManager(jwtConfig)
+	if err != nil {
+		log.Fatal("Failed to create token manager:", err)
+	}
+
+	// 角色策略，配置 RBAC_POLICY_FILE 可以把角色限定到具体的签名账户和合约
+	policy, err := auth.PolicyFromEnv()
+	if err != nil {
+		log.Fatal("Failed to load rbac policy:", err)
+	}
+
+	// 加密保存的助记词，HD 钱包接口按名字引用，解锁后的钱包只留在内存里
+	vaultFile := os.Getenv("MNEMONIC_VAULT_FILE")
+	if vaultFile == "" {
+		vaultFile = vault.DefaultFile
+	}
+	mnemonics, err := vault.Open(vaultFile)
+	if err != nil {
+		log.Fatal("Failed to load mnemonic vault:", err)
+	}
+	defer mnemonics.LockAll()
+
+	// 创建 UserHandler
+	authz := middleware.NewAuthorizer(policy)
+	userHandler, err := web.NewUserHandler(pools, authz, mnemonics)
+	if err != nil {
+		log.Fatal("Failed to create user handler:", err)
 	}
 
+	// 内部服务用的 API 密钥，只保存哈希
+	keysFile := os.Getenv("API_KEYS_FILE")
+	if keysFile == "" {
+		keysFile = apikey.DefaultFile
+	}
+	apiKeys, err := apikey.Open(keysFile)
+	if err != nil {
+		log.Fatal("Failed to load api keys:", err)
+	}
+	defer apiKeys.Flush()
+
 	// 初始化 Web 服务器
 	server := initWebServer()
 
+	// 带 X-API-Key 的请求用密钥认证并限流，其余请求除公开路径外都需要 JWT 登录
+	server.Use(middleware.NewAPIKeyMiddlewareBuilder(apiKeys).Build())
+	server.Use(middleware.NewLoginJWTMiddlewareBuilder(tokens).
+		IgnorePaths(web.PublicPaths...).
+		Build())
+
 	// 加载视图文件
 	server.LoadHTMLGlob("gin-example/views/*") // 修正路径
 
 	// 注册路由
 	userHandler.RegisterRoutes(server)
+	authHandler, err := web.NewAuthHandler(tokens, pools)
+	if err != nil {
+		log.Fatal("Failed to create auth handler:", err)
+	}
+	authHandler.RegisterRoutes(server)
+	web.NewAPIKeyHandler(apiKeys, authz).RegisterRoutes(server)
+	web.NewVaultHandler(mnemonics, authz).RegisterRoutes(server)
 
 	// 启动服务器
 	if err := server.Run(":8080"); err != nil {
@@ -32,5 +102,16 @@ func main() {
 func initWebServer() *gin.Engine {
 	// 初始化 gin 引擎并返回
 	server := gin.Default()
+	// 按 IP 限流的接口（如 /auth/nonce）用 ClientIP 区分客户端。默认信任所有代理时 X-Forwarded-For 可以随便伪造，
+	// 只信任 TRUSTED_PROXIES（逗号分隔的 IP 或 CIDR）里的反向代理，没有配置时直接用连接的对端地址
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	if err := server.SetTrustedProxies(proxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
 	return server
 }
@@ -0,0 +1,90 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrNoAccount 没有可用的签名账户
+var ErrNoAccount = errors.New("no managed account available")
+
+// Manager 管理服务端可以用来签名的账户，账户来自 ./wallets 下的 keystore 文件
+type Manager struct {
+	ks *keystore.KeyStore
+}
+
+// NewManager 打开 keystore 目录（与 go-ethereum-example/keystore.go 使用同一目录）
+func NewManager(keydir string) *Manager {
+	return &Manager{
+		ks: keystore.NewKeyStore(keydir, keystore.StandardScryptN, keystore.StandardScryptP),
+	}
+}
+
+// Accounts 返回所有托管账户地址
+func (m *Manager) Accounts() []common.Address {
+	var addrs []common.Address
+	for _, acc := range m.ks.Accounts() {
+		addrs = append(addrs, acc.Address)
+	}
+	return addrs
+}
+
+// UnlockAll 用同一个密码解锁所有 keystore 账户，返回解锁失败的账户
+func (m *Manager) UnlockAll(passphrase string) []error {
+	var errs []error
+	for _, acc := range m.ks.Accounts() {
+		if err := m.ks.Unlock(acc, passphrase); err != nil {
+			errs = append(errs, fmt.Errorf("unlock %s: %w", acc.Address.Hex(), err))
+		}
+	}
+	return errs
+}
+
+// Default 第一个托管账户，请求里没有指定 from 时使用
+func (m *Manager) Default() (common.Address, error) {
+	accs := m.ks.Accounts()
+	if len(accs) == 0 {
+		return common.Address{}, ErrNoAccount
+	}
+	return accs[0].Address, nil
+}
+
+// Resolve 请求里指定了 from 就用它，否则用默认账户
+func (m *Manager) Resolve(from *common.Address) (common.Address, error) {
+	if from != nil {
+		return *from, nil
+	}
+	return m.Default()
+}
+
+// Transactor 为托管账户生成 bind.TransactOpts，账户需要已经解锁
+func (m *Manager) Transactor(from common.Address, chainID *big.Int) (*bind.TransactOpts, error) {
+	acc, err := m.find(from)
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewKeyStoreTransactorWithChainID(m.ks, acc, chainID)
+}
+
+// SignHash 用托管账户对 32 字节哈希签名，返回 [R || S || V] 格式（V 为 0/1）
+func (m *Manager) SignHash(from common.Address, hash []byte) ([]byte, error) {
+	acc, err := m.find(from)
+	if err != nil {
+		return nil, err
+	}
+	return m.ks.SignHash(acc, hash)
+}
+
+func (m *Manager) find(addr common.Address) (accounts.Account, error) {
+	acc, err := m.ks.Find(accounts.Account{Address: addr})
+	if err != nil {
+		return accounts.Account{}, fmt.Errorf("%w: %s", ErrNoAccount, addr.Hex())
+	}
+	return acc, nil
+}
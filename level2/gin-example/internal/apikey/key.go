@@ -0,0 +1,137 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// 密钥格式：l2k_<id>_<secret>，id 用来查找记录，服务端只保存 secret 的 SHA-256
+const (
+	keyPrefix   = "l2k_"
+	idBytes     = 6
+	secretBytes = 32
+)
+
+var (
+	// ErrInvalidKey 格式不对、找不到或 secret 不匹配
+	ErrInvalidKey = errors.New("invalid api key")
+	// ErrRevoked 密钥已吊销
+	ErrRevoked = errors.New("api key revoked")
+	// ErrNotFound 没有这个 id 的密钥
+	ErrNotFound = errors.New("api key not found")
+)
+
+// Limits 每个密钥的限流和每日额度，额度按 UTC 自然日重置
+type Limits struct {
+	RatePerSecond float64 `json:"ratePerSecond"` // 令牌桶每秒补充的请求数
+	Burst         int     `json:"burst"`         // 令牌桶容量
+	DailyRPCCalls int64   `json:"dailyRpcCalls"` // 每天最多发往节点的 RPC 调用数，0 表示不限
+	// DailyValue 每天最多通过交易转出的 wei，为空表示不限，0 表示不允许转出
+	DailyValue *big.Int `json:"dailyValue,omitempty"`
+}
+
+// DefaultLimits 创建密钥时没有指定的字段用它补齐
+func DefaultLimits() Limits {
+	return Limits{
+		RatePerSecond: 10,
+		Burst:         20,
+		DailyRPCCalls: 100000,
+		DailyValue:    big.NewInt(params.Ether),
+	}
+}
+
+// Key 保存在文件里的密钥记录，不含明文
+type Key struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Roles     []string   `json:"roles,omitempty"`
+	Hash      string     `json:"hash"`
+	Limits    Limits     `json:"limits"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RotatedAt *time.Time `json:"rotatedAt,omitempty"`
+	// 轮换后旧 secret 在宽限期内仍然有效，调用方有时间切换
+	PreviousHash    string     `json:"previousHash,omitempty"`
+	PreviousExpires time.Time  `json:"previousExpires,omitempty"`
+	RevokedAt       *time.Time `json:"revokedAt,omitempty"`
+	LastUsedAt      *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// View 对外展示的密钥信息，不含哈希
+type View struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Roles           []string   `json:"roles,omitempty"`
+	Limits          Limits     `json:"limits"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	RotatedAt       *time.Time `json:"rotatedAt,omitempty"`
+	PreviousExpires *time.Time `json:"previousExpires,omitempty"`
+	RevokedAt       *time.Time `json:"revokedAt,omitempty"`
+	LastUsedAt      *time.Time `json:"lastUsedAt,omitempty"`
+	Usage           *Usage     `json:"usage,omitempty"`
+}
+
+func (k *Key) view() View {
+	v := View{
+		ID:         k.ID,
+		Name:       k.Name,
+		Roles:      k.Roles,
+		Limits:     k.Limits,
+		CreatedAt:  k.CreatedAt,
+		RotatedAt:  k.RotatedAt,
+		RevokedAt:  k.RevokedAt,
+		LastUsedAt: k.LastUsedAt,
+	}
+	if k.PreviousHash != "" && time.Now().Before(k.PreviousExpires) {
+		expires := k.PreviousExpires
+		v.PreviousExpires = &expires
+	}
+	return v
+}
+
+// matches secret 是否是当前的或者仍在宽限期内的旧 secret
+func (k *Key) matches(secret string, now time.Time) bool {
+	h := hashSecret(secret)
+	if subtle.ConstantTimeCompare([]byte(h), []byte(k.Hash)) == 1 {
+		return true
+	}
+	return k.PreviousHash != "" && now.Before(k.PreviousExpires) &&
+		subtle.ConstantTimeCompare([]byte(h), []byte(k.PreviousHash)) == 1
+}
+
+// parseKey 拆出 id 和 secret
+func parseKey(raw string) (id, secret string, err error) {
+	rest, ok := strings.CutPrefix(raw, keyPrefix)
+	if !ok {
+		return "", "", ErrInvalidKey
+	}
+	id, secret, ok = strings.Cut(rest, "_")
+	if !ok || len(id) != idBytes*2 || len(secret) != secretBytes*2 {
+		return "", "", ErrInvalidKey
+	}
+	return id, secret, nil
+}
+
+func formatKey(id, secret string) string {
+	return keyPrefix + id + "_" + secret
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
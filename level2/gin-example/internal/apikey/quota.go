@@ -0,0 +1,272 @@
+package apikey
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"level2/network"
+)
+
+var (
+	// ErrRateLimited 令牌桶空了
+	ErrRateLimited = errors.New("rate limit exceeded")
+	// ErrRPCQuota 当天的 RPC 调用额度用完
+	ErrRPCQuota = errors.New("daily rpc quota exceeded")
+	// ErrValueQuota 当天的转出额度不够
+	ErrValueQuota = errors.New("daily value quota exceeded")
+)
+
+// Usage 当天的用量
+type Usage struct {
+	Day      string   `json:"day"` // UTC 日期 2006-01-02
+	Requests int64    `json:"requests"`
+	RPCCalls int64    `json:"rpcCalls"`
+	Value    *big.Int `json:"value"`
+}
+
+// Quota 某一时刻的剩余额度，用来生成响应头
+type Quota struct {
+	RateLimit      int
+	RateRemaining  int
+	RPCLimit       int64 // 0 表示不限
+	RPCRemaining   int64
+	ValueLimit     *big.Int // 为空表示不限
+	ValueRemaining *big.Int
+	Reset          time.Time // 每日额度重置时间
+}
+
+// usageFlushInterval 用量最多隔多久写一次文件；有转出金额的调用立即写
+const usageFlushInterval = 10 * time.Second
+
+// meter 一个密钥的令牌桶和当天用量。令牌桶只在内存里；当天用量写进用量文件，服务重启后接着计算
+type meter struct {
+	tokens float64
+	last   time.Time
+	usage  Usage
+}
+
+// refill 按流逝的时间补充令牌，并在跨天时清零用量
+func (m *meter) refill(limits Limits, now time.Time) {
+	if m.last.IsZero() {
+		m.tokens = float64(limits.Burst)
+	} else {
+		m.tokens = math.Min(float64(limits.Burst), m.tokens+now.Sub(m.last).Seconds()*limits.RatePerSecond)
+	}
+	m.last = now
+	if day := now.UTC().Format(time.DateOnly); m.usage.Day != day {
+		m.usage = Usage{Day: day, Value: new(big.Int)}
+	}
+}
+
+func (m *meter) quota(limits Limits, now time.Time) Quota {
+	y, mo, d := now.UTC().Date()
+	q := Quota{
+		RateLimit:     limits.Burst,
+		RateRemaining: int(m.tokens),
+		RPCLimit:      limits.DailyRPCCalls,
+		Reset:         time.Date(y, mo, d+1, 0, 0, 0, 0, time.UTC),
+	}
+	if limits.DailyRPCCalls > 0 {
+		q.RPCRemaining = max(limits.DailyRPCCalls-m.usage.RPCCalls, 0)
+	}
+	if limits.DailyValue != nil {
+		q.ValueLimit = limits.DailyValue
+		q.ValueRemaining = new(big.Int).Sub(limits.DailyValue, m.usage.Value)
+		if q.ValueRemaining.Sign() < 0 {
+			q.ValueRemaining.SetInt64(0)
+		}
+	}
+	return q
+}
+
+// Allow 消耗一个令牌；令牌不够时返回 ErrRateLimited，Quota 里是当前剩余
+func (s *Store) Allow(id string) (Quota, error) {
+	now := s.now()
+	s.mu.Lock()
+	key, ok := s.keys[id]
+	if !ok {
+		s.mu.Unlock()
+		return Quota{}, ErrNotFound
+	}
+	m := s.meter(id)
+	m.refill(key.Limits, now)
+	if m.tokens < 1 {
+		q := m.quota(key.Limits, now)
+		s.mu.Unlock()
+		return q, ErrRateLimited
+	}
+	m.tokens--
+	m.usage.Requests++
+	s.usageDirty = true
+	q := m.quota(key.Limits, now)
+	s.mu.Unlock()
+	s.flushUsage(false)
+	return q, nil
+}
+
+// Charge 记一批发往节点的调用：计入 RPC 次数，发送交易的还要计入转出金额。
+// 额度不够时整批拒绝，不会部分扣减。转出额度只能计量 ETH，有转出额度的密钥不能发送代币转账、授权这类交易
+func (s *Store) Charge(id string, calls []network.Call) (Quota, error) {
+	value, tokenCall, err := callsValue(calls)
+	if err != nil {
+		return Quota{}, err
+	}
+	now := s.now()
+	s.mu.Lock()
+	key, ok := s.keys[id]
+	if !ok {
+		s.mu.Unlock()
+		return Quota{}, ErrNotFound
+	}
+	m := s.meter(id)
+	m.refill(key.Limits, now)
+	limits := key.Limits
+	if err := m.check(limits, len(calls), value, tokenCall); err != nil {
+		q := m.quota(limits, now)
+		s.mu.Unlock()
+		return q, err
+	}
+	m.usage.RPCCalls += int64(len(calls))
+	m.usage.Value.Add(m.usage.Value, value)
+	s.usageDirty = true
+	q := m.quota(limits, now)
+	s.mu.Unlock()
+	// 转出金额不能因为重启丢掉，立即写文件
+	s.flushUsage(value.Sign() > 0)
+	return q, nil
+}
+
+// check 这批调用是否超出额度
+func (m *meter) check(limits Limits, calls int, value *big.Int, tokenCall string) error {
+	if limits.DailyRPCCalls > 0 && m.usage.RPCCalls+int64(calls) > limits.DailyRPCCalls {
+		return fmt.Errorf("%w: %d of %d used", ErrRPCQuota, m.usage.RPCCalls, limits.DailyRPCCalls)
+	}
+	if limits.DailyValue == nil {
+		return nil
+	}
+	if tokenCall != "" {
+		return fmt.Errorf("%w: %s moves tokens, which a daily value quota cannot meter", ErrValueQuota, tokenCall)
+	}
+	if value.Sign() > 0 {
+		if total := new(big.Int).Add(m.usage.Value, value); total.Cmp(limits.DailyValue) > 0 {
+			return fmt.Errorf("%w: sending %s wei, %s of %s wei used", ErrValueQuota, value, m.usage.Value, limits.DailyValue)
+		}
+	}
+	return nil
+}
+
+// Quota 当前剩余额度
+func (s *Store) Quota(id string) (Quota, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return Quota{}, ErrNotFound
+	}
+	m := s.meter(id)
+	m.refill(key.Limits, now)
+	return m.quota(key.Limits, now), nil
+}
+
+// meter 调用方持有 s.mu；第一次用到时接上用量文件里保存的当天用量
+func (s *Store) meter(id string) *meter {
+	m, ok := s.meters[id]
+	if !ok {
+		m = &meter{}
+		if u, ok := s.savedUsage[id]; ok && u.Value != nil {
+			m.usage = u
+			m.usage.Value = new(big.Int).Set(u.Value)
+		}
+		s.meters[id] = m
+	}
+	return m
+}
+
+// 会转移代币或授权别人转移的方法选择器。转出额度按 wei 计，没法计量这些调用
+var tokenMethods = map[string]string{
+	"a9059cbb": "transfer(address,uint256)",
+	"23b872dd": "transferFrom(address,address,uint256)", // ERC-20 和 ERC-721 相同
+	"095ea7b3": "approve(address,uint256)",
+	"39509351": "increaseAllowance(address,uint256)",
+	"d505accf": "permit(address,address,uint256,uint256,uint8,bytes32,bytes32)",
+	"42842e0e": "safeTransferFrom(address,address,uint256)",
+	"b88d4fde": "safeTransferFrom(address,address,uint256,bytes)",
+	"f242432a": "safeTransferFrom(address,address,uint256,uint256,bytes)",
+	"2eb2c2d6": "safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)",
+	"a22cb465": "setApprovalForAll(address,bool)",
+}
+
+// tokenCall 交易数据是否调用了 tokenMethods 里的方法。最后一个参数为 0 的 approve 和 setApprovalForAll(false)
+// 是撤销授权，不算在内。只识别标准方法，经过路由合约或 multicall 的转账识别不出来
+func tokenCall(data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	selector := hex.EncodeToString(data[:4])
+	method, ok := tokenMethods[selector]
+	if !ok {
+		return ""
+	}
+	if selector == "095ea7b3" || selector == "a22cb465" {
+		if len(data) >= 4+64 && new(big.Int).SetBytes(data[4+32:4+64]).Sign() == 0 {
+			return ""
+		}
+	}
+	return method
+}
+
+// callsValue 交易转出的 wei 合计，以及其中第一个代币调用：eth_sendRawTransaction 解码签名交易，
+// eth_sendTransaction 读 value 和 data（或 input）字段
+func callsValue(calls []network.Call) (*big.Int, string, error) {
+	total := new(big.Int)
+	var token string
+	for _, c := range calls {
+		var value *big.Int
+		var data []byte
+		switch c.Method {
+		case "eth_sendRawTransaction":
+			var params []hexutil.Bytes
+			if err := json.Unmarshal(c.Params, &params); err != nil || len(params) == 0 {
+				return nil, "", fmt.Errorf("decode %s params: %v", c.Method, err)
+			}
+			tx := new(types.Transaction)
+			if err := tx.UnmarshalBinary(params[0]); err != nil {
+				return nil, "", fmt.Errorf("decode raw transaction: %w", err)
+			}
+			value, data = tx.Value(), tx.Data()
+		case "eth_sendTransaction":
+			var params []struct {
+				Value *hexutil.Big  `json:"value"`
+				Data  hexutil.Bytes `json:"data"`
+				Input hexutil.Bytes `json:"input"`
+			}
+			if err := json.Unmarshal(c.Params, &params); err != nil || len(params) == 0 {
+				return nil, "", fmt.Errorf("decode %s params: %v", c.Method, err)
+			}
+			if params[0].Value != nil {
+				value = params[0].Value.ToInt()
+			}
+			data = params[0].Input
+			if len(data) == 0 {
+				data = params[0].Data
+			}
+		default:
+			continue
+		}
+		if value != nil {
+			total.Add(total, value)
+		}
+		if token == "" {
+			token = tokenCall(data)
+		}
+	}
+	return total, token, nil
+}
@@ -0,0 +1,274 @@
+package apikey
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"level2/network"
+)
+
+// fakeClock 测试用时钟，只有 advance 才会走
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// newTestStore path 为空时只在内存里
+func newTestStore(t *testing.T, path string, clock *fakeClock) *Store {
+	t.Helper()
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = clock.now
+	return s
+}
+
+func createKey(t *testing.T, s *Store, limits Limits) string {
+	t.Helper()
+	_, v, err := s.Create("test", nil, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v.ID
+}
+
+// startClock 从 UTC 23:00 开始，方便测试跨天
+func startClock() *fakeClock {
+	return &fakeClock{t: time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)}
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := startClock()
+	s := newTestStore(t, "", clock)
+	id := createKey(t, s, Limits{RatePerSecond: 2, Burst: 3})
+
+	steps := []struct {
+		advance   time.Duration
+		err       error
+		remaining int
+	}{
+		{0, nil, 2},
+		{0, nil, 1},
+		{0, nil, 0},
+		{0, ErrRateLimited, 0},
+		{250 * time.Millisecond, ErrRateLimited, 0}, // 只补了半个令牌
+		{250 * time.Millisecond, nil, 0},
+		{10 * time.Second, nil, 2}, // 补满也不超过 Burst
+	}
+	for i, step := range steps {
+		clock.advance(step.advance)
+		q, err := s.Allow(id)
+		if !errors.Is(err, step.err) {
+			t.Fatalf("step %d: err = %v, want %v", i, err, step.err)
+		}
+		if q.RateLimit != 3 || q.RateRemaining != step.remaining {
+			t.Fatalf("step %d: quota = %d/%d, want %d/3", i, q.RateRemaining, q.RateLimit, step.remaining)
+		}
+	}
+	if _, err := s.Allow("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("unknown key: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDailyRollover(t *testing.T) {
+	clock := startClock()
+	s := newTestStore(t, "", clock)
+	id := createKey(t, s, Limits{RatePerSecond: 10, Burst: 10, DailyRPCCalls: 3, DailyValue: big.NewInt(100)})
+
+	calls := []network.Call{{Method: "eth_blockNumber"}, {Method: "eth_chainId"}}
+	if _, err := s.Charge(id, calls); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Charge(id, append(calls, sendTransaction(t, 100, nil)...)); !errors.Is(err, ErrRPCQuota) {
+		t.Fatalf("err = %v, want ErrRPCQuota", err)
+	}
+	q, err := s.Charge(id, sendTransaction(t, 60, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.RPCRemaining != 0 || q.ValueRemaining.Int64() != 40 {
+		t.Fatalf("quota = %d calls, %s wei, want 0 and 40", q.RPCRemaining, q.ValueRemaining)
+	}
+	if want := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC); !q.Reset.Equal(want) {
+		t.Fatalf("reset = %v, want %v", q.Reset, want)
+	}
+
+	// 过了 UTC 零点用量清零
+	clock.advance(59 * time.Minute)
+	if _, err := s.Charge(id, calls[:1]); !errors.Is(err, ErrRPCQuota) {
+		t.Fatalf("before midnight: err = %v, want ErrRPCQuota", err)
+	}
+	clock.advance(time.Minute)
+	q, err = s.Quota(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.RPCRemaining != 3 || q.ValueRemaining.Int64() != 100 {
+		t.Fatalf("after midnight: quota = %d calls, %s wei, want 3 and 100", q.RPCRemaining, q.ValueRemaining)
+	}
+	if _, err := s.Charge(id, sendTransaction(t, 100, nil)); err != nil {
+		t.Fatalf("after midnight: %v", err)
+	}
+}
+
+func TestCharge(t *testing.T) {
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	transfer := erc20Call("a9059cbb", recipient, big.NewInt(1))
+	revoke := erc20Call("095ea7b3", recipient, new(big.Int))
+
+	tests := []struct {
+		name   string
+		limits Limits
+		calls  []network.Call
+		err    error
+		used   int64 // 成功后当天已转出的 wei
+	}{
+		{"read only", DefaultLimits(), []network.Call{{Method: "eth_call"}}, nil, 0},
+		{"raw transaction", limitsWithValue(100), rawTransaction(t, 70, nil), nil, 70},
+		{"send transaction", limitsWithValue(100), sendTransaction(t, 100, nil), nil, 100},
+		{"batch sums value", limitsWithValue(100), append(rawTransaction(t, 60, nil), sendTransaction(t, 41, nil)...), ErrValueQuota, 0},
+		{"over value quota", limitsWithValue(100), rawTransaction(t, 101, nil), ErrValueQuota, 0},
+		{"zero value quota", limitsWithValue(0), sendTransaction(t, 1, nil), ErrValueQuota, 0},
+		{"raw token transfer", limitsWithValue(100), rawTransaction(t, 0, transfer), ErrValueQuota, 0},
+		{"sent token transfer", limitsWithValue(100), sendTransaction(t, 0, transfer), ErrValueQuota, 0},
+		{"transferFrom", limitsWithValue(100), rawTransaction(t, 0, erc20Call("23b872dd", recipient, big.NewInt(1))), ErrValueQuota, 0},
+		{"approve", limitsWithValue(100), rawTransaction(t, 0, erc20Call("095ea7b3", recipient, big.NewInt(1))), ErrValueQuota, 0},
+		{"revoke approval", limitsWithValue(100), rawTransaction(t, 0, revoke), nil, 0},
+		{"token transfer without value quota", Limits{RatePerSecond: 1, Burst: 1}, rawTransaction(t, 5, transfer), nil, 5},
+		{"malformed raw transaction", DefaultLimits(), []network.Call{{Method: "eth_sendRawTransaction", Params: json.RawMessage(`["0x01"]`)}}, errors.New("decode"), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, "", startClock())
+			id := createKey(t, s, tt.limits)
+			q, err := s.Charge(id, tt.calls)
+			switch {
+			case tt.err == nil && err != nil:
+				t.Fatalf("err = %v", err)
+			case tt.err != nil && err == nil:
+				t.Fatalf("charge succeeded, want %v", tt.err)
+			case tt.err != nil && !errors.Is(err, tt.err) && !strings.Contains(err.Error(), tt.err.Error()):
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			// 被拒绝的一批不计入用量
+			wantCalls := int64(0)
+			if tt.err == nil {
+				wantCalls = int64(len(tt.calls))
+			}
+			s.mu.Lock()
+			usage := Usage{Value: new(big.Int)}
+			if m, ok := s.meters[id]; ok {
+				usage = m.usage
+			}
+			s.mu.Unlock()
+			if usage.RPCCalls != wantCalls || usage.Value.Int64() != tt.used {
+				t.Fatalf("usage = %d calls, %s wei, want %d and %d", usage.RPCCalls, usage.Value, wantCalls, tt.used)
+			}
+			if tt.err == nil && tt.limits.DailyValue != nil && q.ValueRemaining.Int64() != tt.limits.DailyValue.Int64()-tt.used {
+				t.Fatalf("value remaining = %s", q.ValueRemaining)
+			}
+		})
+	}
+}
+
+func TestUsagePersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+	clock := startClock()
+	s := newTestStore(t, path, clock)
+	id := createKey(t, s, Limits{RatePerSecond: 10, Burst: 10, DailyRPCCalls: 10, DailyValue: big.NewInt(100)})
+
+	// 转出金额立即写文件，不用等 Flush
+	if _, err := s.Charge(id, sendTransaction(t, 80, nil)); err != nil {
+		t.Fatal(err)
+	}
+	s = newTestStore(t, path, clock)
+	if _, err := s.Charge(id, sendTransaction(t, 30, nil)); !errors.Is(err, ErrValueQuota) {
+		t.Fatalf("after restart: err = %v, want ErrValueQuota", err)
+	}
+
+	// 只有 RPC 调用时按间隔写，Flush 写下剩下的
+	if _, err := s.Charge(id, []network.Call{{Method: "eth_call"}, {Method: "eth_call"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	s = newTestStore(t, path, clock)
+	q, err := s.Quota(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.RPCRemaining != 7 || q.ValueRemaining.Int64() != 20 {
+		t.Fatalf("after restart: quota = %d calls, %s wei, want 7 and 20", q.RPCRemaining, q.ValueRemaining)
+	}
+
+	// 第二天重启，保存的是前一天的用量，不再计入
+	clock.advance(2 * time.Hour)
+	s = newTestStore(t, path, clock)
+	if q, err = s.Quota(id); err != nil {
+		t.Fatal(err)
+	}
+	if q.RPCRemaining != 10 || q.ValueRemaining.Int64() != 100 {
+		t.Fatalf("next day: quota = %d calls, %s wei, want 10 and 100", q.RPCRemaining, q.ValueRemaining)
+	}
+}
+
+func limitsWithValue(wei int64) Limits {
+	return Limits{RatePerSecond: 1, Burst: 1, DailyValue: big.NewInt(wei)}
+}
+
+// erc20Call 拼出 selector(address,uint256) 的调用数据
+func erc20Call(selector string, to common.Address, amount *big.Int) []byte {
+	data := common.FromHex(selector)
+	data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+	return append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+}
+
+func rawTransaction(t *testing.T, wei int64, data []byte) []network.Call {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x00000000000000000000000000000000000000a0")
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(1337)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1337),
+		Gas:       100000,
+		GasFeeCap: big.NewInt(1),
+		To:        &to,
+		Value:     big.NewInt(wei),
+		Data:      data,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, _ := json.Marshal([]hexutil.Bytes{raw})
+	return []network.Call{{Method: "eth_sendRawTransaction", Params: params}}
+}
+
+func sendTransaction(t *testing.T, wei int64, data []byte) []network.Call {
+	t.Helper()
+	params, err := json.Marshal([]map[string]interface{}{{
+		"to":    "0x00000000000000000000000000000000000000a0",
+		"value": (*hexutil.Big)(big.NewInt(wei)),
+		"input": hexutil.Bytes(data),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []network.Call{{Method: "eth_sendTransaction", Params: params}}
+}
@@ -0,0 +1,306 @@
+package apikey
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultFile 密钥文件，路径相对于 level2
+const DefaultFile = "./data/api-keys.json"
+
+// Store 保存密钥记录（只有哈希）和每个密钥的用量
+type Store struct {
+	mu     sync.Mutex
+	fileMu sync.Mutex // 串行化文件写入
+	path   string
+	keys   map[string]*Key
+	meters map[string]*meter
+	now    func() time.Time // 测试里可以换成假时钟
+
+	// 当天用量保存在 <密钥文件>.usage.json，和密钥记录分开，频繁写入时不用重写密钥文件
+	usageMu     sync.Mutex // 串行化用量文件写入
+	usagePath   string
+	savedUsage  map[string]Usage // 启动时读到的用量，密钥第一次用到时接上
+	usageDirty  bool             // 有没写进文件的用量，调用方持有 mu
+	usageSaveAt time.Time        // 上次写用量文件的时间，调用方持有 usageMu
+}
+
+// Open 读取密钥文件和用量文件，文件不存在时从空开始；path 为空时只保存在内存里
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:       path,
+		keys:       make(map[string]*Key),
+		meters:     make(map[string]*meter),
+		now:        time.Now,
+		savedUsage: make(map[string]Usage),
+	}
+	if path == "" {
+		return s, nil
+	}
+	s.usagePath = strings.TrimSuffix(path, ".json") + ".usage.json"
+	if err := readJSON(s.usagePath, &s.savedUsage); err != nil {
+		return nil, err
+	}
+	var keys []*Key
+	if err := readJSON(path, &keys); err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		s.keys[k.ID] = k
+	}
+	return s, nil
+}
+
+// readJSON 文件不存在时保持 v 不变
+func readJSON(path string, v interface{}) error {
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// Create 新建密钥，返回只出现这一次的明文
+func (s *Store) Create(name string, roles []string, limits Limits) (string, View, error) {
+	if err := limits.validate(); err != nil {
+		return "", View{}, err
+	}
+	id, secret := randomHex(idBytes), randomHex(secretBytes)
+	key := &Key{
+		ID:        id,
+		Name:      name,
+		Roles:     roles,
+		Hash:      hashSecret(secret),
+		Limits:    limits,
+		CreatedAt: s.now(),
+	}
+	s.mu.Lock()
+	s.keys[id] = key
+	v := key.view()
+	s.mu.Unlock()
+	if err := s.save(); err != nil {
+		return "", View{}, err
+	}
+	return formatKey(id, secret), v, nil
+}
+
+// Rotate 换一个新的 secret；grace 大于 0 时旧 secret 在这段时间内仍然可用
+func (s *Store) Rotate(id string, grace time.Duration) (string, View, error) {
+	secret := randomHex(secretBytes)
+	now := s.now()
+	s.mu.Lock()
+	key, ok := s.keys[id]
+	if !ok || key.RevokedAt != nil {
+		s.mu.Unlock()
+		if ok {
+			return "", View{}, ErrRevoked
+		}
+		return "", View{}, ErrNotFound
+	}
+	key.PreviousHash, key.PreviousExpires = "", time.Time{}
+	if grace > 0 {
+		key.PreviousHash, key.PreviousExpires = key.Hash, now.Add(grace)
+	}
+	key.Hash = hashSecret(secret)
+	key.RotatedAt = &now
+	v := key.view()
+	s.mu.Unlock()
+	if err := s.save(); err != nil {
+		return "", View{}, err
+	}
+	return formatKey(id, secret), v, nil
+}
+
+// Revoke 吊销密钥，之后的请求一律拒绝；记录保留以便查看
+func (s *Store) Revoke(id string) (View, error) {
+	s.mu.Lock()
+	key, ok := s.keys[id]
+	if !ok {
+		s.mu.Unlock()
+		return View{}, ErrNotFound
+	}
+	if key.RevokedAt == nil {
+		now := s.now()
+		key.RevokedAt = &now
+	}
+	key.PreviousHash, key.PreviousExpires = "", time.Time{}
+	v := key.view()
+	s.mu.Unlock()
+	return v, s.save()
+}
+
+// Get 密钥信息和当天用量
+func (s *Store) Get(id string) (View, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return View{}, ErrNotFound
+	}
+	return s.viewWithUsage(key), nil
+}
+
+// List 所有密钥，按创建时间排序
+func (s *Store) List() []View {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	views := make([]View, 0, len(s.keys))
+	for _, key := range s.keys {
+		views = append(views, s.viewWithUsage(key))
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].CreatedAt.Before(views[j].CreatedAt) })
+	return views
+}
+
+// Authenticate 校验明文密钥，返回记录的副本
+func (s *Store) Authenticate(raw string) (*Key, error) {
+	id, secret, err := parseKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok || !key.matches(secret, now) {
+		return nil, ErrInvalidKey
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrRevoked
+	}
+	// 最后使用时间只在内存里更新，下次写文件时一起保存
+	key.LastUsedAt = &now
+	k := *key
+	return &k, nil
+}
+
+// viewWithUsage 调用方持有 s.mu
+func (s *Store) viewWithUsage(key *Key) View {
+	v := key.view()
+	if m, ok := s.meters[key.ID]; ok {
+		m.refill(key.Limits, s.now())
+		usage := m.usage
+		usage.Value = new(big.Int).Set(m.usage.Value)
+		v.Usage = &usage
+	}
+	return v
+}
+
+// validate 额度参数是否合理
+func (l Limits) validate() error {
+	if l.RatePerSecond <= 0 {
+		return errors.New("ratePerSecond must be positive")
+	}
+	if l.Burst < 1 {
+		return errors.New("burst must be at least 1")
+	}
+	if l.DailyRPCCalls < 0 {
+		return errors.New("dailyRpcCalls must not be negative")
+	}
+	if l.DailyValue != nil && l.DailyValue.Sign() < 0 {
+		return errors.New("dailyValue must not be negative")
+	}
+	return nil
+}
+
+// save 写入密钥文件
+func (s *Store) save() error {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	if s.path == "" {
+		return nil
+	}
+	s.mu.Lock()
+	keys := make([]*Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	raw, err := json.MarshalIndent(keys, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeFile(s.path, raw)
+}
+
+// Flush 把还没写进文件的用量写下去，服务退出前调用
+func (s *Store) Flush() error {
+	return s.saveUsage(true)
+}
+
+// flushUsage 写用量文件，失败只记日志：额度检查照常按内存里的用量进行
+func (s *Store) flushUsage(force bool) {
+	if err := s.saveUsage(force); err != nil {
+		log.Printf("api keys: save usage: %v", err)
+	}
+}
+
+// saveUsage force 为 false 时距上次写入不到 usageFlushInterval 就跳过，下次再写
+func (s *Store) saveUsage(force bool) error {
+	if s.usagePath == "" {
+		return nil
+	}
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	now := s.now()
+	s.mu.Lock()
+	if !s.usageDirty || !force && now.Sub(s.usageSaveAt) < usageFlushInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	// 只保存当天的用量，旧的自然丢掉
+	day := now.UTC().Format(time.DateOnly)
+	usage := make(map[string]Usage, len(s.meters))
+	for id, u := range s.savedUsage {
+		if u.Day == day {
+			usage[id] = u
+		}
+	}
+	for id, m := range s.meters {
+		if m.usage.Day == day {
+			usage[id] = m.usage
+		}
+	}
+	raw, err := json.MarshalIndent(usage, "", "  ")
+	s.usageDirty = false
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	s.usageSaveAt = now
+	if err := writeFile(s.usagePath, raw); err != nil {
+		s.mu.Lock()
+		s.usageDirty = true
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// writeFile 先写临时文件再改名，权限 0600
+func writeFile(path string, raw []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
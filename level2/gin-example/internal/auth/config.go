@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultIssuer / DefaultAudience 没有配置 JWT_ISSUER / JWT_AUDIENCE 时使用
+	DefaultIssuer   = "level2-gin-example"
+	DefaultAudience = "level2-api"
+	// DefaultAccessTTL 访问令牌有效期，尽量短，泄露后的影响范围小
+	DefaultAccessTTL = 15 * time.Minute
+	// DefaultRefreshTTL 刷新令牌有效期
+	DefaultRefreshTTL = 7 * 24 * time.Hour
+	// DefaultRevocationFile 吊销列表，路径相对于 level2
+	DefaultRevocationFile = "./data/revoked-tokens.json"
+	// leeway 校验 exp / nbf 时允许的时钟误差
+	leeway = 30 * time.Second
+)
+
+// Config 签发和校验 JWT 的参数。HMACSecret 和 ECDSAKey 至少配置一个，都配置时用 ES256 签发，两种都能校验
+type Config struct {
+	Issuer         string
+	Audience       string
+	AccessTTL      time.Duration
+	RefreshTTL     time.Duration
+	HMACSecret     []byte            // HS256
+	ECDSAKey       *ecdsa.PrivateKey // ES256，P-256 曲线
+	RevocationFile string            // 为空时吊销列表只保存在内存里
+}
+
+// ConfigFromEnv 从环境变量读取配置：
+//   - JWT_HS256_SECRET      HS256 密钥，至少 32 字节
+//   - JWT_ES256_KEY_FILE    ES256 私钥（PEM，SEC 1 或 PKCS#8）
+//   - JWT_ISSUER / JWT_AUDIENCE
+//   - JWT_ACCESS_TTL / JWT_REFRESH_TTL  如 15m、168h
+//   - JWT_REVOCATION_FILE   吊销列表文件
+//
+// 两种密钥都没有配置时生成一个临时的 HS256 密钥，服务重启后之前签发的令牌全部失效
+func ConfigFromEnv() (*Config, error) {
+	cfg := &Config{
+		Issuer:         envOr("JWT_ISSUER", DefaultIssuer),
+		Audience:       envOr("JWT_AUDIENCE", DefaultAudience),
+		AccessTTL:      DefaultAccessTTL,
+		RefreshTTL:     DefaultRefreshTTL,
+		RevocationFile: envOr("JWT_REVOCATION_FILE", DefaultRevocationFile),
+	}
+	for name, ttl := range map[string]*time.Duration{"JWT_ACCESS_TTL": &cfg.AccessTTL, "JWT_REFRESH_TTL": &cfg.RefreshTTL} {
+		if v := os.Getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("%s: invalid duration %q", name, v)
+			}
+			*ttl = d
+		}
+	}
+	if v := os.Getenv("JWT_HS256_SECRET"); v != "" {
+		cfg.HMACSecret = []byte(v)
+	}
+	if path := os.Getenv("JWT_ES256_KEY_FILE"); path != "" {
+		key, err := LoadECDSAKey(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ECDSAKey = key
+	}
+	if cfg.HMACSecret == nil && cfg.ECDSAKey == nil {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		cfg.HMACSecret = secret
+		log.Println("JWT_HS256_SECRET / JWT_ES256_KEY_FILE not set, using a temporary key; tokens will not survive a restart")
+	}
+	return cfg, cfg.validate()
+}
+
+func (c *Config) validate() error {
+	if c.HMACSecret == nil && c.ECDSAKey == nil {
+		return errors.New("jwt: no signing key configured")
+	}
+	if c.HMACSecret != nil && len(c.HMACSecret) < 32 {
+		return errors.New("jwt: HS256 secret must be at least 32 bytes")
+	}
+	if c.ECDSAKey != nil && c.ECDSAKey.Curve != elliptic.P256() {
+		return errors.New("jwt: ES256 key must be on the P-256 curve")
+	}
+	if c.AccessTTL <= 0 || c.RefreshTTL <= 0 {
+		return errors.New("jwt: token lifetimes must be positive")
+	}
+	return nil
+}
+
+// LoadECDSAKey 读取 PEM 格式的 P-256 私钥，openssl ecparam -name prime256v1 -genkey 生成的 SEC 1 格式和 PKCS#8 都可以
+func LoadECDSAKey(path string) (*ecdsa.PrivateKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, raw = pem.Decode(raw)
+		if block == nil {
+			return nil, fmt.Errorf("%s: no private key found", path)
+		}
+		switch block.Type {
+		case "EC PRIVATE KEY":
+			return x509.ParseECPrivateKey(block.Bytes)
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			ecKey, ok := key.(*ecdsa.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("%s: not an ECDSA key", path)
+			}
+			return ecKey, nil
+		}
+		// 跳过 EC PARAMETERS 等其它块
+	}
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
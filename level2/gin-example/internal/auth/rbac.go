@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Permission 接口需要的权限
+type Permission string
+
+const (
+	PermRead     Permission = "chain:read"      // 只读查询
+	PermSign     Permission = "tx:sign"         // 签名/发送原始交易
+	PermTransfer Permission = "funds:transfer"  // 转 ETH 或代币
+	PermDeploy   Permission = "contract:deploy" // 部署合约
+	PermWrite    Permission = "contract:write"  // 调用合约的写方法
+	PermScan     Permission = "scanner:write"   // 修改扫描器的监控列表、启动扫描
+	PermAdmin    Permission = "admin"           // 查看拒绝记录等管理接口
+	// PermAll 通配，拥有所有权限
+	PermAll Permission = "*"
+)
+
+// 内置角色
+const (
+	RoleViewer    = "viewer"
+	RoleOperator  = "operator"
+	RoleTreasurer = "treasurer"
+	RoleAdmin     = "admin"
+)
+
+// ErrForbidden 角色没有所需的权限，或权限不覆盖请求的账户/合约
+var ErrForbidden = errors.New("forbidden")
+
+// Grant 一条授权。Accounts / Contracts 为空表示不限，否则只能用列出的签名账户、只能操作列出的合约
+type Grant struct {
+	Permission Permission       `json:"permission"`
+	Accounts   []common.Address `json:"accounts,omitempty"`
+	Contracts  []common.Address `json:"contracts,omitempty"`
+}
+
+// covers 授权是否覆盖这次请求。限定了范围的授权要求请求里能确定地址，取不到地址时不放行
+func (g *Grant) covers(req Request) bool {
+	if g.Permission != PermAll && g.Permission != req.Permission {
+		return false
+	}
+	if len(g.Accounts) > 0 && (req.Account == nil || !containsAddress(g.Accounts, *req.Account)) {
+		return false
+	}
+	if len(g.Contracts) > 0 && (req.Contract == nil || !containsAddress(g.Contracts, *req.Contract)) {
+		return false
+	}
+	return true
+}
+
+// Request 一次需要授权的操作；Account / Contract 为空表示接口不涉及签名账户或合约
+type Request struct {
+	Permission Permission
+	Account    *common.Address
+	Contract   *common.Address
+}
+
+func (r Request) String() string {
+	s := string(r.Permission)
+	if r.Account != nil {
+		s += " account=" + r.Account.Hex()
+	}
+	if r.Contract != nil {
+		s += " contract=" + r.Contract.Hex()
+	}
+	return s
+}
+
+// Policy 角色到授权的映射
+type Policy struct {
+	Roles map[string][]Grant `json:"roles"`
+}
+
+// DefaultPolicy 内置角色：
+//   - viewer     只读
+//   - operator   只读、签名交易、部署合约、调用合约写方法、管理扫描器
+//   - treasurer  只读、转账
+//   - admin      所有权限
+func DefaultPolicy() *Policy {
+	return &Policy{Roles: map[string][]Grant{
+		RoleViewer:    {{Permission: PermRead}},
+		RoleOperator:  {{Permission: PermRead}, {Permission: PermSign}, {Permission: PermDeploy}, {Permission: PermWrite}, {Permission: PermScan}},
+		RoleTreasurer: {{Permission: PermRead}, {Permission: PermTransfer}},
+		RoleAdmin:     {{Permission: PermAll}},
+	}}
+}
+
+// LoadPolicy 从 JSON 文件读取角色定义，格式与 Policy 相同：
+//
+//	{"roles": {"treasurer": [{"permission": "funds:transfer", "accounts": ["0x..."]}]}}
+//
+// 文件里的角色覆盖同名的内置角色，其余内置角色保留
+func LoadPolicy(path string) (*Policy, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file Policy
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parse policy %s: %w", path, err)
+	}
+	p := DefaultPolicy()
+	for role, grants := range file.Roles {
+		for _, g := range grants {
+			if g.Permission == "" {
+				return nil, fmt.Errorf("policy %s: role %s has a grant without permission", path, role)
+			}
+		}
+		p.Roles[role] = grants
+	}
+	return p, nil
+}
+
+// PolicyFromEnv 配置了 RBAC_POLICY_FILE 时读取该文件，否则使用内置角色
+func PolicyFromEnv() (*Policy, error) {
+	if path := os.Getenv("RBAC_POLICY_FILE"); path != "" {
+		return LoadPolicy(path)
+	}
+	return DefaultPolicy(), nil
+}
+
+// Authorize 令牌里的任意一个角色有覆盖请求的授权即通过，否则返回包装了 ErrForbidden 的错误
+func (p *Policy) Authorize(claims *Claims, req Request) error {
+	if len(claims.Roles) == 0 {
+		return fmt.Errorf("%w: no roles, %s required", ErrForbidden, req)
+	}
+	scoped := false
+	for _, role := range claims.Roles {
+		for _, g := range p.Roles[role] {
+			if g.covers(req) {
+				return nil
+			}
+			if g.Permission == req.Permission || g.Permission == PermAll {
+				scoped = true
+			}
+		}
+	}
+	if scoped {
+		return fmt.Errorf("%w: %s is outside the scope granted to roles %s", ErrForbidden, req, strings.Join(claims.Roles, ","))
+	}
+	return fmt.Errorf("%w: roles %s lack %s", ErrForbidden, strings.Join(claims.Roles, ","), req.Permission)
+}
+
+// RoleNames 已定义的角色，按名字排序
+func (p *Policy) RoleNames() []string {
+	names := make([]string, 0, len(p.Roles))
+	for name := range p.Roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsAddress(list []common.Address, addr common.Address) bool {
+	for _, a := range list {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
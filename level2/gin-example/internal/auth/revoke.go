@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Revocations 被吊销的令牌 ID（jti）和会话 ID（sid），到期后自动清理
+type Revocations struct {
+	mu     sync.RWMutex
+	fileMu sync.Mutex // 串行化文件写入
+	path   string
+	items  map[string]time.Time // id -> 令牌原本的过期时间
+}
+
+// OpenRevocations 读取吊销列表，文件不存在时从空列表开始；path 为空时只保存在内存里
+func OpenRevocations(path string) (*Revocations, error) {
+	r := &Revocations{path: path, items: make(map[string]time.Time)}
+	if path == "" {
+		return r, nil
+	}
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &r.items); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Revoke 吊销一个 ID，记录保留到 expires 之后（那时令牌本身也已经过期）
+func (r *Revocations) Revoke(id string, expires time.Time) error {
+	r.mu.Lock()
+	if old, ok := r.items[id]; !ok || expires.After(old) {
+		r.items[id] = expires
+	}
+	r.mu.Unlock()
+	return r.save()
+}
+
+// RevokeOnce 吊销一个 ID，检查和吊销在同一把锁里完成；返回这个 ID 之前是否已经被吊销过，
+// 并发调用时只有一个调用者会得到 false
+func (r *Revocations) RevokeOnce(id string, expires time.Time) (bool, error) {
+	r.mu.Lock()
+	old, revoked := r.items[id]
+	if !revoked || expires.After(old) {
+		r.items[id] = expires
+	}
+	r.mu.Unlock()
+	if revoked {
+		return true, nil
+	}
+	return false, r.save()
+}
+
+// Revoked ID 是否已被吊销
+func (r *Revocations) Revoked(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.items[id]
+	return ok
+}
+
+// Len 当前的吊销记录数
+func (r *Revocations) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.items)
+}
+
+// save 清理已经过期的记录后写入文件（先写临时文件再改名）
+func (r *Revocations) save() error {
+	r.fileMu.Lock()
+	defer r.fileMu.Unlock()
+	r.mu.Lock()
+	now := time.Now()
+	for id, exp := range r.items {
+		if exp.Before(now.Add(-leeway)) {
+			delete(r.items, id)
+		}
+	}
+	if r.path == "" {
+		r.mu.Unlock()
+		return nil
+	}
+	raw, err := json.MarshalIndent(r.items, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
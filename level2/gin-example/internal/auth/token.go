@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 令牌类型，写在 typ 声明里，防止把刷新令牌当访问令牌用
+const (
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
+)
+
+var (
+	// ErrInvalidToken 签名、格式、exp / nbf / iss / aud 校验没有通过
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrRevoked 令牌或它所在的会话已被吊销
+	ErrRevoked = errors.New("token revoked")
+	// ErrTokenType 令牌类型不对，比如用刷新令牌访问接口
+	ErrTokenType = errors.New("wrong token type")
+)
+
+// Claims 令牌里的声明：用户、角色和绑定的钱包地址
+type Claims struct {
+	UserID  string           `json:"uid"`
+	Roles   []string         `json:"roles,omitempty"`
+	Wallets []common.Address `json:"wallets,omitempty"`
+	Type    string           `json:"typ"`
+	Session string           `json:"sid"` // 同一次登录签发的访问令牌和刷新令牌共用，退出时整个会话一起吊销
+	jwt.RegisteredClaims
+}
+
+// HasRole 是否拥有某个角色
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasWallet 钱包地址是否绑定在这个用户上
+func (c *Claims) HasWallet(addr common.Address) bool {
+	for _, w := range c.Wallets {
+		if w == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Subject 签发令牌时的用户信息
+type Subject struct {
+	UserID  string
+	Roles   []string
+	Wallets []common.Address
+}
+
+// TokenPair 一次登录或刷新得到的令牌
+type TokenPair struct {
+	AccessToken      string    `json:"accessToken"`
+	RefreshToken     string    `json:"refreshToken"`
+	TokenType        string    `json:"tokenType"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+}
+
+// Manager 签发、校验、刷新和吊销令牌
+type Manager struct {
+	cfg     *Config
+	revoked *Revocations
+}
+
+// NewManager 按配置创建 Manager，并加载吊销列表
+func NewManager(cfg *Config) (*Manager, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	revoked, err := OpenRevocations(cfg.RevocationFile)
+	if err != nil {
+		return nil, fmt.Errorf("open revocation list: %w", err)
+	}
+	return &Manager{cfg: cfg, revoked: revoked}, nil
+}
+
+// Issue 为用户签发一对新令牌（新会话）
+func (m *Manager) Issue(sub Subject) (*TokenPair, error) {
+	return m.issue(sub, randomID())
+}
+
+func (m *Manager) issue(sub Subject, session string) (*TokenPair, error) {
+	now := time.Now()
+	pair := &TokenPair{
+		TokenType:        "Bearer",
+		ExpiresAt:        now.Add(m.cfg.AccessTTL),
+		RefreshExpiresAt: now.Add(m.cfg.RefreshTTL),
+	}
+	var err error
+	if pair.AccessToken, err = m.sign(sub, TypeAccess, session, now, pair.ExpiresAt); err != nil {
+		return nil, err
+	}
+	if pair.RefreshToken, err = m.sign(sub, TypeRefresh, session, now, pair.RefreshExpiresAt); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// sign 有 ES256 私钥时用 ES256，否则用 HS256
+func (m *Manager) sign(sub Subject, typ, session string, now, expires time.Time) (string, error) {
+	claims := &Claims{
+		UserID:  sub.UserID,
+		Roles:   sub.Roles,
+		Wallets: sub.Wallets,
+		Type:    typ,
+		Session: session,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.cfg.Issuer,
+			Subject:   sub.UserID,
+			Audience:  jwt.ClaimStrings{m.cfg.Audience},
+			ExpiresAt: jwt.NewNumericDate(expires),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        randomID(),
+		},
+	}
+	if m.cfg.ECDSAKey != nil {
+		return jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(m.cfg.ECDSAKey)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.cfg.HMACSecret)
+}
+
+// Verify 校验签名、exp、nbf、iss、aud 和令牌类型，再检查吊销列表
+func (m *Manager) Verify(raw, typ string) (*Claims, error) {
+	claims, err := m.parse(raw, typ)
+	if err != nil {
+		return nil, err
+	}
+	if m.revoked.Revoked(claims.ID) || m.revoked.Revoked(claims.Session) {
+		return nil, ErrRevoked
+	}
+	return claims, nil
+}
+
+// parse 校验签名、exp、nbf、iss、aud 和令牌类型，不检查吊销列表
+func (m *Manager) parse(raw, typ string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(raw, claims, m.key,
+		jwt.WithValidMethods(m.methods()),
+		jwt.WithIssuer(m.cfg.Issuer),
+		jwt.WithAudience(m.cfg.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(leeway),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if claims.Type != typ {
+		return nil, fmt.Errorf("%w: got %q, want %q", ErrTokenType, claims.Type, typ)
+	}
+	return claims, nil
+}
+
+// Refresh 用刷新令牌换一对新令牌。旧的刷新令牌立即吊销，同一个刷新令牌只能用一次；
+// 已经用过的刷新令牌再次出现说明它可能被盗用，整个会话一起吊销
+func (m *Manager) Refresh(raw string) (*TokenPair, error) {
+	claims, err := m.parse(raw, TypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	if m.revoked.Revoked(claims.Session) {
+		return nil, ErrRevoked
+	}
+	reused, err := m.revoked.RevokeOnce(claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		if err := m.RevokeSession(claims); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: refresh token reused, session revoked", ErrRevoked)
+	}
+	return m.issue(Subject{UserID: claims.UserID, Roles: claims.Roles, Wallets: claims.Wallets}, claims.Session)
+}
+
+// RevokeSession 吊销整个会话（退出登录），会话内的访问令牌和刷新令牌都会失效
+func (m *Manager) RevokeSession(claims *Claims) error {
+	// 会话最长持续到刷新令牌过期
+	return m.revoked.Revoke(claims.Session, time.Now().Add(m.cfg.RefreshTTL))
+}
+
+// RevokeToken 只吊销一个令牌
+func (m *Manager) RevokeToken(claims *Claims) error {
+	return m.revoked.Revoke(claims.ID, claims.ExpiresAt.Time)
+}
+
+// Revocations 吊销列表
+func (m *Manager) Revocations() *Revocations {
+	return m.revoked
+}
+
+// key 按令牌头里的算法选择校验密钥
+func (m *Manager) key(token *jwt.Token) (interface{}, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if m.cfg.HMACSecret != nil {
+			return m.cfg.HMACSecret, nil
+		}
+	case jwt.SigningMethodES256.Alg():
+		if m.cfg.ECDSAKey != nil {
+			return &m.cfg.ECDSAKey.PublicKey, nil
+		}
+	}
+	return nil, fmt.Errorf("no key for algorithm %s", token.Method.Alg())
+}
+
+// methods 只接受配置了密钥的算法，避免算法混淆
+func (m *Manager) methods() []string {
+	var methods []string
+	if m.cfg.HMACSecret != nil {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if m.cfg.ECDSAKey != nil {
+		methods = append(methods, jwt.SigningMethodES256.Alg())
+	}
+	return methods
+}
+
+func randomID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	m, err := NewManager(&Config{
+		Issuer:     "test",
+		Audience:   "test",
+		AccessTTL:  time.Minute,
+		RefreshTTL: time.Hour,
+		HMACSecret: []byte("0123456789abcdef0123456789abcdef"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	m := newTestManager(t)
+	first, err := m.Issue(Subject{UserID: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 旧的刷新令牌再次使用：拒绝，并且同一会话里新签发的令牌也失效
+	if _, err := m.Refresh(first.RefreshToken); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("reused refresh token = %v, want ErrRevoked", err)
+	}
+	if _, err := m.Verify(second.AccessToken, TypeAccess); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("access token after reuse = %v, want ErrRevoked", err)
+	}
+	if _, err := m.Refresh(second.RefreshToken); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("refresh token after reuse = %v, want ErrRevoked", err)
+	}
+}
+
+func TestConcurrentRefresh(t *testing.T) {
+	m := newTestManager(t)
+	pair, err := m.Issue(Subject{UserID: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Refresh(pair.RefreshToken); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 1 {
+		t.Fatalf("%d concurrent refreshes succeeded, want exactly 1", succeeded)
+	}
+}
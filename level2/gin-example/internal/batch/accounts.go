@@ -0,0 +1,173 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// DefaultChunkSize 每个批量请求包含的调用数，公共节点大多限制在 100~1000 之间
+	DefaultChunkSize = 100
+	// concurrency 同时发出的批量请求数
+	concurrency = 4
+)
+
+// 可以查询的字段
+const (
+	FieldBalance = "balance"
+	FieldNonce   = "nonce"
+	FieldCode    = "code"
+)
+
+// Caller rpc.Client 的批量调用能力，方便用本地的假节点测试
+type Caller interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+// Account 一个地址的查询结果，单个字段失败只记录在 Errors 里，不影响其它字段和其它地址
+type Account struct {
+	Address    string            `json:"address"`
+	Balance    string            `json:"balance,omitempty"`
+	Nonce      *uint64           `json:"nonce,omitempty"`
+	CodeSize   *int              `json:"codeSize,omitempty"`
+	IsContract *bool             `json:"isContract,omitempty"`
+	Errors     map[string]string `json:"errors,omitempty"` // 字段名 -> 错误
+}
+
+func (a *Account) fail(field string, err error) {
+	if a.Errors == nil {
+		a.Errors = make(map[string]string)
+	}
+	a.Errors[field] = err.Error()
+}
+
+// Options 批量查询参数
+type Options struct {
+	Block     string   // 已经转换好的区块参数：latest / pending / 0x...
+	Fields    []string // 默认查询全部字段
+	ChunkSize int      // 默认 DefaultChunkSize
+	// MaxChunkSize 节点允许的单个批量请求最大调用数，ChunkSize 超过时按它截断；0 表示不限制
+	MaxChunkSize int
+}
+
+// BlockArg 把区块号转换成 JSON-RPC 参数，nil 表示最新区块
+func BlockArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(number)
+}
+
+// call 一次 JSON-RPC 调用和它对应的地址、字段
+type call struct {
+	account *Account
+	field   string
+	elem    rpc.BatchElem
+	err     error
+}
+
+// Lookup 用 eth_getBalance / eth_getTransactionCount / eth_getCode 批量查询地址，
+// 所有调用按 ChunkSize 分成多个批量请求并发发送。返回结果和 addresses 顺序一致，
+// 以及实际发出的批量请求数。
+func Lookup(ctx context.Context, caller Caller, addresses []common.Address, opts Options) ([]*Account, int) {
+	if opts.Block == "" {
+		opts.Block = "latest"
+	}
+	if len(opts.Fields) == 0 {
+		opts.Fields = []string{FieldBalance, FieldNonce, FieldCode}
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	if opts.MaxChunkSize > 0 {
+		chunkSize = min(chunkSize, opts.MaxChunkSize)
+	}
+
+	accounts := make([]*Account, len(addresses))
+	var calls []*call
+	for i, addr := range addresses {
+		accounts[i] = &Account{Address: addr.Hex()}
+		for _, field := range opts.Fields {
+			calls = append(calls, newCall(accounts[i], addr, field, opts.Block))
+		}
+	}
+
+	var chunks [][]*call
+	for start := 0; start < len(calls); start += chunkSize {
+		chunks = append(chunks, calls[start:min(start+chunkSize, len(calls))])
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, chunk := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(chunk []*call) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			runChunk(ctx, caller, chunk)
+		}(chunk)
+	}
+	wg.Wait()
+
+	// 一个地址的几个字段可能分在不同批次里，等所有批次完成后再统一写结果
+	for _, c := range calls {
+		if c.err != nil {
+			c.account.fail(c.field, c.err)
+			continue
+		}
+		c.apply(c.elem.Result)
+	}
+	return accounts, len(chunks)
+}
+
+func newCall(account *Account, addr common.Address, field, block string) *call {
+	c := &call{account: account, field: field}
+	switch field {
+	case FieldBalance:
+		c.elem = rpc.BatchElem{Method: "eth_getBalance", Args: []interface{}{addr, block}, Result: new(hexutil.Big)}
+	case FieldNonce:
+		c.elem = rpc.BatchElem{Method: "eth_getTransactionCount", Args: []interface{}{addr, block}, Result: new(hexutil.Uint64)}
+	case FieldCode:
+		c.elem = rpc.BatchElem{Method: "eth_getCode", Args: []interface{}{addr, block}, Result: new(hexutil.Bytes)}
+	}
+	return c
+}
+
+// runChunk 整个批量请求失败时（网络错误、超过节点限制）把错误记到这一批的每个调用上
+func runChunk(ctx context.Context, caller Caller, chunk []*call) {
+	elems := make([]rpc.BatchElem, len(chunk))
+	for i, c := range chunk {
+		elems[i] = c.elem
+	}
+	err := caller.BatchCallContext(ctx, elems)
+	for i, c := range chunk {
+		c.err = err
+		if c.err == nil {
+			c.err = elems[i].Error
+		}
+	}
+}
+
+func (c *call) apply(result interface{}) {
+	a := c.account
+	switch v := result.(type) {
+	case *hexutil.Big:
+		a.Balance = v.ToInt().String()
+	case *hexutil.Uint64:
+		nonce := uint64(*v)
+		a.Nonce = &nonce
+	case *hexutil.Bytes:
+		size := len(*v)
+		isContract := size > 0
+		a.CodeSize, a.IsContract = &size, &isContract
+	default:
+		a.fail(c.field, fmt.Errorf("unexpected result %T", result))
+	}
+}
@@ -0,0 +1,72 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeCaller 本地的假节点：记录每个批量请求的大小，超过 limit 的批量请求整体失败
+type fakeCaller struct {
+	limit int
+
+	mu    sync.Mutex
+	sizes []int
+}
+
+func (f *fakeCaller) BatchCallContext(_ context.Context, b []rpc.BatchElem) error {
+	f.mu.Lock()
+	f.sizes = append(f.sizes, len(b))
+	f.mu.Unlock()
+	if len(b) > f.limit {
+		return errors.New("batch too large")
+	}
+	for i := range b {
+		switch r := b[i].Result.(type) {
+		case *hexutil.Big:
+			*r = hexutil.Big(*common.Big1)
+		case *hexutil.Uint64:
+			*r = 7
+		case *hexutil.Bytes:
+			*r = nil
+		}
+	}
+	return nil
+}
+
+func TestLookupClampsChunkSize(t *testing.T) {
+	caller := &fakeCaller{limit: 10}
+	addresses := make([]common.Address, 12)
+	for i := range addresses {
+		addresses[i] = common.Address{byte(i + 1)}
+	}
+	// 客户端要求 1000 个调用一批，节点只允许 10 个
+	accounts, batches := Lookup(context.Background(), caller, addresses, Options{ChunkSize: 1000, MaxChunkSize: 10})
+	if batches != 4 {
+		t.Fatalf("batches = %d, want 4 for 36 calls of at most 10", batches)
+	}
+	for _, size := range caller.sizes {
+		if size > 10 {
+			t.Fatalf("sent a batch of %d calls, limit is 10", size)
+		}
+	}
+	for _, a := range accounts {
+		if len(a.Errors) > 0 || a.Balance != "1" || a.Nonce == nil || *a.Nonce != 7 || *a.IsContract {
+			t.Fatalf("account = %+v", a)
+		}
+	}
+}
+
+func TestLookupBatchFailure(t *testing.T) {
+	caller := &fakeCaller{limit: 2}
+	accounts, _ := Lookup(context.Background(), caller, []common.Address{{1}}, Options{Fields: []string{FieldBalance, FieldNonce, FieldCode}})
+	// 整个批量请求失败时每个字段都记录错误
+	if got := len(accounts[0].Errors); got != 3 {
+		t.Fatalf("errors = %v, want one per field", accounts[0].Errors)
+	}
+}
@@ -0,0 +1,196 @@
+package bytecode
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	// EIP-1167 最小代理：363d3d373d3d3d363d73 <20 字节实现地址> 5af43d82803e903d91602b57fd5bf3
+	minimalProxyPrefix = hexutil.MustDecode("0x363d3d373d3d3d363d73")
+	minimalProxySuffix = hexutil.MustDecode("0x5af43d82803e903d91602b57fd5bf3")
+
+	// EIP-1967 实现地址所在的存储槽 bytes32(uint256(keccak256("eip1967.proxy.implementation")) - 1)
+	EIP1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	// EIP-1967 beacon 地址所在的存储槽
+	EIP1967BeaconSlot = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+)
+
+// Instruction 一条反汇编指令
+type Instruction struct {
+	PC     int    `json:"pc"`
+	Op     string `json:"op"`
+	Opcode byte   `json:"opcode"`
+	Push   string `json:"push,omitempty"` // PUSH 的立即数
+}
+
+func (i Instruction) String() string {
+	if i.Push != "" {
+		return fmt.Sprintf("%06x  %s %s", i.PC, i.Op, i.Push)
+	}
+	return fmt.Sprintf("%06x  %s", i.PC, i.Op)
+}
+
+// Disassemble 逐字节解码，PUSH 的立即数不会被当作操作码；代码末尾被截断的 PUSH 数据右侧补零
+func Disassemble(code []byte) []Instruction {
+	var out []Instruction
+	for pc := 0; pc < len(code); pc++ {
+		op := code[pc]
+		ins := Instruction{PC: pc, Op: opName(op), Opcode: op}
+		if n := pushSize(op); n > 0 {
+			data := make([]byte, n)
+			copy(data, code[min(pc+1, len(code)):min(pc+1+n, len(code))])
+			ins.Push = hexutil.Encode(data)
+			pc += n
+		}
+		out = append(out, ins)
+	}
+	return out
+}
+
+// FormatText 每行一条指令，供命令行输出
+func FormatText(instructions []Instruction) string {
+	var sb strings.Builder
+	for _, ins := range instructions {
+		sb.WriteString(ins.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Selector 分发器中找到的函数选择器，以及在已知 ABI 中匹配到的签名
+type Selector struct {
+	Selector   string   `json:"selector"`
+	Signatures []string `json:"signatures,omitempty"`
+}
+
+// Proxy 代理合约特征
+type Proxy struct {
+	Kind           string          `json:"kind"` // eip1167 / eip1967 / delegatecall
+	Implementation *common.Address `json:"implementation,omitempty"`
+}
+
+// Analysis 字节码分析结果
+type Analysis struct {
+	Size            int           `json:"size"`
+	Selectors       []Selector    `json:"selectors"`
+	HasDelegateCall bool          `json:"hasDelegateCall"`
+	HasSelfDestruct bool          `json:"hasSelfDestruct"`
+	Proxy           *Proxy        `json:"proxy,omitempty"`
+	Metadata        *Metadata     `json:"metadata,omitempty"`
+	Instructions    []Instruction `json:"instructions,omitempty"`
+}
+
+// Analyze 反汇编并提取选择器、代理和 SELFDESTRUCT 特征。abis 用来把选择器匹配成函数签名。
+func Analyze(code []byte, abis ...abi.ABI) *Analysis {
+	a := &Analysis{Size: len(code)}
+	body, meta, ok := SplitMetadata(code)
+	if ok {
+		a.Metadata, _ = DecodeMetadata(meta)
+	}
+	instructions := Disassemble(body)
+	a.Instructions = instructions
+
+	known := selectorSignatures(abis)
+	for _, sel := range dispatcherSelectors(instructions) {
+		a.Selectors = append(a.Selectors, Selector{Selector: sel, Signatures: known[sel]})
+	}
+
+	for _, ins := range instructions {
+		switch ins.Opcode {
+		case opDELEGATECALL:
+			a.HasDelegateCall = true
+		case opSELFDESTRUCT:
+			a.HasSelfDestruct = true
+		}
+	}
+	a.Proxy = detectProxy(code, instructions, a.HasDelegateCall)
+	return a
+}
+
+// dispatcherSelectors solc 的分发器对每个函数生成 PUSH4 <selector> EQ（可能中间夹一个 DUP），
+// 用 GT/LT 做二分查找的 PUSH4 不算。选择器开头是 0 字节时 solc 用更短的 PUSH1-PUSH3（比如 ERC-1155 的
+// balanceOf 0x00fdd58e 是 PUSH3 0xfdd58e），这种短 PUSH 在普通代码里也常见，所以还要求 EQ 后面紧跟 PUSH <tag> JUMPI
+func dispatcherSelectors(instructions []Instruction) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for i, ins := range instructions {
+		n := pushSize(ins.Opcode)
+		if n == 0 || n > 4 || ins.Push == "0xffffffff" {
+			continue
+		}
+		for j := i + 1; j < len(instructions) && j <= i+2; j++ {
+			next := instructions[j]
+			if next.Opcode == opEQ {
+				if n < 4 && !jumpsAfter(instructions, j) {
+					break
+				}
+				sel := hexutil.Encode(common.LeftPadBytes(hexutil.MustDecode(ins.Push), 4))
+				if !seen[sel] {
+					seen[sel] = true
+					out = append(out, sel)
+				}
+				break
+			}
+			if next.Opcode < opDUP1 || next.Opcode > opDUP16 {
+				break
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// jumpsAfter 第 i 条指令之后是 PUSH <tag> JUMPI，即比较结果直接用于跳转
+func jumpsAfter(instructions []Instruction, i int) bool {
+	return i+2 < len(instructions) && pushSize(instructions[i+1].Opcode) > 0 && instructions[i+2].Opcode == opJUMPI
+}
+
+// selectorSignatures 选择器 -> 已知 ABI 中的函数签名
+func selectorSignatures(abis []abi.ABI) map[string][]string {
+	out := make(map[string][]string)
+	for _, parsed := range abis {
+		for _, m := range parsed.Methods {
+			sel := hexutil.Encode(m.ID)
+			if !contains(out[sel], m.Sig) {
+				out[sel] = append(out[sel], m.Sig)
+			}
+		}
+	}
+	for _, sigs := range out {
+		sort.Strings(sigs)
+	}
+	return out
+}
+
+func detectProxy(code []byte, instructions []Instruction, hasDelegateCall bool) *Proxy {
+	if len(code) == len(minimalProxyPrefix)+common.AddressLength+len(minimalProxySuffix) &&
+		bytes.HasPrefix(code, minimalProxyPrefix) && bytes.HasSuffix(code, minimalProxySuffix) {
+		impl := common.BytesToAddress(code[len(minimalProxyPrefix) : len(minimalProxyPrefix)+common.AddressLength])
+		return &Proxy{Kind: "eip1167", Implementation: &impl}
+	}
+	if !hasDelegateCall {
+		return nil
+	}
+	for _, ins := range instructions {
+		if ins.Push == EIP1967ImplementationSlot.Hex() || ins.Push == EIP1967BeaconSlot.Hex() {
+			return &Proxy{Kind: "eip1967"}
+		}
+	}
+	return &Proxy{Kind: "delegatecall"}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
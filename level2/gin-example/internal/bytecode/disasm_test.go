@@ -0,0 +1,31 @@
+package bytecode
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestDispatcherSelectors(t *testing.T) {
+	// DUP1 PUSH4 0xa9059cbb EQ PUSH2 JUMPI
+	// DUP1 PUSH3 0xfdd58e EQ PUSH2 JUMPI       选择器 0x00fdd58e
+	// DUP1 PUSH1 0x01 EQ ISZERO                普通比较，不是选择器
+	// DUP1 PUSH4 0xffffffff EQ PUSH2 JUMPI     掩码
+	code := hexutil.MustDecode("0x8063a9059cbb1461002a578062fdd58e1461003f5780600114158063ffffffff1461004f57")
+	got := dispatcherSelectors(Disassemble(code))
+	want := []string{"0x00fdd58e", "0xa9059cbb"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("selectors = %v, want %v", got, want)
+	}
+}
+
+func TestDisassembleTruncatedPush(t *testing.T) {
+	ins := Disassemble(hexutil.MustDecode("0x6001630102"))
+	if len(ins) != 2 {
+		t.Fatalf("got %d instructions, want 2", len(ins))
+	}
+	if ins[1].Op != "PUSH4" || ins[1].Push != "0x01020000" {
+		t.Fatalf("truncated push = %s %s, want PUSH4 0x01020000", ins[1].Op, ins[1].Push)
+	}
+}
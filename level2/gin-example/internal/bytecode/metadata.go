@@ -0,0 +1,157 @@
+package bytecode
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Metadata solc 追加在 runtime 字节码末尾的 CBOR 元数据
+// https://docs.soliditylang.org/en/latest/metadata.html#encoding-of-the-metadata-hash-in-the-bytecode
+type Metadata struct {
+	Solc         string `json:"solc,omitempty"` // 如 0.8.26
+	IPFS         string `json:"ipfs,omitempty"` // base58 编码的 CID，如 Qm...
+	Bzzr0        string `json:"bzzr0,omitempty"`
+	Bzzr1        string `json:"bzzr1,omitempty"`
+	Experimental bool   `json:"experimental,omitempty"`
+	Raw          string `json:"raw"`
+}
+
+// SplitMetadata 字节码最后 2 字节是 CBOR 数据的长度，据此切分出代码主体和元数据
+func SplitMetadata(code []byte) (body []byte, meta []byte, ok bool) {
+	if len(code) < 2 {
+		return code, nil, false
+	}
+	n := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	if n == 0 || n+2 > len(code) {
+		return code, nil, false
+	}
+	meta = code[len(code)-2-n : len(code)-2]
+	if meta[0]&0xe0 != 0xa0 { // CBOR map
+		return code, nil, false
+	}
+	return code[:len(code)-2-n], meta, true
+}
+
+// DecodeMetadata 解码 solc 的 CBOR 元数据（只支持 solc 会用到的 map/text/bytes/bool）
+func DecodeMetadata(meta []byte) (*Metadata, error) {
+	d := &cborDecoder{data: meta}
+	major, count, err := d.header()
+	if err != nil {
+		return nil, err
+	}
+	if major != 5 {
+		return nil, errors.New("metadata is not a cbor map")
+	}
+	m := &Metadata{Raw: hexutil.Encode(meta)}
+	for i := uint64(0); i < count; i++ {
+		key, err := d.text()
+		if err != nil {
+			return nil, err
+		}
+		major, n, err := d.header()
+		if err != nil {
+			return nil, err
+		}
+		var value []byte
+		switch major {
+		case 2, 3: // byte string / text string
+			if value, err = d.take(n); err != nil {
+				return nil, err
+			}
+		case 7: // false / true
+		default:
+			return nil, fmt.Errorf("unsupported cbor type %d for key %s", major, key)
+		}
+		switch key {
+		case "solc":
+			if major == 3 {
+				m.Solc = string(value) // 非正式版本以字符串保存
+			} else if len(value) == 3 {
+				m.Solc = fmt.Sprintf("%d.%d.%d", value[0], value[1], value[2])
+			}
+		case "ipfs":
+			m.IPFS = base58Encode(value)
+		case "bzzr0":
+			m.Bzzr0 = hexutil.Encode(value)
+		case "bzzr1":
+			m.Bzzr1 = hexutil.Encode(value)
+		case "experimental":
+			m.Experimental = major == 7 && n == 21
+		}
+	}
+	return m, nil
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+// header 读取类型和长度；简单值（major 7）返回其编码值，如 true 为 21
+func (d *cborDecoder) header() (major byte, n uint64, err error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, errors.New("unexpected end of cbor data")
+	}
+	b := d.data[d.pos]
+	d.pos++
+	major, info := b>>5, b&0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info <= 27:
+		size := 1 << (info - 24)
+		raw, err := d.take(uint64(size))
+		if err != nil {
+			return 0, 0, err
+		}
+		return major, new(big.Int).SetBytes(raw).Uint64(), nil
+	}
+	return 0, 0, fmt.Errorf("unsupported cbor additional info %d", info)
+}
+
+func (d *cborDecoder) take(n uint64) ([]byte, error) {
+	if uint64(len(d.data)-d.pos) < n {
+		return nil, errors.New("unexpected end of cbor data")
+	}
+	out := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return out, nil
+}
+
+func (d *cborDecoder) text() (string, error) {
+	major, n, err := d.header()
+	if err != nil {
+		return "", err
+	}
+	if major != 3 {
+		return "", errors.New("cbor map key is not a string")
+	}
+	s, err := d.take(n)
+	return string(s), err
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Encode IPFS CID（multihash）使用的 base58btc 编码
+func base58Encode(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix, mod := big.NewInt(58), new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
@@ -0,0 +1,126 @@
+package bytecode
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"level2/gin-example/internal/deploy"
+)
+
+// 比对结果
+const (
+	MatchExact    = "exact"    // 完全一致（immutable 除外）
+	MatchPartial  = "partial"  // 只有元数据不同，通常是源码注释、路径或编译设置不同
+	MatchMismatch = "mismatch" // 代码本身不同
+)
+
+// Verification 链上 runtime 字节码与本地编译产物的比对结果
+type Verification struct {
+	Contract         string    `json:"contract"`
+	Status           string    `json:"status"`
+	OnchainSize      int       `json:"onchainSize"`
+	ExpectedSize     int       `json:"expectedSize"`
+	MaskedImmutables int       `json:"maskedImmutables"`
+	FirstDifference  int       `json:"firstDifference"` // 第一个不同的字节偏移（不含元数据），一致时为 -1
+	DerivedRuntime   bool      `json:"derivedRuntime"`  // 产物里没有 deployedBytecode，runtime 取自创建字节码的末尾
+	OnchainMetadata  *Metadata `json:"onchainMetadata,omitempty"`
+	ExpectedMetadata *Metadata `json:"expectedMetadata,omitempty"`
+}
+
+// Verify 比对链上代码和产物的 deployedBytecode：
+// 先把 immutable 和库地址的位置清零，再分别比较代码主体和 CBOR 元数据
+func Verify(onchain []byte, artifact *deploy.Artifact) (*Verification, error) {
+	if len(onchain) == 0 {
+		return nil, errors.New("no code at address")
+	}
+	v := &Verification{Contract: artifact.Name, OnchainSize: len(onchain), FirstDifference: -1}
+
+	expectedHex := artifact.DeployedBytecode
+	if expectedHex == "" {
+		// solc --bin 只有创建字节码：runtime 是它的末尾（没有构造参数时）
+		creation := deploy.CleanHex(artifact.Bytecode)
+		if len(creation) < len(onchain)*2 {
+			return nil, errors.New("artifact has no deployed bytecode")
+		}
+		expectedHex = creation[len(creation)-len(onchain)*2:]
+		v.DerivedRuntime = true
+	}
+	expected, libMasks, err := decodeWithPlaceholders(expectedHex)
+	if err != nil {
+		return nil, err
+	}
+	v.ExpectedSize = len(expected)
+
+	got := append([]byte{}, onchain...)
+	masks := libMasks
+	for _, refs := range artifact.ImmutableReferences {
+		masks = append(masks, refs...)
+		v.MaskedImmutables += len(refs)
+	}
+	for _, m := range masks {
+		zero(got, m)
+		zero(expected, m)
+	}
+
+	gotBody, gotMeta, gotOK := SplitMetadata(got)
+	expBody, expMeta, expOK := SplitMetadata(expected)
+	if gotOK {
+		v.OnchainMetadata, _ = DecodeMetadata(gotMeta)
+	}
+	if expOK {
+		v.ExpectedMetadata, _ = DecodeMetadata(expMeta)
+	}
+
+	switch {
+	case bytes.Equal(got, expected):
+		v.Status = MatchExact
+	case bytes.Equal(gotBody, expBody):
+		v.Status = MatchPartial
+	default:
+		v.Status = MatchMismatch
+		v.FirstDifference = firstDifference(gotBody, expBody)
+	}
+	return v, nil
+}
+
+// decodeWithPlaceholders deployedBytecode 里可能还留着库占位符，解码时把它们当作 20 字节的 0，并记录位置
+func decodeWithPlaceholders(s string) ([]byte, []deploy.Offset, error) {
+	s = deploy.CleanHex(s)
+	var masks []deploy.Offset
+	for {
+		idx := strings.Index(s, "__")
+		if idx < 0 {
+			break
+		}
+		if idx%2 != 0 || idx+40 > len(s) {
+			return nil, nil, errors.New("malformed library placeholder")
+		}
+		s = s[:idx] + strings.Repeat("0", 40) + s[idx+40:]
+		masks = append(masks, deploy.Offset{Start: idx / 2, Length: 20})
+	}
+	code, err := hexutil.Decode("0x" + s)
+	return code, masks, err
+}
+
+func zero(code []byte, m deploy.Offset) {
+	for i := m.Start; i < m.Start+m.Length && i < len(code); i++ {
+		code[i] = 0
+	}
+}
+
+func firstDifference(a, b []byte) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return len(a)
+		}
+		return len(b)
+	}
+	return -1
+}
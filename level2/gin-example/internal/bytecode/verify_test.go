@@ -0,0 +1,98 @@
+package bytecode
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"level2/gin-example/internal/deploy"
+)
+
+// testdata/Counter.* 是 solc 0.8.21（--optimize，evmVersion paris）编译 testdata/Counter.sol 得到的 abi、创建字节码和 runtime 字节码
+func loadCounter(t *testing.T) (*deploy.Artifact, []byte) {
+	t.Helper()
+	artifact, err := deploy.LoadBinArtifact("testdata/Counter.abi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile("testdata/Counter.bin-runtime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return artifact, common.FromHex(strings.TrimSpace(string(raw)))
+}
+
+func TestSplitAndDecodeMetadata(t *testing.T) {
+	_, runtime := loadCounter(t)
+	body, meta, ok := SplitMetadata(runtime)
+	if !ok {
+		t.Fatal("no metadata found")
+	}
+	// 0.8.x 的元数据是 {ipfs: 34 字节, solc: 3 字节}，CBOR 编码 51 字节，后面跟 2 字节长度
+	if len(meta) != 0x33 || len(body)+len(meta)+2 != len(runtime) {
+		t.Fatalf("body %d bytes, metadata %d bytes, code %d bytes", len(body), len(meta), len(runtime))
+	}
+	m, err := DecodeMetadata(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Solc != "0.8.21" {
+		t.Errorf("solc = %q, want 0.8.21", m.Solc)
+	}
+	if !strings.HasPrefix(m.IPFS, "Qm") || len(m.IPFS) != 46 {
+		t.Errorf("ipfs = %q, want a CIDv0", m.IPFS)
+	}
+
+	// 没有元数据尾巴的代码
+	if _, _, ok := SplitMetadata(body); ok {
+		t.Error("metadata found in stripped code")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	artifact, runtime := loadCounter(t)
+	body, meta, _ := SplitMetadata(runtime)
+
+	v, err := Verify(runtime, artifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Status != MatchExact || v.FirstDifference != -1 || v.OnchainMetadata == nil {
+		t.Fatalf("identical code: %+v", v)
+	}
+
+	// 只有元数据不同（比如源码里改了注释）：换掉 IPFS 哈希的一个字节
+	otherMeta := append([]byte{}, meta...)
+	otherMeta[10] ^= 0xff
+	onchain := append(append(append([]byte{}, body...), otherMeta...), runtime[len(runtime)-2:]...)
+	if v, err = Verify(onchain, artifact); err != nil {
+		t.Fatal(err)
+	}
+	if v.Status != MatchPartial || v.OnchainMetadata.IPFS == v.ExpectedMetadata.IPFS {
+		t.Fatalf("metadata-only change: %+v", v)
+	}
+
+	// 代码主体不同
+	onchain = append([]byte{}, runtime...)
+	onchain[20] ^= 0xff
+	if v, err = Verify(onchain, artifact); err != nil {
+		t.Fatal(err)
+	}
+	if v.Status != MatchMismatch || v.FirstDifference != 20 {
+		t.Fatalf("code change: %+v", v)
+	}
+
+	// 只有创建字节码时 runtime 从它的末尾取
+	artifact.DeployedBytecode = ""
+	if v, err = Verify(runtime, artifact); err != nil {
+		t.Fatal(err)
+	}
+	if v.Status != MatchExact || !v.DerivedRuntime {
+		t.Fatalf("derived runtime: %+v", v)
+	}
+	if !bytes.HasSuffix(common.FromHex(artifact.Bytecode), runtime) {
+		t.Fatal("creation code does not end with the runtime code")
+	}
+}
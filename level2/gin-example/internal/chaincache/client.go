@@ -0,0 +1,394 @@
+package chaincache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// 缓存的数据种类，也是磁盘缓存的子目录名
+const (
+	KindBlock    = "block"
+	KindBlockNum = "blocknum" // 区块号 -> 区块哈希
+	KindTx       = "tx"
+	KindReceipt  = "receipt"
+	KindReceipts = "receipts" // 整个区块的收据
+	KindCode     = "code"
+)
+
+// headTTL 最新区块号的缓存时间。头部旧一点只会让判定更保守，不会缓存未确认的数据
+const headTTL = 4 * time.Second
+
+// Options 缓存配置
+type Options struct {
+	// Size 内存 LRU 的条目数
+	Size int
+	// Dir 磁盘缓存目录，为空时只用内存
+	Dir string
+	// FinalityDepth 距离最新区块至少这么多块的数据才缓存，避免缓存可能被重组的数据
+	FinalityDepth uint64
+	// Devnet 本地开发链（anvil、hardhat）重启后，同样的区块号和交易哈希会对应不同的数据。
+	// 缓存按创世区块哈希区分：磁盘缓存放在 <Dir>/<创世区块哈希> 下，发现创世区块变了就清空内存缓存
+	Devnet bool
+}
+
+// Client 在 ethclient 前面加一层缓存，只缓存不会再变化的数据：按哈希查询的区块、交易、收据，
+// 以及固定区块上的合约代码。其它方法直接使用内嵌的 *ethclient.Client。
+type Client struct {
+	*ethclient.Client
+
+	mem    *lru
+	disk   atomic.Pointer[disk] // 开发链上知道创世区块之前为空
+	dir    string
+	devnet bool
+	depth  uint64
+	stats  map[string]*kindStats
+
+	headMu  sync.Mutex
+	head    uint64
+	headAt  time.Time
+	genesis common.Hash // 只在开发链上使用
+}
+
+// New 创建缓存客户端
+func New(client *ethclient.Client, opts Options) *Client {
+	c := &Client{
+		Client: client,
+		mem:    newLRU(max(opts.Size, 1)),
+		dir:    opts.Dir,
+		devnet: opts.Devnet,
+		depth:  opts.FinalityDepth,
+		stats:  make(map[string]*kindStats),
+	}
+	if opts.Dir != "" && !opts.Devnet {
+		c.disk.Store(&disk{dir: opts.Dir})
+	}
+	for _, kind := range []string{KindBlock, KindBlockNum, KindTx, KindReceipt, KindReceipts, KindCode} {
+		c.stats[kind] = &kindStats{}
+	}
+	return c
+}
+
+type kindStats struct {
+	memoryHits atomic.Uint64
+	diskHits   atomic.Uint64
+	misses     atomic.Uint64
+	notFinal   atomic.Uint64 // 数据还没有达到确认深度，没有缓存
+}
+
+// KindStats 一类数据的命中统计
+type KindStats struct {
+	MemoryHits uint64  `json:"memoryHits"`
+	DiskHits   uint64  `json:"diskHits"`
+	Misses     uint64  `json:"misses"`
+	NotFinal   uint64  `json:"notFinal"`
+	HitRate    float64 `json:"hitRate"`
+}
+
+// Stats 缓存统计
+type Stats struct {
+	Entries       int                  `json:"entries"`
+	Disk          bool                 `json:"disk"`
+	FinalityDepth uint64               `json:"finalityDepth"`
+	Kinds         map[string]KindStats `json:"kinds"`
+}
+
+// Stats 返回各类数据的命中率
+func (c *Client) Stats() Stats {
+	s := Stats{Entries: c.mem.len(), Disk: c.dir != "", FinalityDepth: c.depth, Kinds: make(map[string]KindStats)}
+	for kind, ks := range c.stats {
+		k := KindStats{
+			MemoryHits: ks.memoryHits.Load(),
+			DiskHits:   ks.diskHits.Load(),
+			Misses:     ks.misses.Load(),
+			NotFinal:   ks.notFinal.Load(),
+		}
+		if total := k.MemoryHits + k.DiskHits + k.Misses; total > 0 {
+			k.HitRate = float64(k.MemoryHits+k.DiskHits) / float64(total)
+		}
+		s.Kinds[kind] = k
+	}
+	return s
+}
+
+// lookup 先查内存再查磁盘，磁盘命中时解码并放回内存。开发链上最新区块号过期时先重新检查创世区块，
+// 节点重启后不会继续返回旧链上缓存的数据
+func (c *Client) lookup(ctx context.Context, kind, key string, decode func([]byte) (interface{}, error)) (interface{}, bool) {
+	if c.devnet {
+		c.headMu.Lock()
+		fresh := time.Since(c.headAt) <= headTTL
+		c.headMu.Unlock()
+		if !fresh {
+			// 查询失败时照常查缓存，未命中时的请求会把错误报出来
+			c.refreshHead(ctx)
+		}
+	}
+	if v, ok := c.mem.get(kind + ":" + key); ok {
+		c.stats[kind].memoryHits.Add(1)
+		return v, true
+	}
+	if data, ok := c.disk.Load().get(kind, key); ok {
+		if v, err := decode(data); err == nil {
+			c.stats[kind].diskHits.Add(1)
+			c.mem.add(kind+":"+key, v)
+			return v, true
+		}
+	}
+	c.stats[kind].misses.Add(1)
+	return nil, false
+}
+
+func (c *Client) store(kind, key string, v interface{}, encode func() ([]byte, error)) {
+	c.mem.add(kind+":"+key, v)
+	d := c.disk.Load()
+	if d == nil {
+		return
+	}
+	data, err := encode()
+	if err == nil {
+		err = d.put(kind, key, data)
+	}
+	if err != nil {
+		log.Printf("chain cache: write %s/%s: %v", kind, key, err)
+	}
+}
+
+// final 区块是否已经达到确认深度
+func (c *Client) final(ctx context.Context, number uint64) bool {
+	c.headMu.Lock()
+	head, fresh := c.head, time.Since(c.headAt) <= headTTL
+	c.headMu.Unlock()
+	if !fresh {
+		// 查询节点时不持有锁，一个慢请求不会卡住其它查询
+		var err error
+		if head, err = c.refreshHead(ctx); err != nil {
+			return false
+		}
+	}
+	return number+c.depth <= head
+}
+
+// refreshHead 查询最新区块号；开发链上同时检查创世区块，节点重启过就换掉缓存
+func (c *Client) refreshHead(ctx context.Context) (uint64, error) {
+	head, err := c.Client.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var genesis common.Hash
+	if c.devnet {
+		header, err := c.Client.HeaderByNumber(ctx, common.Big0)
+		if err != nil {
+			return 0, err
+		}
+		genesis = header.Hash()
+	}
+
+	c.headMu.Lock()
+	defer c.headMu.Unlock()
+	c.head, c.headAt = head, time.Now()
+	if c.devnet && genesis != c.genesis {
+		c.genesis = genesis
+		c.mem.reset()
+		if c.dir != "" {
+			c.disk.Store(&disk{dir: filepath.Join(c.dir, genesis.Hex())})
+		}
+	}
+	return head, nil
+}
+
+// BlockByHash 按哈希查询区块，确认后的区块会被缓存
+func (c *Client) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if v, ok := c.lookup(ctx, KindBlock, hash.Hex(), decodeBlock); ok {
+		return v.(*types.Block), nil
+	}
+	block, err := c.Client.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	c.storeBlock(ctx, block)
+	return block, nil
+}
+
+// BlockByNumber 只有具体的、已确认的区块号才走缓存；nil（最新区块）和 pending 等特殊值直接查询
+func (c *Client) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if number == nil || number.Sign() < 0 || !number.IsUint64() {
+		return c.Client.BlockByNumber(ctx, number)
+	}
+	if v, ok := c.lookup(ctx, KindBlockNum, number.String(), decodeHash); ok {
+		if block, err := c.BlockByHash(ctx, v.(common.Hash)); err == nil {
+			return block, nil
+		}
+	}
+	block, err := c.Client.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if c.storeBlock(ctx, block) {
+		hash := block.Hash()
+		c.store(KindBlockNum, number.String(), hash, func() ([]byte, error) { return hash.Bytes(), nil })
+	}
+	return block, nil
+}
+
+func (c *Client) storeBlock(ctx context.Context, block *types.Block) bool {
+	if !c.final(ctx, block.NumberU64()) {
+		c.stats[KindBlock].notFinal.Add(1)
+		return false
+	}
+	c.store(KindBlock, block.Hash().Hex(), block, func() ([]byte, error) { return rlp.EncodeToBytes(block) })
+	return true
+}
+
+// cachedTx 缓存的交易，只缓存已打包的交易
+type cachedTx struct {
+	Tx          *types.Transaction `json:"tx"`
+	BlockNumber uint64             `json:"blockNumber"`
+}
+
+// TransactionByHash 按哈希查询交易。直接调用 eth_getTransactionByHash 以便拿到所在区块号判断是否已确认
+func (c *Client) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	if v, ok := c.lookup(ctx, KindTx, hash.Hex(), decodeTx); ok {
+		return v.(*cachedTx).Tx, false, nil
+	}
+	var raw json.RawMessage
+	if err := c.Client.Client().CallContext(ctx, &raw, "eth_getTransactionByHash", hash); err != nil {
+		return nil, false, err
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, false, ethereum.NotFound
+	}
+	var meta struct {
+		BlockNumber *hexutil.Big `json:"blockNumber"`
+	}
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return nil, false, err
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalJSON(raw); err != nil {
+		return nil, false, err
+	}
+	if meta.BlockNumber == nil {
+		return tx, true, nil
+	}
+	number := meta.BlockNumber.ToInt().Uint64()
+	if !c.final(ctx, number) {
+		c.stats[KindTx].notFinal.Add(1)
+		return tx, false, nil
+	}
+	entry := &cachedTx{Tx: tx, BlockNumber: number}
+	c.store(KindTx, hash.Hex(), entry, func() ([]byte, error) { return json.Marshal(entry) })
+	return tx, false, nil
+}
+
+// TransactionReceipt 已确认区块中的收据会被缓存
+func (c *Client) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	if v, ok := c.lookup(ctx, KindReceipt, hash.Hex(), decodeReceipt); ok {
+		return v.(*types.Receipt), nil
+	}
+	receipt, err := c.Client.TransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !c.final(ctx, receipt.BlockNumber.Uint64()) {
+		c.stats[KindReceipt].notFinal.Add(1)
+		return receipt, nil
+	}
+	c.store(KindReceipt, hash.Hex(), receipt, func() ([]byte, error) { return json.Marshal(receipt) })
+	return receipt, nil
+}
+
+// BlockReceipts 按区块哈希查询时缓存整个区块的收据；按区块号查询直接转发
+func (c *Client) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	hash, ok := blockNrOrHash.Hash()
+	if !ok {
+		return c.Client.BlockReceipts(ctx, blockNrOrHash)
+	}
+	if v, ok := c.lookup(ctx, KindReceipts, hash.Hex(), decodeReceipts); ok {
+		return v.([]*types.Receipt), nil
+	}
+	receipts, err := c.Client.BlockReceipts(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	// 空区块没有收据可以判断区块号，不缓存
+	if len(receipts) == 0 || !c.final(ctx, receipts[0].BlockNumber.Uint64()) {
+		c.stats[KindReceipts].notFinal.Add(1)
+		return receipts, nil
+	}
+	c.store(KindReceipts, hash.Hex(), receipts, func() ([]byte, error) { return json.Marshal(receipts) })
+	return receipts, nil
+}
+
+// CodeAt 指定了已确认区块号时缓存代码，nil（最新区块）直接查询
+func (c *Client) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	if blockNumber == nil || blockNumber.Sign() < 0 || !blockNumber.IsUint64() {
+		return c.Client.CodeAt(ctx, account, blockNumber)
+	}
+	key := fmt.Sprintf("%s-%d", account.Hex(), blockNumber.Uint64())
+	if v, ok := c.lookup(ctx, KindCode, key, func(b []byte) (interface{}, error) { return b, nil }); ok {
+		return v.([]byte), nil
+	}
+	code, err := c.Client.CodeAt(ctx, account, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if !c.final(ctx, blockNumber.Uint64()) {
+		c.stats[KindCode].notFinal.Add(1)
+		return code, nil
+	}
+	c.store(KindCode, key, code, func() ([]byte, error) { return code, nil })
+	return code, nil
+}
+
+func decodeBlock(data []byte) (interface{}, error) {
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(data, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func decodeHash(data []byte) (interface{}, error) {
+	if len(data) != common.HashLength {
+		return nil, fmt.Errorf("invalid hash length %d", len(data))
+	}
+	return common.BytesToHash(data), nil
+}
+
+func decodeTx(data []byte) (interface{}, error) {
+	entry := new(cachedTx)
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func decodeReceipt(data []byte) (interface{}, error) {
+	receipt := new(types.Receipt)
+	if err := json.Unmarshal(data, receipt); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+func decodeReceipts(data []byte) (interface{}, error) {
+	var receipts []*types.Receipt
+	if err := json.Unmarshal(data, &receipts); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
@@ -0,0 +1,168 @@
+package chaincache
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// standIn 本地替身节点：回答 eth_blockNumber、创世区块头和 eth_getCode，记录 eth_getCode 的次数
+type standIn struct {
+	*httptest.Server
+	mu      sync.Mutex
+	head    uint64
+	genesis byte // 写进创世区块的 extraData，改变它就相当于节点重启成了一条新链
+	code    string
+	getCode atomic.Int64
+}
+
+func newStandIn(t *testing.T, head uint64) *standIn {
+	s := &standIn{head: head, code: "0x6001"}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *standIn) genesisHeader() *types.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &types.Header{Difficulty: big.NewInt(1), Number: big.NewInt(0), Extra: []byte{s.genesis}}
+}
+
+func (s *standIn) restart(code string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.genesis++
+	s.code = code
+}
+
+func (s *standIn) serve(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	head, code := s.head, s.code
+	s.mu.Unlock()
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	switch req.Method {
+	case "eth_blockNumber":
+		resp["result"] = hexutil.EncodeUint64(head)
+	case "eth_getBlockByNumber": // 只会查询创世区块
+		resp["result"] = s.genesisHeader()
+	case "eth_getCode":
+		s.getCode.Add(1)
+		resp["result"] = code
+	default:
+		resp["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func dial(t *testing.T, s *standIn, opts Options) *Client {
+	client, err := ethclient.Dial(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return New(client, opts)
+}
+
+// 比最新区块减确认深度更新的数据每次都查询节点，不进缓存
+func TestFinalGating(t *testing.T) {
+	s := newStandIn(t, 100)
+	c := dial(t, s, Options{Size: 16, Dir: t.TempDir(), FinalityDepth: 5})
+	ctx := context.Background()
+	addr := common.HexToAddress("0x1")
+
+	for _, tt := range []struct {
+		block  int64
+		cached bool
+	}{
+		{96, false}, // 96+5 > 100
+		{100, false},
+		{95, true}, // 正好达到确认深度
+		{10, true},
+	} {
+		before := s.getCode.Load()
+		for i := 0; i < 2; i++ {
+			if _, err := c.CodeAt(ctx, addr, big.NewInt(tt.block)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want := int64(2)
+		if tt.cached {
+			want = 1
+		}
+		if got := s.getCode.Load() - before; got != want {
+			t.Errorf("block %d: %d eth_getCode calls, want %d", tt.block, got, want)
+		}
+	}
+	if got := c.Stats().Kinds[KindCode].NotFinal; got != 4 {
+		t.Errorf("notFinal = %d, want 4", got)
+	}
+	// 最新区块（nil）从不缓存
+	before := s.getCode.Load()
+	c.CodeAt(ctx, addr, nil)
+	c.CodeAt(ctx, addr, nil)
+	if got := s.getCode.Load() - before; got != 2 {
+		t.Errorf("latest: %d eth_getCode calls, want 2", got)
+	}
+}
+
+// 开发链重启（创世区块变了）后不再返回旧链的缓存，磁盘缓存换到新的创世区块目录下
+func TestDevnetGenesisSwitch(t *testing.T) {
+	s := newStandIn(t, 100)
+	dir := t.TempDir()
+	c := dial(t, s, Options{Size: 16, Dir: dir, FinalityDepth: 5, Devnet: true})
+	ctx := context.Background()
+	addr := common.HexToAddress("0x1")
+	block := big.NewInt(10)
+
+	oldGenesis := s.genesisHeader().Hash()
+	code, err := c.CodeAt(ctx, addr, block)
+	if err != nil || hexutil.Encode(code) != "0x6001" {
+		t.Fatalf("CodeAt = %x, %v", code, err)
+	}
+	key := addr.Hex() + "-10"
+	if _, err := os.Stat(filepath.Join(dir, oldGenesis.Hex(), KindCode, key)); err != nil {
+		t.Fatalf("not cached under the genesis directory: %v", err)
+	}
+
+	s.restart("0x6002")
+	// 最新区块号过期后，下一次查询（即使会命中缓存）先重新检查创世区块
+	c.headMu.Lock()
+	c.headAt = time.Now().Add(-2 * headTTL)
+	c.headMu.Unlock()
+
+	code, err = c.CodeAt(ctx, addr, block)
+	if err != nil || hexutil.Encode(code) != "0x6002" {
+		t.Fatalf("after restart CodeAt = %x, %v, want 0x6002", code, err)
+	}
+	newGenesis := s.genesisHeader().Hash()
+	if _, err := os.Stat(filepath.Join(dir, newGenesis.Hex(), KindCode, key)); err != nil {
+		t.Fatalf("not cached under the new genesis directory: %v", err)
+	}
+	// 旧链的文件留在原目录，不会被新链读到
+	if _, err := os.Stat(filepath.Join(dir, oldGenesis.Hex(), KindCode, key)); err != nil {
+		t.Fatalf("old genesis directory: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package chaincache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// disk 磁盘缓存，每条数据一个文件：<dir>/<kind>/<key>
+type disk struct {
+	dir string
+}
+
+func (d *disk) path(kind, key string) string {
+	return filepath.Join(d.dir, kind, key)
+}
+
+// get 不存在时返回 false，读失败也当作未命中
+func (d *disk) get(kind, key string) ([]byte, bool) {
+	if d == nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(d.path(kind, key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// put 先写临时文件再改名，进程中途退出也不会留下写了一半的文件
+func (d *disk) put(kind, key string, data []byte) error {
+	if d == nil {
+		return nil
+	}
+	path := d.path(kind, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Join(err, os.Remove(tmp))
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ConvertArgs 把 JSON 参数按 ABI 输入类型转换成 abi.Pack 需要的 Go 类型
+func ConvertArgs(inputs abi.Arguments, raw []json.RawMessage) ([]interface{}, error) {
+	if len(raw) != len(inputs) {
+		return nil, fmt.Errorf("argument count mismatch: want %d, got %d", len(inputs), len(raw))
+	}
+	args := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		var v interface{}
+		dec := json.NewDecoder(bytes.NewReader(raw[i]))
+		dec.UseNumber() // 大整数不能经过 float64
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("argument %d (%s): %w", i, input.Name, err)
+		}
+		arg, err := ConvertArg(input.Type, v)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d (%s %s): %w", i, input.Type.String(), input.Name, err)
+		}
+		args[i] = arg
+	}
+	return args, nil
+}
+
+// ConvertArg 把一个 JSON 解码后的值（json.Number/string/bool/[]interface{}/map）转换成 ABI 类型对应的 Go 值
+func ConvertArg(t abi.Type, v interface{}) (interface{}, error) {
+	rv, err := convert(t, v)
+	if err != nil {
+		return nil, err
+	}
+	return rv.Interface(), nil
+}
+
+func convert(t abi.Type, v interface{}) (reflect.Value, error) {
+	switch t.T {
+	case abi.AddressTy:
+		s, ok := v.(string)
+		if !ok || !common.IsHexAddress(s) {
+			return reflect.Value{}, fmt.Errorf("invalid address %v", v)
+		}
+		return reflect.ValueOf(common.HexToAddress(s)), nil
+
+	case abi.IntTy, abi.UintTy:
+		n, err := toBigInt(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if err := checkIntRange(t, n); err != nil {
+			return reflect.Value{}, err
+		}
+		return intValue(t, n), nil
+
+	case abi.BoolTy:
+		switch b := v.(type) {
+		case bool:
+			return reflect.ValueOf(b), nil
+		case string:
+			if b == "true" || b == "false" {
+				return reflect.ValueOf(b == "true"), nil
+			}
+		}
+		return reflect.Value{}, fmt.Errorf("invalid bool %v", v)
+
+	case abi.StringTy:
+		s, ok := v.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("invalid string %v", v)
+		}
+		return reflect.ValueOf(s), nil
+
+	case abi.BytesTy:
+		b, err := toBytes(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(b), nil
+
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b, err := toBytes(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		size := t.Size
+		if t.T == abi.FunctionTy {
+			size = 24
+		}
+		// 十六进制必须正好 N 字节，少写一位不会被悄悄补零；文本按 copy(key[:], []byte("foo")) 的习惯右侧补零
+		if s := v.(string); isHex(s) && len(b) != size {
+			return reflect.Value{}, fmt.Errorf("expected %d bytes for %s, got %d", size, t.String(), len(b))
+		}
+		if len(b) > size {
+			return reflect.Value{}, fmt.Errorf("value too long for %s: %d bytes", t.String(), len(b))
+		}
+		arr := reflect.New(t.GetType()).Elem()
+		reflect.Copy(arr, reflect.ValueOf(b))
+		return arr, nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		items, ok := v.([]interface{})
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected array for %s", t.String())
+		}
+		var out reflect.Value
+		if t.T == abi.ArrayTy {
+			if len(items) != t.Size {
+				return reflect.Value{}, fmt.Errorf("expected %d elements for %s, got %d", t.Size, t.String(), len(items))
+			}
+			out = reflect.New(t.GetType()).Elem()
+		} else {
+			out = reflect.MakeSlice(t.GetType(), len(items), len(items))
+		}
+		for i, item := range items {
+			elem, err := convert(*t.Elem, item)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+
+	case abi.TupleTy:
+		out := reflect.New(t.GetType()).Elem()
+		switch fields := v.(type) {
+		case map[string]interface{}: // 按字段名
+			for i, name := range t.TupleRawNames {
+				fv, ok := fields[name]
+				if !ok {
+					return reflect.Value{}, fmt.Errorf("missing tuple field %q", name)
+				}
+				elem, err := convert(*t.TupleElems[i], fv)
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("%s: %w", name, err)
+				}
+				out.Field(i).Set(elem)
+			}
+		case []interface{}: // 按位置
+			if len(fields) != len(t.TupleElems) {
+				return reflect.Value{}, fmt.Errorf("expected %d tuple fields, got %d", len(t.TupleElems), len(fields))
+			}
+			for i, fv := range fields {
+				elem, err := convert(*t.TupleElems[i], fv)
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("[%d]: %w", i, err)
+				}
+				out.Field(i).Set(elem)
+			}
+		default:
+			return reflect.Value{}, fmt.Errorf("expected object or array for %s", t.String())
+		}
+		return out, nil
+	}
+	return reflect.Value{}, fmt.Errorf("unsupported abi type %s", t.String())
+}
+
+// toBigInt 支持 JSON 数字、十进制字符串和 0x 开头的十六进制字符串
+func toBigInt(v interface{}) (*big.Int, error) {
+	var s string
+	switch n := v.(type) {
+	case json.Number:
+		s = n.String()
+	case string:
+		s = n
+	default:
+		return nil, fmt.Errorf("invalid integer %v", v)
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	return n, nil
+}
+
+func checkIntRange(t abi.Type, n *big.Int) error {
+	if t.T == abi.UintTy {
+		if n.Sign() < 0 || n.BitLen() > t.Size {
+			return fmt.Errorf("%s out of range for uint%d", n, t.Size)
+		}
+		return nil
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return fmt.Errorf("%s out of range for int%d", n, t.Size)
+	}
+	return nil
+}
+
+// intValue 8/16/32/64 位整数 abi 包要求原生类型，其他位数用 *big.Int
+func intValue(t abi.Type, n *big.Int) reflect.Value {
+	goType := t.GetType()
+	switch goType.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflect.ValueOf(n.Uint64()).Convert(goType)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.ValueOf(n.Int64()).Convert(goType)
+	}
+	return reflect.ValueOf(n)
+}
+
+// toBytes 0x 开头按十六进制解析，否则按 UTF-8 文本处理
+func toBytes(v interface{}) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid bytes %v", v)
+	}
+	if isHex(s) {
+		return hexutil.Decode(s)
+	}
+	return []byte(s), nil
+}
+
+func isHex(s string) bool {
+	return strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X")
+}
+
+// FormatValues 把解码出来的返回值转换成方便 JSON 输出的形式，按输出参数名（没有名字用下标）组织
+func FormatValues(outputs abi.Arguments, values []interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(values))
+	for i, v := range values {
+		name := outputs[i].Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i)
+		}
+		out[name] = FormatValue(outputs[i].Type, v)
+	}
+	return out
+}
+
+// FormatValue 大整数转十进制字符串，字节转十六进制，tuple 转对象
+func FormatValue(t abi.Type, v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		if n, ok := v.(*big.Int); ok {
+			return n.String()
+		}
+		return fmt.Sprintf("%d", v)
+	case abi.AddressTy:
+		return v.(common.Address).Hex()
+	case abi.BytesTy:
+		return hexutil.Encode(v.([]byte))
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return hexutil.Encode(b)
+	case abi.SliceTy, abi.ArrayTy:
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = FormatValue(*t.Elem, rv.Index(i).Interface())
+		}
+		return items
+	case abi.TupleTy:
+		fields := make(map[string]interface{}, len(t.TupleElems))
+		for i, name := range t.TupleRawNames {
+			if name == "" {
+				name = fmt.Sprintf("%d", i)
+			}
+			fields[name] = FormatValue(*t.TupleElems[i], rv.Field(i).Interface())
+		}
+		return fields
+	}
+	return v
+}
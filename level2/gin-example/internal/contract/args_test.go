@@ -0,0 +1,92 @@
+package contract
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func mustType(t *testing.T, typ string, components []abi.ArgumentMarshaling) abi.Type {
+	t.Helper()
+	ty, err := abi.NewType(typ, "", components)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ty
+}
+
+func TestConvertArgs(t *testing.T) {
+	addr := common.HexToAddress("0x5B38Da6a701c568545dCfcB03FcB875f56beddC4")
+	pair := []abi.ArgumentMarshaling{{Name: "to", Type: "address"}, {Name: "amount", Type: "uint256"}}
+	tests := []struct {
+		typ        string
+		components []abi.ArgumentMarshaling
+		raw        string
+		want       interface{}
+		wantErr    bool
+	}{
+		{typ: "address", raw: `"0x5B38Da6a701c568545dCfcB03FcB875f56beddC4"`, want: addr},
+		{typ: "address", raw: `"0x1234"`, wantErr: true},
+		{typ: "uint8", raw: `255`, want: uint8(255)},
+		{typ: "uint8", raw: `256`, wantErr: true},
+		{typ: "uint64", raw: `"0xffffffffffffffff"`, want: uint64(1<<64 - 1)},
+		{typ: "uint256", raw: `115792089237316195423570985008687907853269984665640564039457584007913129639935`,
+			want: new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))},
+		{typ: "uint256", raw: `-1`, wantErr: true},
+		{typ: "int64", raw: `-9223372036854775808`, want: int64(-1 << 63)},
+		{typ: "int256", raw: `"-42"`, want: big.NewInt(-42)},
+		{typ: "int8", raw: `128`, wantErr: true},
+		{typ: "bool", raw: `true`, want: true},
+		{typ: "bool", raw: `"false"`, want: false},
+		{typ: "bool", raw: `1`, wantErr: true},
+		{typ: "string", raw: `"hello"`, want: "hello"},
+		{typ: "string", raw: `1`, wantErr: true},
+		{typ: "bytes", raw: `"0x0102"`, want: []byte{1, 2}},
+		{typ: "bytes", raw: `"ab"`, want: []byte("ab")},
+		{typ: "bytes4", raw: `"0xa9059cbb"`, want: [4]byte{0xa9, 0x05, 0x9c, 0xbb}},
+		{typ: "bytes4", raw: `"0xa9059c"`, wantErr: true}, // 少一个字节不能补零
+		{typ: "bytes4", raw: `"0xa9059cbb00"`, wantErr: true},
+		{typ: "bytes4", raw: `"foo"`, want: [4]byte{'f', 'o', 'o', 0}},
+		{typ: "bytes4", raw: `"fooba"`, wantErr: true},
+		{typ: "uint256[]", raw: `[1, "0x2"]`, want: []*big.Int{big.NewInt(1), big.NewInt(2)}},
+		{typ: "uint256[]", raw: `"1"`, wantErr: true},
+		{typ: "address[2]", raw: `["0x5B38Da6a701c568545dCfcB03FcB875f56beddC4", "0x5B38Da6a701c568545dCfcB03FcB875f56beddC4"]`, want: [2]common.Address{addr, addr}},
+		{typ: "address[2]", raw: `["0x5B38Da6a701c568545dCfcB03FcB875f56beddC4"]`, wantErr: true},
+		{typ: "tuple", components: pair, raw: `{"to": "0x5B38Da6a701c568545dCfcB03FcB875f56beddC4", "amount": 7}`},
+		{typ: "tuple", components: pair, raw: `["0x5B38Da6a701c568545dCfcB03FcB875f56beddC4", 7]`},
+		{typ: "tuple", components: pair, raw: `{"to": "0x5B38Da6a701c568545dCfcB03FcB875f56beddC4"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		ty := mustType(t, tt.typ, tt.components)
+		inputs := abi.Arguments{{Name: "x", Type: ty}}
+		args, err := ConvertArgs(inputs, []json.RawMessage{json.RawMessage(tt.raw)})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s %s: expected error, got %v", tt.typ, tt.raw, args[0])
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.typ, tt.raw, err)
+			continue
+		}
+		// 转换结果必须能被 abi 包编码
+		if _, err := inputs.Pack(args...); err != nil {
+			t.Errorf("%s %s: pack: %v", tt.typ, tt.raw, err)
+		}
+		if tt.want != nil && !reflect.DeepEqual(args[0], tt.want) {
+			t.Errorf("%s %s = %#v, want %#v", tt.typ, tt.raw, args[0], tt.want)
+		}
+	}
+}
+
+func TestConvertArgsCount(t *testing.T) {
+	inputs := abi.Arguments{{Name: "x", Type: mustType(t, "uint256", nil)}}
+	if _, err := ConvertArgs(inputs, nil); err == nil {
+		t.Fatal("expected argument count error")
+	}
+}
@@ -0,0 +1,103 @@
+package contract
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DecodedCall 按 ABI 解码出来的 calldata
+type DecodedCall struct {
+	Method string                 `json:"method"`
+	Args   map[string]interface{} `json:"args,omitempty"`
+	Error  string                 `json:"error,omitempty"` // 选择器匹配但参数解码失败
+}
+
+// DecodeCalldata 用选择器在 ABI 中查找方法并解码参数；选择器不在 ABI 中时返回 nil
+func DecodeCalldata(parsed abi.ABI, data []byte) *DecodedCall {
+	if len(data) < 4 {
+		return nil
+	}
+	method, err := parsed.MethodById(data[:4])
+	if err != nil {
+		return nil
+	}
+	call := &DecodedCall{Method: method.Sig}
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		call.Error = err.Error()
+		return call
+	}
+	call.Args = FormatValues(method.Inputs, values)
+	return call
+}
+
+// DecodedEvent 按 ABI 解码出来的事件
+type DecodedEvent struct {
+	Event string                 `json:"event"`
+	Args  map[string]interface{} `json:"args"`
+}
+
+// DecodeLog 依次尝试每个 ABI，topic0 和 indexed 参数个数都对得上才算匹配
+// （ERC-20 和 ERC-721 的 Transfer 事件 topic0 相同，只能靠 indexed 个数区分）。
+// 没有匹配的事件时返回 nil。
+func DecodeLog(log *types.Log, abis ...abi.ABI) *DecodedEvent {
+	if len(log.Topics) == 0 {
+		return nil
+	}
+	for _, parsed := range abis {
+		event, err := parsed.EventByID(log.Topics[0])
+		if err != nil || event.Anonymous {
+			continue
+		}
+		if decoded, err := decodeEvent(event, log); err == nil {
+			return decoded
+		}
+	}
+	return nil
+}
+
+func decodeEvent(event *abi.Event, log *types.Log) (*DecodedEvent, error) {
+	indexed := len(event.Inputs) - len(event.Inputs.NonIndexed())
+	if indexed != len(log.Topics)-1 {
+		return nil, fmt.Errorf("event %s expects %d indexed args, log has %d topics", event.Sig, indexed, len(log.Topics)-1)
+	}
+	values, err := event.Inputs.Unpack(log.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make(map[string]interface{}, len(event.Inputs))
+	topics, data := log.Topics[1:], values
+	for i, arg := range event.Inputs {
+		if !arg.Indexed {
+			args[argName(arg, i)] = FormatValue(arg.Type, data[0])
+			data = data[1:]
+			continue
+		}
+		topic := topics[0]
+		topics = topics[1:]
+		switch arg.Type.T {
+		case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+			// 动态类型作为 indexed 参数时 topic 里只有 keccak256 哈希，无法还原
+			args[argName(arg, i)] = hexutil.Encode(topic.Bytes())
+		default:
+			value := make(map[string]interface{}, 1)
+			if err := abi.ParseTopicsIntoMap(value, abi.Arguments{arg}, []common.Hash{topic}); err != nil {
+				return nil, err
+			}
+			args[argName(arg, i)] = FormatValue(arg.Type, value[arg.Name])
+		}
+	}
+	return &DecodedEvent{Event: event.Sig, Args: args}, nil
+}
+
+func argName(arg abi.Argument, i int) string {
+	if arg.Name != "" {
+		return arg.Name
+	}
+	return fmt.Sprintf("%d", i)
+}
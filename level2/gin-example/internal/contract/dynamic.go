@@ -0,0 +1,110 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// FindMethod 按方法名、完整签名（如 transfer(address,uint256)）或 4 字节选择器查找方法。
+// 重载的方法只给名字时会报错，并列出所有候选签名。
+func FindMethod(parsed abi.ABI, ident string) (abi.Method, error) {
+	if strings.HasPrefix(ident, "0x") {
+		selector, err := hexutil.Decode(ident)
+		if err != nil || len(selector) != 4 {
+			return abi.Method{}, fmt.Errorf("invalid selector %s", ident)
+		}
+		m, err := parsed.MethodById(selector)
+		if err != nil {
+			return abi.Method{}, err
+		}
+		return *m, nil
+	}
+	if strings.Contains(ident, "(") {
+		sig := strings.ReplaceAll(ident, " ", "")
+		for _, m := range parsed.Methods {
+			if m.Sig == sig {
+				return m, nil
+			}
+		}
+		return abi.Method{}, fmt.Errorf("method %s not found", ident)
+	}
+
+	var candidates []abi.Method
+	for _, m := range parsed.Methods {
+		if m.RawName == ident {
+			candidates = append(candidates, m)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return abi.Method{}, fmt.Errorf("method %s not found", ident)
+	case 1:
+		return candidates[0], nil
+	}
+	sigs := make([]string, len(candidates))
+	for i, m := range candidates {
+		sigs[i] = m.Sig
+	}
+	sort.Strings(sigs)
+	return abi.Method{}, fmt.Errorf("method %s is overloaded, use one of: %s", ident, strings.Join(sigs, ", "))
+}
+
+// Pack 把 JSON 参数转换并编码成 calldata
+func Pack(method abi.Method, raw []json.RawMessage) ([]byte, error) {
+	args, err := ConvertArgs(method.Inputs, raw)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := method.Inputs.Pack(args...)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, method.ID...), packed...), nil
+}
+
+// CallResult eth_call 的结果
+type CallResult struct {
+	Method  string                 `json:"method"`
+	Raw     string                 `json:"raw"`
+	Outputs map[string]interface{} `json:"outputs"`
+}
+
+// Call 通过 eth_call 调用方法并解码返回值，block 为 nil 表示最新区块
+func Call(ctx context.Context, caller ethereum.ContractCaller, to common.Address, method abi.Method,
+	raw []json.RawMessage, from common.Address, value *big.Int, block *big.Int) (*CallResult, error) {
+	data, err := Pack(method, raw)
+	if err != nil {
+		return nil, err
+	}
+	out, err := caller.CallContract(ctx, ethereum.CallMsg{From: from, To: &to, Data: data, Value: value}, block)
+	if err != nil {
+		return nil, err
+	}
+	values, err := method.Outputs.Unpack(out)
+	if err != nil {
+		return nil, fmt.Errorf("unpack outputs of %s: %w", method.Sig, err)
+	}
+	return &CallResult{Method: method.Sig, Raw: hexutil.Encode(out), Outputs: FormatValues(method.Outputs, values)}, nil
+}
+
+// Send 签名并发送交易，nonce、gas 和手续费由 bind 自动填充（opts 里已设置的值优先）
+func Send(opts *bind.TransactOpts, backend bind.ContractBackend, to common.Address, parsed abi.ABI,
+	method abi.Method, raw []json.RawMessage) (*types.Transaction, error) {
+	data, err := Pack(method, raw)
+	if err != nil {
+		return nil, err
+	}
+	bound := bind.NewBoundContract(to, parsed, backend, backend, backend)
+	return bound.RawTransact(opts, data)
+}
@@ -0,0 +1,148 @@
+package deploy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// ErrArtifactNotFound 在给定目录中找不到合约的编译产物
+var ErrArtifactNotFound = errors.New("artifact not found")
+
+// Offset 字节码中一段需要替换的位置（库地址占位符或 immutable 变量）
+type Offset struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// Artifact 一个合约的编译产物，字节码都是不带 0x 的十六进制，可能含有库占位符
+type Artifact struct {
+	Name                string
+	Source              string
+	RawABI              json.RawMessage
+	ABI                 abi.ABI
+	Bytecode            string
+	DeployedBytecode    string
+	LinkReferences      map[string]map[string][]Offset // 源文件 -> 库名 -> 位置
+	ImmutableReferences map[string][]Offset            // AST id -> 位置，只在 deployedBytecode 中
+}
+
+// remixArtifact Remix 编译产物格式，如 task2/artifacts/Counter.json
+type remixArtifact struct {
+	ABI  json.RawMessage `json:"abi"`
+	Data struct {
+		Bytecode struct {
+			Object         string                         `json:"object"`
+			LinkReferences map[string]map[string][]Offset `json:"linkReferences"`
+		} `json:"bytecode"`
+		DeployedBytecode struct {
+			Object              string              `json:"object"`
+			ImmutableReferences map[string][]Offset `json:"immutableReferences"`
+		} `json:"deployedBytecode"`
+	} `json:"data"`
+}
+
+// LoadRemixArtifact 读取 Remix 生成的 <Name>.json
+func LoadRemixArtifact(path string) (*Artifact, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ra remixArtifact
+	if err := json.Unmarshal(raw, &ra); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	if len(ra.ABI) == 0 || ra.Data.Bytecode.Object == "" {
+		return nil, fmt.Errorf("%s is not a contract artifact", path)
+	}
+	a := &Artifact{
+		Name:                strings.TrimSuffix(filepath.Base(path), ".json"),
+		Source:              path,
+		RawABI:              ra.ABI,
+		Bytecode:            ra.Data.Bytecode.Object,
+		DeployedBytecode:    ra.Data.DeployedBytecode.Object,
+		LinkReferences:      ra.Data.Bytecode.LinkReferences,
+		ImmutableReferences: ra.Data.DeployedBytecode.ImmutableReferences,
+	}
+	return a, a.parseABI()
+}
+
+// LoadBinArtifact 读取 solc --abi --bin 生成的 X.abi + X.bin（如 pkg/Store_sol_Store.*），
+// 同目录下有 X.bin-runtime 时一并读取
+func LoadBinArtifact(abiPath string) (*Artifact, error) {
+	base := strings.TrimSuffix(abiPath, ".abi")
+	rawABI, err := os.ReadFile(abiPath)
+	if err != nil {
+		return nil, err
+	}
+	bin, err := os.ReadFile(base + ".bin")
+	if err != nil {
+		return nil, err
+	}
+	a := &Artifact{
+		Name:     contractName(filepath.Base(base)),
+		Source:   base + ".bin",
+		RawABI:   rawABI,
+		Bytecode: CleanHex(string(bin)),
+	}
+	if runtime, err := os.ReadFile(base + ".bin-runtime"); err == nil {
+		a.DeployedBytecode = CleanHex(string(runtime))
+	}
+	return a, a.parseABI()
+}
+
+// FindArtifact 依次在各目录里按合约名查找 <name>.json 或 *_<name>.abi + .bin。
+// name 来自请求，只和目录里列出的文件名比较，不拼进路径，../ 之类的名字找不到任何文件
+func FindArtifact(dirs []string, name string) (*Artifact, error) {
+	if !filepath.IsLocal(name) || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("%w: invalid contract name %q", ErrArtifactNotFound, name)
+	}
+	for _, dir := range dirs {
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if !e.IsDir() && e.Name() == name+".json" {
+				return LoadRemixArtifact(filepath.Join(dir, e.Name()))
+			}
+		}
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.abi"))
+		for _, abiPath := range matches {
+			base := strings.TrimSuffix(abiPath, ".abi")
+			if contractName(filepath.Base(base)) == name && fileExists(base+".bin") {
+				return LoadBinArtifact(abiPath)
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrArtifactNotFound, name)
+}
+
+func (a *Artifact) parseABI() error {
+	parsed, err := abi.JSON(strings.NewReader(string(a.RawABI)))
+	if err != nil {
+		return fmt.Errorf("parse abi of %s: %w", a.Name, err)
+	}
+	a.ABI = parsed
+	return nil
+}
+
+// contractName Store_sol_Store -> Store，与 registry 对 .abi 文件的命名一致
+func contractName(base string) string {
+	if idx := strings.LastIndex(base, "_"); idx >= 0 {
+		return base[idx+1:]
+	}
+	return base
+}
+
+// CleanHex 去掉首尾空白和 0x 前缀，.bin 文件和 Remix 构件里两种写法都有
+func CleanHex(s string) string {
+	return strings.TrimPrefix(strings.TrimSpace(s), "0x")
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
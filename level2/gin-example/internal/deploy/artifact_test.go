@@ -0,0 +1,39 @@
+package deploy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testRemixArtifact = `{"abi":[],"data":{"bytecode":{"object":"6080","linkReferences":{}},"deployedBytecode":{"object":"6080"}}}`
+
+func TestFindArtifactStaysInDir(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "artifacts")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{
+		filepath.Join(dir, "Counter.json"): testRemixArtifact,
+		filepath.Join(root, "Secret.json"): testRemixArtifact, // 目录外
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, err := FindArtifact([]string{dir}, "Counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "Counter" || a.Bytecode != "6080" {
+		t.Fatalf("artifact = %+v", a)
+	}
+	for _, name := range []string{"../Secret", "..", "/Secret", "sub/Counter", `..\\Secret`, ""} {
+		if _, err := FindArtifact([]string{dir}, name); !errors.Is(err, ErrArtifactNotFound) {
+			t.Errorf("FindArtifact(%q) = %v, want ErrArtifactNotFound", name, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// 标准 CREATE2 工厂（Arachnid deterministic-deployment-proxy），在主网、Sepolia 等链上地址都相同。
+// 调用数据为 salt(32 字节) ++ initCode，返回新合约地址。
+var (
+	FactoryAddress = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+
+	// 工厂的部署交易是不带 chainId 的预签名交易，任何链上都能重放，签名者需要先有 gasPrice*gas 的余额
+	factorySigner = common.HexToAddress("0x3fAB184622Dc19b6109349B94811493BF2a45362")
+	factoryTx     = hexutil.MustDecode("0xf8a58085174876e800830186a08080b853604580600e600039806000f350fe7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f58015156039578182fd5b8082525050506014600cf31ba02222222222222222222222222222222222222222222222222222222222222222a02222222222222222222222222222222222222222222222222222222222222222")
+)
+
+var (
+	// ErrCollision 预测的地址上已经有合约代码
+	ErrCollision = errors.New("create2 address already has code")
+	// ErrFactoryNotDeployed 链上没有 CREATE2 工厂
+	ErrFactoryNotDeployed = errors.New("create2 factory not deployed")
+)
+
+// Prediction CREATE2 地址预测结果
+type Prediction struct {
+	Factory      common.Address `json:"factory"`
+	Salt         common.Hash    `json:"salt"`
+	InitCodeHash common.Hash    `json:"initCodeHash"`
+	Address      common.Address `json:"address"`
+	HasCode      bool           `json:"hasCode"` // true 表示地址冲突
+}
+
+// PredictAddress keccak256(0xff ++ deployer ++ salt ++ keccak256(initCode))[12:]
+func PredictAddress(deployer common.Address, salt common.Hash, initCodeHash common.Hash) common.Address {
+	return crypto.CreateAddress2(deployer, salt, initCodeHash[:])
+}
+
+// Predict 计算地址，并用 CodeAt 检查该地址是否已经有代码
+func Predict(ctx context.Context, backend bind.ContractBackend, factory common.Address, salt common.Hash, initCode []byte) (*Prediction, error) {
+	p := &Prediction{Factory: factory, Salt: salt, InitCodeHash: crypto.Keccak256Hash(initCode)}
+	p.Address = PredictAddress(factory, salt, p.InitCodeHash)
+	code, err := backend.CodeAt(ctx, p.Address, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.HasCode = len(code) > 0
+	return p, nil
+}
+
+// Create2Deploy 通过工厂合约部署，opts.Value 随调用转给新合约的构造函数。
+// 部署前检查地址冲突并模拟构造函数，部署后确认代码出现在预测的地址上
+func Create2Deploy(ctx context.Context, opts *bind.TransactOpts, backend Backend, factory common.Address,
+	salt common.Hash, name string, initCode []byte) (*Result, *Prediction, error) {
+	p, err := Predict(ctx, backend, factory, salt, initCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.HasCode {
+		return nil, p, fmt.Errorf("%w: %s", ErrCollision, p.Address.Hex())
+	}
+	factoryCode, err := backend.CodeAt(ctx, factory, nil)
+	if err != nil {
+		return nil, p, err
+	}
+	if len(factoryCode) == 0 {
+		return nil, p, fmt.Errorf("%w at %s", ErrFactoryNotDeployed, factory.Hex())
+	}
+	if err := SimulateCreate2(ctx, backend, factory, opts.From, opts.Value, initCode, nil); err != nil {
+		return nil, p, err
+	}
+
+	data := append(salt.Bytes(), initCode...)
+	bound := bind.NewBoundContract(factory, abi.ABI{}, backend, backend, backend)
+	tx, err := bound.RawTransact(opts, data)
+	if err != nil {
+		return nil, p, err
+	}
+	receipt, err := bind.WaitMined(ctx, backend, tx)
+	if err != nil {
+		return nil, p, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, p, &FailedError{Tx: tx, Err: errors.New("create2 deploy reverted")}
+	}
+	code, err := backend.CodeAt(ctx, p.Address, receipt.BlockNumber)
+	if err != nil {
+		return nil, p, err
+	}
+	if len(code) == 0 {
+		return nil, p, &FailedError{Tx: tx, Err: bind.ErrNoCodeAfterDeploy}
+	}
+	return &Result{
+		Contract: name,
+		Address:  p.Address,
+		TxHash:   tx.Hash(),
+		GasUsed:  receipt.GasUsed,
+		Block:    receipt.BlockNumber.Uint64(),
+	}, p, nil
+}
+
+// SimulateCreate2 用 eth_call 直接执行一次构造函数。工厂在 CREATE2 失败时不带数据回滚，
+// 这样才能拿到构造函数自己的回滚数据（自定义错误等）。
+// 不转账时以工厂为 msg.sender，和真实部署一致；转账时 eth_call 要检查余额，改用 from
+func SimulateCreate2(ctx context.Context, caller ethereum.ContractCaller, factory, from common.Address,
+	value *big.Int, initCode []byte, block *big.Int) error {
+	msg := ethereum.CallMsg{From: factory, Value: value, Data: initCode}
+	if value != nil && value.Sign() > 0 {
+		msg.From = from
+	}
+	_, err := caller.CallContract(ctx, msg, block)
+	return err
+}
+
+// EnsureFactory 本地链上没有标准工厂时自己部署：给预签名交易的签名者转够手续费，再广播预签名交易。
+// 节点需要允许不带 chainId 的交易（anvil、hardhat 默认允许，geth 需要 --rpc.allow-unprotected-txs）。
+func EnsureFactory(ctx context.Context, opts *bind.TransactOpts, backend Backend) (common.Address, error) {
+	code, err := backend.CodeAt(ctx, FactoryAddress, nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(code) > 0 {
+		return FactoryAddress, nil
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(factoryTx); err != nil {
+		return common.Address{}, err
+	}
+	cost := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))
+	balance, err := backend.BalanceAt(ctx, factorySigner, nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if balance.Cmp(cost) < 0 {
+		fund := *opts
+		fund.Value = new(big.Int).Sub(cost, balance)
+		fund.GasLimit = 21000
+		bound := bind.NewBoundContract(factorySigner, abi.ABI{}, backend, backend, backend)
+		fundTx, err := bound.RawTransact(&fund, nil)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("fund factory signer: %w", err)
+		}
+		if _, err := bind.WaitMined(ctx, backend, fundTx); err != nil {
+			return common.Address{}, err
+		}
+	}
+	if err := backend.SendTransaction(ctx, tx); err != nil {
+		return common.Address{}, fmt.Errorf("send factory deployment: %w", err)
+	}
+	if _, err := bind.WaitDeployed(ctx, backend, tx); err != nil {
+		return common.Address{}, err
+	}
+	return FactoryAddress, nil
+}
@@ -0,0 +1,113 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"level2/gin-example/internal/contract"
+)
+
+// Backend 部署需要的链上能力：发送交易 + 查询回执 + 查询余额（给 CREATE2 工厂签名者充值时用）
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	ethereum.ChainStateReader
+}
+
+// Result 部署结果
+type Result struct {
+	Contract string         `json:"contract"`
+	Address  common.Address `json:"address"`
+	TxHash   common.Hash    `json:"txHash"`
+	GasUsed  uint64         `json:"gasUsed"`
+	Block    uint64         `json:"block"`
+}
+
+// FailedError 部署交易已经上链但没有留下合约代码（通常是构造函数回滚）
+type FailedError struct {
+	Tx  *types.Transaction
+	Err error
+}
+
+func (e *FailedError) Error() string {
+	return fmt.Sprintf("deploy tx %s failed: %v", e.Tx.Hash().Hex(), e.Err)
+}
+
+func (e *FailedError) Unwrap() error { return e.Err }
+
+// Deploy 链接库、按 JSON 参数打包构造函数，发送部署交易并等待合约代码上链
+func Deploy(ctx context.Context, opts *bind.TransactOpts, backend Backend, artifact *Artifact,
+	rawArgs []json.RawMessage, libs map[string]common.Address) (*Result, error) {
+	code, args, err := prepare(artifact, rawArgs, libs)
+	if err != nil {
+		return nil, err
+	}
+	_, tx, _, err := bind.DeployContract(opts, artifact.ABI, code, backend, args...)
+	if err != nil {
+		return nil, err
+	}
+	return waitDeployed(ctx, backend, artifact.Name, tx)
+}
+
+// DeployBytecode 部署现成的初始化代码（没有 ABI 和构造参数，比如 pkg 下编译好的辅助合约），等待合约代码上链
+func DeployBytecode(ctx context.Context, opts *bind.TransactOpts, backend Backend, name string, initCode []byte) (*Result, error) {
+	_, tx, _, err := bind.DeployContract(opts, abi.ABI{}, initCode, backend)
+	if err != nil {
+		return nil, err
+	}
+	return waitDeployed(ctx, backend, name, tx)
+}
+
+// InitCode 链接后的字节码 + ABI 编码的构造函数参数，即部署交易的 data
+func InitCode(artifact *Artifact, rawArgs []json.RawMessage, libs map[string]common.Address) ([]byte, error) {
+	code, args, err := prepare(artifact, rawArgs, libs)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := artifact.ABI.Pack("", args...)
+	if err != nil {
+		return nil, err
+	}
+	return append(code, packed...), nil
+}
+
+func prepare(artifact *Artifact, rawArgs []json.RawMessage, libs map[string]common.Address) ([]byte, []interface{}, error) {
+	code, err := Link(artifact.Bytecode, artifact.LinkReferences, libs)
+	if err != nil {
+		return nil, nil, err
+	}
+	args, err := contract.ConvertArgs(artifact.ABI.Constructor.Inputs, rawArgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return code, args, nil
+}
+
+// waitDeployed bind.WaitDeployed 等到合约代码出现后再取回执里的 gas 用量
+func waitDeployed(ctx context.Context, backend Backend, name string, tx *types.Transaction) (*Result, error) {
+	address, err := bind.WaitDeployed(ctx, backend, tx)
+	if errors.Is(err, bind.ErrNoCodeAfterDeploy) {
+		return nil, &FailedError{Tx: tx, Err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := backend.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Contract: name,
+		Address:  address,
+		TxHash:   tx.Hash(),
+		GasUsed:  receipt.GasUsed,
+		Block:    receipt.BlockNumber.Uint64(),
+	}, nil
+}
@@ -0,0 +1,73 @@
+package deploy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Link 把字节码中的库占位符替换成库地址。
+// libs 的 key 可以是完整名 "contracts/Lib.sol:Lib"，也可以只写库名 "Lib"。
+// 支持 solc >= 0.5 的 __$<keccak256 前 17 字节>$__ 和旧版的 __<源文件:库名>__ 两种占位符。
+func Link(bytecode string, refs map[string]map[string][]Offset, libs map[string]common.Address) ([]byte, error) {
+	code := CleanHex(bytecode)
+
+	// 有 linkReferences 时按位置替换
+	for file, names := range refs {
+		for name, offsets := range names {
+			addr, ok := lookupLib(libs, file, name)
+			if !ok {
+				return nil, fmt.Errorf("missing address for library %s:%s", file, name)
+			}
+			hexAddr := strings.ToLower(addr.Hex()[2:])
+			for _, off := range offsets {
+				start, end := off.Start*2, (off.Start+off.Length)*2
+				if end > len(code) || off.Length != common.AddressLength {
+					return nil, fmt.Errorf("invalid link reference %s:%s at %d", file, name, off.Start)
+				}
+				code = code[:start] + hexAddr + code[end:]
+			}
+		}
+	}
+
+	// 没有 linkReferences（比如 .bin 文件）时按占位符文本替换
+	for fqName, addr := range libs {
+		hexAddr := strings.ToLower(addr.Hex()[2:])
+		for _, placeholder := range placeholders(fqName) {
+			code = strings.ReplaceAll(code, placeholder, hexAddr)
+		}
+	}
+
+	if idx := strings.Index(code, "__"); idx >= 0 {
+		end := idx + 40
+		if end > len(code) {
+			end = len(code)
+		}
+		return nil, fmt.Errorf("unlinked library placeholder %s", code[idx:end])
+	}
+	return hexutil.Decode("0x" + code)
+}
+
+func lookupLib(libs map[string]common.Address, file, name string) (common.Address, bool) {
+	if addr, ok := libs[file+":"+name]; ok {
+		return addr, true
+	}
+	addr, ok := libs[name]
+	return addr, ok
+}
+
+// placeholders 一个库可能出现的占位符，长度都是 40 个字符
+func placeholders(fqName string) []string {
+	hash := crypto.Keccak256([]byte(fqName))
+	out := []string{"__$" + hexutil.Encode(hash)[2:36] + "$__"}
+	legacy := "__" + fqName
+	if len(legacy) <= 38 {
+		out = append(out, legacy+strings.Repeat("_", 40-len(legacy)))
+	} else {
+		out = append(out, legacy[:38]+"__")
+	}
+	return out
+}
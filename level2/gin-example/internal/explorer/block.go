@@ -0,0 +1,127 @@
+package explorer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrInvalidBlockID 区块标识既不是区块号也不是区块哈希
+var ErrInvalidBlockID = errors.New("invalid block id")
+
+// BlockReader 浏览器需要的区块查询能力
+type BlockReader interface {
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
+}
+
+// BlockView 区块的 JSON 视图，包含合并（The Merge）之后的字段
+type BlockView struct {
+	Number           uint64           `json:"number"`
+	Hash             common.Hash      `json:"hash"`
+	ParentHash       common.Hash      `json:"parentHash"`
+	Timestamp        uint64           `json:"timestamp"`
+	Miner            common.Address   `json:"miner"` // 合并后是出块奖励的接收地址（fee recipient）
+	GasUsed          uint64           `json:"gasUsed"`
+	GasLimit         uint64           `json:"gasLimit"`
+	GasUtilisation   float64          `json:"gasUtilisation"` // gasUsed / gasLimit，百分比
+	BaseFeePerGas    *string          `json:"baseFeePerGas,omitempty"`
+	BlobGasUsed      *uint64          `json:"blobGasUsed,omitempty"`
+	ExcessBlobGas    *uint64          `json:"excessBlobGas,omitempty"`
+	ParentBeaconRoot *common.Hash     `json:"parentBeaconRoot,omitempty"`
+	WithdrawalsRoot  *common.Hash     `json:"withdrawalsRoot,omitempty"`
+	Withdrawals      []WithdrawalView `json:"withdrawals,omitempty"`
+	Difficulty       *string          `json:"difficulty,omitempty"` // 只有合并前的区块才有意义
+	StateRoot        common.Hash      `json:"stateRoot"`
+	ExtraData        string           `json:"extraData"`
+	Size             uint64           `json:"size"`
+	TxCount          int              `json:"txCount"`
+	Transactions     []common.Hash    `json:"transactions"`
+}
+
+// WithdrawalView 信标链提款
+type WithdrawalView struct {
+	Index      uint64         `json:"index"`
+	Validator  uint64         `json:"validatorIndex"`
+	Address    common.Address `json:"address"`
+	AmountGwei uint64         `json:"amountGwei"`
+}
+
+// NewBlockView 把区块转换成 JSON 视图
+func NewBlockView(block *types.Block) *BlockView {
+	h := block.Header()
+	v := &BlockView{
+		Number:           block.NumberU64(),
+		Hash:             block.Hash(),
+		ParentHash:       h.ParentHash,
+		Timestamp:        h.Time,
+		Miner:            h.Coinbase,
+		GasUsed:          h.GasUsed,
+		GasLimit:         h.GasLimit,
+		BlobGasUsed:      h.BlobGasUsed,
+		ExcessBlobGas:    h.ExcessBlobGas,
+		ParentBeaconRoot: h.ParentBeaconRoot,
+		WithdrawalsRoot:  h.WithdrawalsHash,
+		StateRoot:        h.Root,
+		ExtraData:        hexutil.Encode(h.Extra),
+		Size:             block.Size(),
+		TxCount:          len(block.Transactions()),
+		Transactions:     make([]common.Hash, 0, len(block.Transactions())),
+	}
+	if h.GasLimit > 0 {
+		v.GasUtilisation = float64(h.GasUsed) * 100 / float64(h.GasLimit)
+	}
+	if h.BaseFee != nil {
+		fee := h.BaseFee.String()
+		v.BaseFeePerGas = &fee
+	}
+	if h.Difficulty != nil && h.Difficulty.Sign() > 0 {
+		difficulty := h.Difficulty.String()
+		v.Difficulty = &difficulty
+	}
+	for _, w := range block.Withdrawals() {
+		v.Withdrawals = append(v.Withdrawals, WithdrawalView{
+			Index:      w.Index,
+			Validator:  w.Validator,
+			Address:    w.Address,
+			AmountGwei: w.Amount,
+		})
+	}
+	for _, tx := range block.Transactions() {
+		v.Transactions = append(v.Transactions, tx.Hash())
+	}
+	return v
+}
+
+// BlockByID 按 "latest"、十进制/十六进制区块号或区块哈希查询区块
+func BlockByID(ctx context.Context, reader BlockReader, id string) (*types.Block, error) {
+	switch {
+	case id == "" || id == "latest":
+		return reader.BlockByNumber(ctx, nil)
+	case strings.HasPrefix(id, "0x") && len(id) == 66:
+		// HexToHash 会把非法字符悄悄当成 0，先严格解码
+		raw, err := hexutil.Decode(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidBlockID, id)
+		}
+		return reader.BlockByHash(ctx, common.BytesToHash(raw))
+	case strings.HasPrefix(id, "0x"):
+		n, err := hexutil.DecodeBig(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidBlockID, id)
+		}
+		return reader.BlockByNumber(ctx, n)
+	}
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidBlockID, id)
+	}
+	return reader.BlockByNumber(ctx, new(big.Int).SetUint64(n))
+}
@@ -0,0 +1,70 @@
+package explorer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeReader 记录查询方式，返回空区块
+type fakeReader struct {
+	number *big.Int
+	hash   *common.Hash
+}
+
+func (r *fakeReader) BlockByNumber(_ context.Context, number *big.Int) (*types.Block, error) {
+	r.number = number
+	return types.NewBlockWithHeader(&types.Header{}), nil
+}
+
+func (r *fakeReader) BlockByHash(_ context.Context, hash common.Hash) (*types.Block, error) {
+	r.hash = &hash
+	return types.NewBlockWithHeader(&types.Header{}), nil
+}
+
+func TestBlockByID(t *testing.T) {
+	hash := "0x" + strings.Repeat("ab", 32)
+	tests := []struct {
+		id      string
+		number  *big.Int
+		hash    *common.Hash
+		invalid bool
+	}{
+		{id: "latest"},
+		{id: "", number: nil},
+		{id: "12", number: big.NewInt(12)},
+		{id: "0x10", number: big.NewInt(16)},
+		{id: hash, hash: func() *common.Hash { h := common.HexToHash(hash); return &h }()},
+		{id: "0x" + strings.Repeat("zz", 32), invalid: true}, // 66 个字符但不是十六进制
+		{id: "0xzz", invalid: true},
+		{id: "-1", invalid: true},
+	}
+	for _, tt := range tests {
+		r := &fakeReader{}
+		_, err := BlockByID(context.Background(), r, tt.id)
+		if tt.invalid {
+			if !errors.Is(err, ErrInvalidBlockID) {
+				t.Errorf("BlockByID(%q) = %v, want ErrInvalidBlockID", tt.id, err)
+			}
+			if r.number != nil || r.hash != nil {
+				t.Errorf("BlockByID(%q) queried the node", tt.id)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("BlockByID(%q): %v", tt.id, err)
+			continue
+		}
+		if (tt.hash == nil) != (r.hash == nil) || (tt.hash != nil && *tt.hash != *r.hash) {
+			t.Errorf("BlockByID(%q) hash = %v, want %v", tt.id, r.hash, tt.hash)
+		}
+		if (tt.number == nil) != (r.number == nil) || (tt.number != nil && tt.number.Cmp(r.number) != 0) {
+			t.Errorf("BlockByID(%q) number = %v, want %v", tt.id, r.number, tt.number)
+		}
+	}
+}
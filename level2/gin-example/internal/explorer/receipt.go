@@ -0,0 +1,78 @@
+package explorer
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"level2/gin-example/internal/contract"
+)
+
+// TxDetail 单笔交易详情：交易字段、收据以及按 ABI 解码的 calldata 和日志
+type TxDetail struct {
+	*TxView
+	Pending  bool                  `json:"pending"`
+	Contract string                `json:"contract,omitempty"` // 目标地址在注册表中的合约名
+	Call     *contract.DecodedCall `json:"call,omitempty"`
+	Receipt  *ReceiptView          `json:"receipt,omitempty"` // pending 时没有收据
+}
+
+// ReceiptView 交易收据
+type ReceiptView struct {
+	Status            uint64          `json:"status"` // 1 成功，0 失败
+	BlockNumber       string          `json:"blockNumber"`
+	BlockHash         common.Hash     `json:"blockHash"`
+	TransactionIndex  uint            `json:"transactionIndex"`
+	GasUsed           uint64          `json:"gasUsed"`
+	CumulativeGasUsed uint64          `json:"cumulativeGasUsed"`
+	EffectiveGasPrice string          `json:"effectiveGasPrice"`
+	Fee               string          `json:"fee"` // gasUsed * effectiveGasPrice
+	BlobGasUsed       uint64          `json:"blobGasUsed,omitempty"`
+	BlobGasPrice      string          `json:"blobGasPrice,omitempty"`
+	ContractAddress   *common.Address `json:"contractAddress,omitempty"`
+	Logs              []LogView       `json:"logs"`
+}
+
+// LogView 事件日志，能匹配到 ABI 时附带解码结果
+type LogView struct {
+	Index   uint                   `json:"logIndex"`
+	Address common.Address         `json:"address"`
+	Topics  []common.Hash          `json:"topics"`
+	Data    string                 `json:"data"`
+	Decoded *contract.DecodedEvent `json:"decoded,omitempty"`
+}
+
+// NewReceiptView 转换收据。decode 按日志地址选择 ABI 并解码，为 nil 时不解码。
+func NewReceiptView(receipt *types.Receipt, decode func(*types.Log) *contract.DecodedEvent) *ReceiptView {
+	v := &ReceiptView{
+		Status:            receipt.Status,
+		BlockNumber:       receipt.BlockNumber.String(),
+		BlockHash:         receipt.BlockHash,
+		TransactionIndex:  receipt.TransactionIndex,
+		GasUsed:           receipt.GasUsed,
+		CumulativeGasUsed: receipt.CumulativeGasUsed,
+		BlobGasUsed:       receipt.BlobGasUsed,
+		Logs:              make([]LogView, 0, len(receipt.Logs)),
+	}
+	if receipt.EffectiveGasPrice != nil {
+		v.EffectiveGasPrice = receipt.EffectiveGasPrice.String()
+		fee := new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+		v.Fee = fee.String()
+	}
+	if receipt.BlobGasPrice != nil {
+		v.BlobGasPrice = receipt.BlobGasPrice.String()
+	}
+	if receipt.ContractAddress != (common.Address{}) {
+		addr := receipt.ContractAddress
+		v.ContractAddress = &addr
+	}
+	for _, log := range receipt.Logs {
+		lv := LogView{Index: log.Index, Address: log.Address, Topics: log.Topics, Data: hexutil.Encode(log.Data)}
+		if decode != nil {
+			lv.Decoded = decode(log)
+		}
+		v.Logs = append(v.Logs, lv)
+	}
+	return v
+}
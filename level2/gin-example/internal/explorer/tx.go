@@ -0,0 +1,98 @@
+package explorer
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TxView 交易的 JSON 视图
+type TxView struct {
+	Hash                 common.Hash     `json:"hash"`
+	Type                 uint8           `json:"type"`
+	From                 *common.Address `json:"from,omitempty"` // 恢复失败时为空
+	To                   *common.Address `json:"to"`             // 合约创建交易为 null
+	ContractCreation     bool            `json:"contractCreation"`
+	Nonce                uint64          `json:"nonce"`
+	Value                string          `json:"value"`
+	Gas                  uint64          `json:"gas"`
+	GasPrice             string          `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string          `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string          `json:"maxPriorityFeePerGas,omitempty"`
+	BlobHashes           []common.Hash   `json:"blobVersionedHashes,omitempty"`
+	ChainID              string          `json:"chainId,omitempty"`
+	Input                string          `json:"input"`
+	Index                *uint           `json:"transactionIndex,omitempty"`
+}
+
+// Signer 按链 ID 选择能处理所有交易类型的签名器（legacy、EIP-2930、EIP-1559、EIP-4844）；
+// 不带链 ID 的老交易会自动回退到 Homestead 规则
+func Signer(chainID *big.Int) types.Signer {
+	return types.LatestSignerForChainID(chainID)
+}
+
+// NewTxView 转换交易并恢复发送者
+func NewTxView(tx *types.Transaction, signer types.Signer) *TxView {
+	v := &TxView{
+		Hash:             tx.Hash(),
+		Type:             tx.Type(),
+		To:               tx.To(),
+		ContractCreation: tx.To() == nil,
+		Nonce:            tx.Nonce(),
+		Value:            tx.Value().String(),
+		Gas:              tx.Gas(),
+		BlobHashes:       tx.BlobHashes(),
+		Input:            hexutil.Encode(tx.Data()),
+	}
+	if tx.Type() == types.LegacyTxType || tx.Type() == types.AccessListTxType {
+		v.GasPrice = tx.GasPrice().String()
+	} else {
+		v.MaxFeePerGas = tx.GasFeeCap().String()
+		v.MaxPriorityFeePerGas = tx.GasTipCap().String()
+	}
+	if tx.Protected() {
+		v.ChainID = tx.ChainId().String()
+	}
+	if from, err := types.Sender(signer, tx); err == nil {
+		v.From = &from
+	}
+	return v
+}
+
+// Page 分页结果
+type Page struct {
+	Page     int         `json:"page"`
+	PageSize int         `json:"pageSize"`
+	Total    int         `json:"total"`
+	Items    interface{} `json:"items"`
+}
+
+// BlockTxs 区块内交易分页，page 从 1 开始
+func BlockTxs(block *types.Block, signer types.Signer, page, size int) *Page {
+	txs := block.Transactions()
+	start, end := PageBounds(len(txs), page, size)
+	items := make([]*TxView, 0, end-start)
+	for i := start; i < end; i++ {
+		v := NewTxView(txs[i], signer)
+		idx := uint(i)
+		v.Index = &idx
+		items = append(items, v)
+	}
+	return &Page{Page: page, PageSize: size, Total: len(txs), Items: items}
+}
+
+// PageBounds 第 page 页（从 1 开始）在 total 条记录里的下标范围，超出范围时返回空区间。
+// 先比较再相乘，page 很大时 (page-1)*size 不会溢出成负数
+func PageBounds(total, page, size int) (int, int) {
+	if page < 1 || size < 1 || page-1 > total/size {
+		return total, total
+	}
+	start := (page - 1) * size
+	end := start + size
+	if end > total {
+		end = total
+	}
+	return start, end
+}
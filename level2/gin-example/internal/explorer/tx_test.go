@@ -0,0 +1,23 @@
+package explorer
+
+import "testing"
+
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		total, page, size int
+		start, end        int
+	}{
+		{10, 1, 4, 0, 4},
+		{10, 3, 4, 8, 10},
+		{10, 4, 4, 10, 10},
+		{0, 1, 25, 0, 0},
+		{10, 1 << 62, 4, 10, 10}, // (page-1)*size 会溢出
+		{10, 0, 4, 10, 10},
+	}
+	for _, tt := range tests {
+		start, end := PageBounds(tt.total, tt.page, tt.size)
+		if start != tt.start || end != tt.end {
+			t.Errorf("PageBounds(%d, %d, %d) = %d, %d, want %d, %d", tt.total, tt.page, tt.size, start, end, tt.start, tt.end)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package inspect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"level2/gin-example/internal/bytecode"
+)
+
+var addressRe = regexp.MustCompile("^0x[0-9a-fA-F]{40}$")
+
+// ErrBadChecksum 大小写混合的地址没有通过 EIP-55 校验
+var ErrBadChecksum = errors.New("invalid EIP-55 checksum")
+
+// Backend 地址检查需要读取状态并做 eth_call
+type Backend interface {
+	ethereum.ChainStateReader
+	ethereum.ContractCaller
+}
+
+// ParseAddress 先做格式检查，大小写混合时再做 EIP-55 校验；全小写/全大写视为未带校验和
+func ParseAddress(s string) (addr common.Address, checksummed bool, err error) {
+	if !addressRe.MatchString(s) {
+		return common.Address{}, false, fmt.Errorf("invalid address %q", s)
+	}
+	body := s[2:]
+	if body == strings.ToLower(body) || body == strings.ToUpper(body) {
+		return common.HexToAddress(s), false, nil
+	}
+	mixed, err := common.NewMixedcaseAddressFromString(s)
+	if err != nil {
+		return common.Address{}, false, err
+	}
+	if !mixed.ValidChecksum() {
+		return common.Address{}, false, fmt.Errorf("%w: want %s", ErrBadChecksum, mixed.Address().Hex())
+	}
+	return mixed.Address(), true, nil
+}
+
+// Report 地址检查结果
+type Report struct {
+	Address     string        `json:"address"`
+	Checksummed bool          `json:"checksummed"`
+	Kind        string        `json:"kind"` // eoa / contract
+	Balance     string        `json:"balance"`
+	Nonce       uint64        `json:"nonce"`
+	CodeSize    int           `json:"codeSize"`
+	Contract    *ContractInfo `json:"contract,omitempty"`
+}
+
+// ContractInfo 合约地址的接口探测和代理信息
+type ContractInfo struct {
+	ERC165     bool            `json:"erc165"`
+	Interfaces map[string]bool `json:"interfaces,omitempty"` // 通过 supportsInterface 探测
+	Standards  []string        `json:"standards"`            // 通过分发器里的函数选择器判断
+	Proxy      *ProxyInfo      `json:"proxy,omitempty"`
+}
+
+// ProxyInfo 代理合约及其实现地址
+type ProxyInfo struct {
+	Kind           string          `json:"kind"` // eip1167 / eip1967 / eip1967-beacon / delegatecall
+	Implementation *common.Address `json:"implementation,omitempty"`
+	Beacon         *common.Address `json:"beacon,omitempty"`
+	Admin          *common.Address `json:"admin,omitempty"`
+}
+
+// Inspect 查询余额、nonce、代码，合约地址再做 ERC-165 / ERC-20/721/1155 探测和代理检测
+func Inspect(ctx context.Context, backend Backend, address common.Address, block *big.Int) (*Report, error) {
+	r := &Report{Address: address.Hex(), Kind: "eoa"}
+	balance, err := backend.BalanceAt(ctx, address, block)
+	if err != nil {
+		return nil, err
+	}
+	r.Balance = balance.String()
+	if r.Nonce, err = backend.NonceAt(ctx, address, block); err != nil {
+		return nil, err
+	}
+	code, err := backend.CodeAt(ctx, address, block)
+	if err != nil {
+		return nil, err
+	}
+	r.CodeSize = len(code)
+	if len(code) == 0 {
+		return r, nil
+	}
+
+	r.Kind = "contract"
+	analysis := bytecode.Analyze(code)
+	info := &ContractInfo{Standards: matchStandards(analysis.Selectors)}
+	info.ERC165 = supportsInterface(ctx, backend, address, block, erc165ID) &&
+		!supportsInterface(ctx, backend, address, block, invalidID)
+	if info.ERC165 {
+		info.Interfaces = make(map[string]bool, len(interfaceIDs))
+		for name, id := range interfaceIDs {
+			info.Interfaces[name] = supportsInterface(ctx, backend, address, block, id)
+		}
+	}
+	if info.Proxy, err = detectProxy(ctx, backend, address, block, analysis); err != nil {
+		return nil, err
+	}
+	r.Contract = info
+	return r, nil
+}
+
+var (
+	erc165ID  = [4]byte{0x01, 0xff, 0xc9, 0xa7}
+	invalidID = [4]byte{0xff, 0xff, 0xff, 0xff}
+
+	interfaceIDs = map[string][4]byte{
+		"ERC721":             {0x80, 0xac, 0x58, 0xcd},
+		"ERC721Metadata":     {0x5b, 0x5e, 0x13, 0x9f},
+		"ERC721Enumerable":   {0x78, 0x0e, 0x9d, 0x63},
+		"ERC1155":            {0xd9, 0xb6, 0x7a, 0x26},
+		"ERC1155MetadataURI": {0x0e, 0x89, 0x34, 0x1c},
+		"ERC2981":            {0x2a, 0x55, 0x20, 0x5a},
+	}
+
+	// 各标准必须实现的函数选择器
+	standardSelectors = map[string][]string{
+		"ERC20": {
+			"0x18160ddd", // totalSupply()
+			"0x70a08231", // balanceOf(address)
+			"0xa9059cbb", // transfer(address,uint256)
+			"0x23b872dd", // transferFrom(address,address,uint256)
+			"0x095ea7b3", // approve(address,uint256)
+			"0xdd62ed3e", // allowance(address,address)
+		},
+		"ERC721": {
+			"0x70a08231", // balanceOf(address)
+			"0x6352211e", // ownerOf(uint256)
+			"0x42842e0e", // safeTransferFrom(address,address,uint256)
+			"0x23b872dd", // transferFrom(address,address,uint256)
+			"0xa22cb465", // setApprovalForAll(address,bool)
+			"0x081812fc", // getApproved(uint256)
+		},
+		"ERC1155": {
+			"0x00fdd58e", // balanceOf(address,uint256)
+			"0x4e1273f4", // balanceOfBatch(address[],uint256[])
+			"0xf242432a", // safeTransferFrom(address,address,uint256,uint256,bytes)
+			"0x2eb2c2d6", // safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)
+			"0xa22cb465", // setApprovalForAll(address,bool)
+		},
+	}
+
+	// EIP-1967 admin 槽 bytes32(uint256(keccak256("eip1967.proxy.admin")) - 1)
+	eip1967AdminSlot = common.HexToHash("0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103")
+	// implementation() 选择器，beacon 合约用它返回实现地址
+	implementationSelector = hexutil.MustDecode("0x5c60da1b")
+)
+
+// supportsInterface 调用 supportsInterface(bytes4)，调用失败或返回数据不对都视为不支持
+func supportsInterface(ctx context.Context, backend Backend, address common.Address, block *big.Int, id [4]byte) bool {
+	data := append(hexutil.MustDecode("0x01ffc9a7"), common.RightPadBytes(id[:], 32)...)
+	out, err := backend.CallContract(ctx, ethereum.CallMsg{To: &address, Data: data, Gas: 30000}, block)
+	if err != nil || len(out) != 32 {
+		return false
+	}
+	return new(big.Int).SetBytes(out).Cmp(common.Big1) == 0
+}
+
+func matchStandards(selectors []bytecode.Selector) []string {
+	have := make(map[string]bool, len(selectors))
+	for _, s := range selectors {
+		have[s.Selector] = true
+	}
+	standards := []string{}
+	for _, name := range []string{"ERC20", "ERC721", "ERC1155"} {
+		ok := true
+		for _, sel := range standardSelectors[name] {
+			if !have[sel] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			standards = append(standards, name)
+		}
+	}
+	return standards
+}
+
+// detectProxy EIP-1167 从字节码里直接取实现地址；EIP-1967 读取存储槽，beacon 代理再调用 beacon.implementation()
+func detectProxy(ctx context.Context, backend Backend, address common.Address, block *big.Int, analysis *bytecode.Analysis) (*ProxyInfo, error) {
+	if analysis.Proxy != nil && analysis.Proxy.Kind == "eip1167" {
+		return &ProxyInfo{Kind: "eip1167", Implementation: analysis.Proxy.Implementation}, nil
+	}
+
+	impl, err := slotAddress(ctx, backend, address, bytecode.EIP1967ImplementationSlot, block)
+	if err != nil {
+		return nil, err
+	}
+	admin, err := slotAddress(ctx, backend, address, eip1967AdminSlot, block)
+	if err != nil {
+		return nil, err
+	}
+	if impl != nil {
+		return &ProxyInfo{Kind: "eip1967", Implementation: impl, Admin: admin}, nil
+	}
+	beacon, err := slotAddress(ctx, backend, address, bytecode.EIP1967BeaconSlot, block)
+	if err != nil {
+		return nil, err
+	}
+	if beacon != nil {
+		info := &ProxyInfo{Kind: "eip1967-beacon", Beacon: beacon, Admin: admin}
+		out, err := backend.CallContract(ctx, ethereum.CallMsg{To: beacon, Data: implementationSelector}, block)
+		if err == nil && len(out) == 32 {
+			addr := common.BytesToAddress(out)
+			info.Implementation = &addr
+		}
+		return info, nil
+	}
+	if analysis.HasDelegateCall {
+		return &ProxyInfo{Kind: "delegatecall"}, nil
+	}
+	return nil, nil
+}
+
+func slotAddress(ctx context.Context, backend Backend, address common.Address, slot common.Hash, block *big.Int) (*common.Address, error) {
+	value, err := backend.StorageAt(ctx, address, slot, block)
+	if err != nil {
+		return nil, err
+	}
+	addr := common.BytesToAddress(value)
+	if addr == (common.Address{}) {
+		return nil, nil
+	}
+	return &addr, nil
+}
@@ -0,0 +1,30 @@
+package inspect
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"level2/gin-example/internal/bytecode"
+)
+
+// testdata/ERC1155.bin-runtime 是 solc 编译 testdata/ERC1155.sol 得到的运行时字节码，
+// balanceOf(address,uint256) 的选择器 0x00fdd58e 在分发器里是 PUSH3 0xfdd58e
+func TestMatchStandardsERC1155(t *testing.T) {
+	raw, err := os.ReadFile("testdata/ERC1155.bin-runtime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := common.FromHex(strings.TrimSpace(string(raw)))
+	analysis := bytecode.Analyze(code)
+
+	got := matchStandards(analysis.Selectors)
+	if want := []string{"ERC1155"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("standards = %v, want %v", got, want)
+	}
+	if analysis.Proxy != nil {
+		t.Fatalf("unexpected proxy %+v", analysis.Proxy)
+	}
+}
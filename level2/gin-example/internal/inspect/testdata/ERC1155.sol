@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+// 最小的 ERC-1155 实现，只用来测试选择器识别；运行时字节码见 ERC1155.bin-runtime
+// solc 0.8.21 --optimize --optimize-runs 200 --evm-version paris --bin-runtime ERC1155.sol
+interface IERC1155Receiver {
+    function onERC1155Received(address operator, address from, uint256 id, uint256 value, bytes calldata data) external returns (bytes4);
+    function onERC1155BatchReceived(address operator, address from, uint256[] calldata ids, uint256[] calldata values, bytes calldata data) external returns (bytes4);
+}
+
+contract ERC1155 {
+    event TransferSingle(address indexed operator, address indexed from, address indexed to, uint256 id, uint256 value);
+    event TransferBatch(address indexed operator, address indexed from, address indexed to, uint256[] ids, uint256[] values);
+    event ApprovalForAll(address indexed account, address indexed operator, bool approved);
+
+    mapping(uint256 => mapping(address => uint256)) private _balances;
+    mapping(address => mapping(address => bool)) private _operatorApprovals;
+
+    constructor(uint256 id, uint256 amount) {
+        _balances[id][msg.sender] = amount;
+        emit TransferSingle(msg.sender, address(0), msg.sender, id, amount);
+    }
+
+    function supportsInterface(bytes4 interfaceId) external pure returns (bool) {
+        return interfaceId == 0x01ffc9a7 || interfaceId == 0xd9b67a26 || interfaceId == 0x0e89341c;
+    }
+
+    function uri(uint256) external pure returns (string memory) {
+        return "";
+    }
+
+    function balanceOf(address account, uint256 id) public view returns (uint256) {
+        return _balances[id][account];
+    }
+
+    function balanceOfBatch(address[] calldata accounts, uint256[] calldata ids) external view returns (uint256[] memory batch) {
+        require(accounts.length == ids.length, "length mismatch");
+        batch = new uint256[](accounts.length);
+        for (uint256 i = 0; i < accounts.length; i++) {
+            batch[i] = balanceOf(accounts[i], ids[i]);
+        }
+    }
+
+    function setApprovalForAll(address operator, bool approved) external {
+        _operatorApprovals[msg.sender][operator] = approved;
+        emit ApprovalForAll(msg.sender, operator, approved);
+    }
+
+    function isApprovedForAll(address account, address operator) public view returns (bool) {
+        return _operatorApprovals[account][operator];
+    }
+
+    function safeTransferFrom(address from, address to, uint256 id, uint256 value, bytes calldata data) external {
+        require(from == msg.sender || isApprovedForAll(from, msg.sender), "not approved");
+        _move(from, to, id, value);
+        emit TransferSingle(msg.sender, from, to, id, value);
+        if (to.code.length > 0) {
+            require(IERC1155Receiver(to).onERC1155Received(msg.sender, from, id, value, data) == IERC1155Receiver.onERC1155Received.selector, "rejected");
+        }
+    }
+
+    function safeBatchTransferFrom(address from, address to, uint256[] calldata ids, uint256[] calldata values, bytes calldata data) external {
+        require(from == msg.sender || isApprovedForAll(from, msg.sender), "not approved");
+        require(ids.length == values.length, "length mismatch");
+        for (uint256 i = 0; i < ids.length; i++) {
+            _move(from, to, ids[i], values[i]);
+        }
+        emit TransferBatch(msg.sender, from, to, ids, values);
+        if (to.code.length > 0) {
+            require(IERC1155Receiver(to).onERC1155BatchReceived(msg.sender, from, ids, values, data) == IERC1155Receiver.onERC1155BatchReceived.selector, "rejected");
+        }
+    }
+
+    function _move(address from, address to, uint256 id, uint256 value) private {
+        require(to != address(0), "zero address");
+        require(_balances[id][from] >= value, "insufficient balance");
+        _balances[id][from] -= value;
+        _balances[id][to] += value;
+    }
+}
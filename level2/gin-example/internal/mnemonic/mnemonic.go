@@ -0,0 +1,173 @@
+// Package mnemonic BIP-39 助记词：生成、校验（报告出错单词的位置和校验和错误）以及带可选密码短语的种子派生
+package mnemonic
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	hdwallet "github.com/miguelmota/go-ethereum-hdwallet"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/text/unicode/norm"
+)
+
+// 熵的来源
+const (
+	SourceSystem   = "crypto/rand" // 操作系统的安全随机数
+	SourceProvided = "provided"    // 调用方给出的熵（骰子、硬件随机数等）
+)
+
+// WordCounts 允许的单词数，分别对应 128、160、192、224、256 位熵
+var WordCounts = []int{12, 15, 18, 21, 24}
+
+var (
+	// ErrWordCount 单词数不是 12/15/18/21/24
+	ErrWordCount = errors.New("mnemonic must have 12, 15, 18, 21 or 24 words")
+	// ErrUnknownWord 单词不在 BIP-39 英文词表里
+	ErrUnknownWord = errors.New("word is not in the BIP-39 english wordlist")
+	// ErrChecksum 单词都对，但最后几位校验和不匹配（通常是抄错或顺序颠倒）
+	ErrChecksum = errors.New("mnemonic checksum mismatch")
+	// ErrEntropy 给出的熵长度和单词数不符
+	ErrEntropy = errors.New("invalid entropy length")
+)
+
+// Error 校验失败的细节，Unwrap 得到上面的某个错误
+type Error struct {
+	Err      error  `json:"-"`
+	Kind     string `json:"kind"`               // wordCount、unknownWord 或 checksum
+	Position int    `json:"position,omitempty"` // 出错单词的位置，从 1 开始；校验和错误时是最后一个单词
+	Word     string `json:"word,omitempty"`
+	// Suggestions 词表里前缀相同的单词，BIP-39 英文词表的前 4 个字母是唯一的
+	Suggestions []string `json:"suggestions,omitempty"`
+	Words       int      `json:"words"`
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Position > 0 && e.Word != "":
+		return fmt.Sprintf("%v: word %d %q", e.Err, e.Position, e.Word)
+	case e.Kind == "wordCount":
+		return fmt.Sprintf("%v, got %d", e.Err, e.Words)
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Generated 新生成的助记词
+type Generated struct {
+	Mnemonic    string        `json:"mnemonic"`
+	Words       int           `json:"words"`
+	EntropyBits int           `json:"entropyBits"`
+	Entropy     hexutil.Bytes `json:"entropy"`
+	Source      string        `json:"entropySource"`
+}
+
+// Generate 生成 words 个单词的助记词。entropy 为空时从 crypto/rand 读取；
+// 不为空时使用调用方的熵，words 为 0 表示按熵的长度决定
+func Generate(words int, entropy []byte) (*Generated, error) {
+	source := SourceProvided
+	if len(entropy) == 0 {
+		if words == 0 {
+			words = 12
+		}
+		source = SourceSystem
+	} else if words == 0 {
+		words = len(entropy) * 8 * 33 / 32 / 11
+	}
+	bits, ok := entropyBits(words)
+	if !ok {
+		return nil, &Error{Err: ErrWordCount, Kind: "wordCount", Words: words}
+	}
+	if source == SourceSystem {
+		entropy = make([]byte, bits/8)
+		if _, err := rand.Read(entropy); err != nil {
+			return nil, err
+		}
+	} else if len(entropy)*8 != bits {
+		return nil, fmt.Errorf("%w: %d words need %d bits of entropy, got %d", ErrEntropy, words, bits, len(entropy)*8)
+	}
+	m, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return nil, err
+	}
+	return &Generated{Mnemonic: m, Words: words, EntropyBits: bits, Entropy: entropy, Source: source}, nil
+}
+
+// entropyBits 单词数对应的熵位数：每个单词 11 位，其中熵占 32/33，其余是校验和
+func entropyBits(words int) (int, bool) {
+	for _, n := range WordCounts {
+		if n == words {
+			return words * 11 * 32 / 33, true
+		}
+	}
+	return 0, false
+}
+
+// Normalize NFKD 规范化、转小写并把空白压成单个空格，和 BIP-39 计算种子时的输入一致
+func Normalize(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(norm.NFKD.String(mnemonic))), " ")
+}
+
+// Validate 按单词数、词表、校验和的顺序检查，返回第一个问题，错误类型为 *Error
+func Validate(mnemonic string) error {
+	words := strings.Fields(Normalize(mnemonic))
+	if _, ok := entropyBits(len(words)); !ok {
+		return &Error{Err: ErrWordCount, Kind: "wordCount", Words: len(words)}
+	}
+	for i, w := range words {
+		if _, ok := bip39.GetWordIndex(w); !ok {
+			return &Error{Err: ErrUnknownWord, Kind: "unknownWord", Position: i + 1, Word: w, Suggestions: suggest(w), Words: len(words)}
+		}
+	}
+	if _, err := bip39.EntropyFromMnemonic(strings.Join(words, " ")); err != nil {
+		if errors.Is(err, bip39.ErrChecksumIncorrect) {
+			return &Error{Err: ErrChecksum, Kind: "checksum", Position: len(words), Word: words[len(words)-1], Words: len(words)}
+		}
+		return err
+	}
+	return nil
+}
+
+// suggest 前 4 个字母（不足 4 个时全部）相同的单词，找不到时放宽到前 3 个
+func suggest(word string) []string {
+	for _, n := range []int{4, 3} {
+		prefix := word
+		if len(prefix) > n {
+			prefix = prefix[:n]
+		}
+		var out []string
+		for _, w := range bip39.GetWordList() {
+			if strings.HasPrefix(w, prefix) {
+				out = append(out, w)
+				if len(out) == 5 {
+					break
+				}
+			}
+		}
+		if len(out) > 0 {
+			return out
+		}
+	}
+	return nil
+}
+
+// Seed 校验后派生 64 字节种子：PBKDF2-HMAC-SHA512(mnemonic, "mnemonic"+passphrase, 2048)，两者都先做 NFKD。
+// 同一个助记词配不同的密码短语得到完全不同的钱包，密码短语写错不会报错
+func Seed(mnemonic, passphrase string) ([]byte, error) {
+	if err := Validate(mnemonic); err != nil {
+		return nil, err
+	}
+	return bip39.NewSeed(Normalize(mnemonic), norm.NFKD.String(passphrase)), nil
+}
+
+// Wallet 从助记词和可选的密码短语创建 HD 钱包
+func Wallet(mnemonic, passphrase string) (*hdwallet.Wallet, error) {
+	seed, err := Seed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return hdwallet.NewFromSeed(seed)
+}
@@ -0,0 +1,152 @@
+package mnemonic
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// BIP-39 英文测试向量（trezor/python-mnemonic vectors.json），种子都用密码短语 "TREZOR" 派生
+var trezorVectors = []struct {
+	entropy  string
+	mnemonic string
+	seed     string
+}{
+	{
+		"00000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		"80808080808080808080808080808080",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+		"d71de856f81a8acc65e6fc851a38d4d7ec216fd0796d0a6827a3ad6ed5511a30fa280f12eb2e47ed2ac03b5c462a0358d18d69fe4f985ec81778c1b370b652a8",
+	},
+	{
+		"ffffffffffffffffffffffffffffffff",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		"ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+	},
+	{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+		"bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd3097170af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8",
+	},
+}
+
+func TestTrezorVectors(t *testing.T) {
+	for _, v := range trezorVectors {
+		generated, err := Generate(0, common.FromHex(v.entropy))
+		if err != nil {
+			t.Fatalf("Generate(%s): %v", v.entropy, err)
+		}
+		if generated.Mnemonic != v.mnemonic || generated.Source != SourceProvided {
+			t.Errorf("Generate(%s) = %q (%s), want %q", v.entropy, generated.Mnemonic, generated.Source, v.mnemonic)
+		}
+		if err := Validate(v.mnemonic); err != nil {
+			t.Errorf("Validate(%q): %v", v.mnemonic, err)
+		}
+		seed, err := Seed(v.mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := common.Bytes2Hex(seed); got != v.seed {
+			t.Errorf("Seed(%q, TREZOR) = %s, want %s", v.mnemonic, got, v.seed)
+		}
+		// 大小写和多余的空白不影响种子
+		messy := "  " + strings.ToUpper(strings.ReplaceAll(v.mnemonic, " ", " \t "))
+		if seed, err := Seed(messy, "TREZOR"); err != nil || common.Bytes2Hex(seed) != v.seed {
+			t.Errorf("Seed of the unnormalized mnemonic = %x, %v", seed, err)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		words   int
+		entropy []byte
+		want    int // 单词数，0 表示应该失败
+		err     error
+	}{
+		{0, nil, 12, nil},
+		{24, nil, 24, nil},
+		{15, make([]byte, 20), 15, nil},
+		{0, make([]byte, 28), 21, nil},
+		{13, nil, 0, ErrWordCount},
+		{12, make([]byte, 32), 0, ErrEntropy},
+		{0, make([]byte, 8), 0, ErrWordCount},
+	}
+	for _, tt := range tests {
+		generated, err := Generate(tt.words, tt.entropy)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Generate(%d, %d bytes): err = %v, want %v", tt.words, len(tt.entropy), err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Generate(%d, %d bytes): %v", tt.words, len(tt.entropy), err)
+		}
+		if generated.Words != tt.want || len(strings.Fields(generated.Mnemonic)) != tt.want || generated.EntropyBits != len(generated.Entropy)*8 {
+			t.Errorf("Generate(%d, %d bytes) = %+v, want %d words", tt.words, len(tt.entropy), generated, tt.want)
+		}
+		if err := Validate(generated.Mnemonic); err != nil {
+			t.Errorf("generated mnemonic does not validate: %v", err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	abandon11 := strings.Repeat("abandon ", 11)
+	tests := []struct {
+		name     string
+		mnemonic string
+		want     *Error
+	}{
+		{"too few words", strings.Repeat("abandon ", 11), &Error{Err: ErrWordCount, Kind: "wordCount", Words: 11}},
+		{"empty", "", &Error{Err: ErrWordCount, Kind: "wordCount", Words: 0}},
+		{
+			"unknown word reported with its position",
+			"abandon abandon abandn abandon abandon abandon abandon abandon abandon abandon abandon about",
+			&Error{Err: ErrUnknownWord, Kind: "unknownWord", Position: 3, Word: "abandn", Suggestions: []string{"abandon"}, Words: 12},
+		},
+		{
+			"first unknown word wins",
+			"legal winner thank year wave sausage worth usefull legal winner thnk yellow",
+			&Error{Err: ErrUnknownWord, Kind: "unknownWord", Position: 8, Word: "usefull", Suggestions: []string{"useful"}, Words: 12},
+		},
+		{"checksum", abandon11 + "abandon", &Error{Err: ErrChecksum, Kind: "checksum", Position: 12, Word: "abandon", Words: 12}},
+		{"swapped words", "winner legal thank year wave sausage worth useful legal winner thank yellow", &Error{Err: ErrChecksum, Kind: "checksum", Position: 12, Word: "yellow", Words: 12}},
+		{"valid", abandon11 + "about", nil},
+		{"valid after normalization", "  ABANDON\t" + strings.Repeat("abandon  ", 10) + "About ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.mnemonic)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("err = %v", err)
+				}
+				return
+			}
+			var got *Error
+			if !errors.As(err, &got) {
+				t.Fatalf("err = %v, want *Error", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("err = %+v, want %+v", got, tt.want)
+			}
+			if !errors.Is(err, tt.want.Err) {
+				t.Fatalf("errors.Is(%v, %v) = false", err, tt.want.Err)
+			}
+		})
+	}
+}
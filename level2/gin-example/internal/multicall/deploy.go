@@ -0,0 +1,60 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"level2/gin-example/internal/deploy"
+	pkgStore "level2/pkg"
+)
+
+// ErrNotDeployed 标准地址和 CompatAddress 上都没有代码
+var ErrNotDeployed = errors.New("multicall3 not deployed")
+
+// 本地开发链上没有 Multicall3 时，通过 CREATE2 工厂部署 pkg/Multicall3.sol 编译出的合约（见 pkg/Multicall3.go）。
+// 编译器版本和参数与官方部署不同，所以地址不是标准地址，但在每条链上都相同。
+
+// compatInitCode 部署代码
+func compatInitCode() []byte {
+	return common.FromHex(pkgStore.Multicall3MetaData.Bin)
+}
+
+// CompatAddress 开发链上的 Multicall3 通过标准 CREATE2 工厂、salt 为 0 部署后的地址
+func CompatAddress() common.Address {
+	return deploy.PredictAddress(deploy.FactoryAddress, common.Hash{}, crypto.Keccak256Hash(compatInitCode()))
+}
+
+// Resolve 优先使用标准地址上的 Multicall3，其次是之前在 CompatAddress 部署过的
+func Resolve(ctx context.Context, backend bind.ContractCaller) (common.Address, error) {
+	for _, addr := range []common.Address{Address, CompatAddress()} {
+		code, err := backend.CodeAt(ctx, addr, nil)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if len(code) > 0 {
+			return addr, nil
+		}
+	}
+	return common.Address{}, ErrNotDeployed
+}
+
+// Ensure 找不到 Multicall3 时部署一份（需要时先部署 CREATE2 工厂），只应该在本地开发链上调用
+func Ensure(ctx context.Context, opts *bind.TransactOpts, backend deploy.Backend) (common.Address, error) {
+	addr, err := Resolve(ctx, backend)
+	if !errors.Is(err, ErrNotDeployed) {
+		return addr, err
+	}
+	factory, err := deploy.EnsureFactory(ctx, opts, backend)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("ensure create2 factory: %w", err)
+	}
+	result, _, err := deploy.Create2Deploy(ctx, opts, backend, factory, common.Hash{}, "Multicall3", compatInitCode())
+	if err != nil {
+		return common.Address{}, fmt.Errorf("deploy multicall3: %w", err)
+	}
+	return result.Address, nil
+}
@@ -0,0 +1,156 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"level2/gin-example/internal/revert"
+	pkgStore "level2/pkg"
+)
+
+// DefaultChunkSize 每个 aggregate3 包含的调用数，太大容易超过节点的 eth_call gas 上限
+const DefaultChunkSize = 500
+
+// Address Multicall3 在主网、Sepolia 等几百条链上的标准地址
+var Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// ErrNoData 调用成功但没有返回数据，通常是目标地址不是合约
+var ErrNoData = errors.New("call returned no data")
+
+// Call 一个待打包的合约调用
+type Call struct {
+	Target       common.Address
+	ABI          *abi.ABI
+	Method       string
+	Args         []interface{}
+	AllowFailure bool // false 时这个调用失败会让整个 aggregate3 回滚
+}
+
+// Result 单个调用的结果，Success 为 false 或解码失败时 Err 不为空
+type Result struct {
+	Success    bool
+	ReturnData []byte
+	Values     []interface{}
+	Err        error
+}
+
+// Options 聚合调用的参数
+type Options struct {
+	Block     *big.Int // nil 表示 latest
+	ChunkSize int
+}
+
+// Aggregator 把多个合约调用打包成 aggregate3 的 eth_call
+type Aggregator struct {
+	address  common.Address
+	contract *pkgStore.Multicall3CallerRaw
+	decoder  *revert.Decoder
+}
+
+// New 绑定指定地址上的 Multicall3；abis 用于解码失败调用中的自定义错误
+func New(address common.Address, caller bind.ContractCaller, abis ...abi.ABI) (*Aggregator, error) {
+	mc, err := pkgStore.NewMulticall3Caller(address, caller)
+	if err != nil {
+		return nil, err
+	}
+	return &Aggregator{
+		address:  address,
+		contract: &pkgStore.Multicall3CallerRaw{Contract: mc},
+		decoder:  revert.NewDecoder(abis...),
+	}, nil
+}
+
+// Address 正在使用的 Multicall3 地址
+func (a *Aggregator) Address() common.Address {
+	return a.address
+}
+
+// Aggregate 按 ChunkSize 分组，每组一次 aggregate3，结果顺序与 calls 一致。
+// 打包参数失败的调用不会发出去，只记录在对应的 Result 里；整个 eth_call 失败时返回错误
+func (a *Aggregator) Aggregate(ctx context.Context, calls []Call, opts Options) ([]*Result, int, error) {
+	size := opts.ChunkSize
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	results := make([]*Result, len(calls))
+	var packed []pkgStore.Multicall3Call3
+	var index []int
+	for i, c := range calls {
+		data, err := c.ABI.Pack(c.Method, c.Args...)
+		if err != nil {
+			results[i] = &Result{Err: fmt.Errorf("pack %s: %w", c.Method, err)}
+			continue
+		}
+		packed = append(packed, pkgStore.Multicall3Call3{Target: c.Target, AllowFailure: c.AllowFailure, CallData: data})
+		index = append(index, i)
+	}
+
+	chunks := 0
+	for start := 0; start < len(packed); start += size {
+		end := start + size
+		if end > len(packed) {
+			end = len(packed)
+		}
+		returned, err := a.aggregate3(ctx, packed[start:end], opts.Block)
+		if err != nil {
+			return nil, chunks, err
+		}
+		chunks++
+		for j, r := range returned {
+			i := index[start+j]
+			results[i] = a.decode(calls[i], r)
+		}
+	}
+	return results, chunks, nil
+}
+
+// aggregate3 aggregate3 在合约里声明为 payable，abigen 只生成了交易方法，这里直接用 eth_call 调用
+func (a *Aggregator) aggregate3(ctx context.Context, calls []pkgStore.Multicall3Call3, block *big.Int) ([]pkgStore.Multicall3Result, error) {
+	var out []interface{}
+	err := a.contract.Call(&bind.CallOpts{Context: ctx, BlockNumber: block}, &out, "aggregate3", calls)
+	if err != nil {
+		if errors.Is(err, bind.ErrNoCode) {
+			// 地址上的 Multicall3 不见了，比如开发链重启过
+			return nil, fmt.Errorf("aggregate3 at %s: %w", a.address.Hex(), ErrNotDeployed)
+		}
+		if reason, ok := a.decoder.DecodeError(err); ok {
+			return nil, fmt.Errorf("aggregate3: %s", reason.Message)
+		}
+		return nil, fmt.Errorf("aggregate3: %w", err)
+	}
+	returned := *abi.ConvertType(out[0], new([]pkgStore.Multicall3Result)).(*[]pkgStore.Multicall3Result)
+	if len(returned) != len(calls) {
+		return nil, fmt.Errorf("aggregate3 returned %d results for %d calls", len(returned), len(calls))
+	}
+	return returned, nil
+}
+
+// decode 按方法的返回值类型解码，失败的调用解码 revert 原因
+func (a *Aggregator) decode(c Call, r pkgStore.Multicall3Result) *Result {
+	result := &Result{Success: r.Success, ReturnData: r.ReturnData}
+	if !r.Success {
+		reason := a.decoder.Decode(r.ReturnData)
+		result.Err = fmt.Errorf("%s reverted: %s", c.Method, reason.Message)
+		if reason.Message == "" {
+			result.Err = fmt.Errorf("%s reverted: %s", c.Method, reason.Data)
+		}
+		return result
+	}
+	method := c.ABI.Methods[c.Method]
+	if len(r.ReturnData) == 0 && len(method.Outputs) > 0 {
+		result.Err = fmt.Errorf("%s: %w", c.Method, ErrNoData)
+		return result
+	}
+	values, err := method.Outputs.Unpack(r.ReturnData)
+	if err != nil {
+		result.Err = fmt.Errorf("unpack %s: %w", c.Method, err)
+		return result
+	}
+	result.Values = values
+	return result
+}
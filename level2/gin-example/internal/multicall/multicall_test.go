@@ -0,0 +1,137 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"level2/gin-example/internal/deploy"
+	pkgStore "level2/pkg"
+)
+
+// factoryRuntime 标准 CREATE2 工厂（deterministic-deployment-proxy）的运行时代码，直接写进创世状态
+var factoryRuntime = hexutil.MustDecode("0x7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f58015156039578182fd5b8082525050506014600cf3")
+
+// newBackend 模拟链：一个有余额的账户和 CREATE2 工厂，后台定时出块，bind.WaitMined 才能返回
+func newBackend(t *testing.T) (*simulated.Backend, *bind.TransactOpts) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	sim := simulated.NewBackend(types.GenesisAlloc{
+		from:                  {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+		deploy.FactoryAddress: {Code: factoryRuntime},
+	})
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				sim.Commit()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		sim.Close()
+	})
+	opts, err := bind.NewKeyedTransactorWithChainID(key, params.AllDevChainProtocolChanges.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sim, opts
+}
+
+func TestEnsureAndAggregate(t *testing.T) {
+	sim, opts := newBackend(t)
+	client := sim.Client()
+	ctx := context.Background()
+
+	if _, err := Resolve(ctx, client); !errors.Is(err, ErrNotDeployed) {
+		t.Fatalf("Resolve before deploy = %v, want ErrNotDeployed", err)
+	}
+	addr, err := Ensure(ctx, opts, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != CompatAddress() {
+		t.Fatalf("deployed at %s, want %s", addr.Hex(), CompatAddress().Hex())
+	}
+	// 再次调用直接返回已部署的地址
+	if again, err := Ensure(ctx, opts, client); err != nil || again != addr {
+		t.Fatalf("second Ensure = %s, %v", again.Hex(), err)
+	}
+
+	mcABI, err := pkgStore.Multicall3MetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	agg, err := New(addr, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := []Call{
+		{Target: addr, ABI: mcABI, Method: "getEthBalance", Args: []interface{}{opts.From}},
+		{Target: addr, ABI: mcABI, Method: "getChainId"},
+		// 普通账户上没有代码，调用成功但没有返回数据
+		{Target: opts.From, ABI: mcABI, Method: "getChainId", AllowFailure: true},
+	}
+	results, chunks, err := agg.Aggregate(ctx, calls, Options{ChunkSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunks != 2 {
+		t.Fatalf("chunks = %d, want 2", chunks)
+	}
+	if balance := results[0].Values[0].(*big.Int); balance.Sign() <= 0 {
+		t.Fatalf("balance = %s", balance)
+	}
+	if chainID := results[1].Values[0].(*big.Int); chainID.Cmp(params.AllDevChainProtocolChanges.ChainID) != 0 {
+		t.Fatalf("chainId = %s", chainID)
+	}
+	if !errors.Is(results[2].Err, ErrNoData) {
+		t.Fatalf("call on an account = %v, want ErrNoData", results[2].Err)
+	}
+
+	// aggregate 里的调用（未知方法）失败时回滚 "Multicall3: call failed"
+	failing := Call{Target: addr, ABI: mcABI, Method: "aggregate", AllowFailure: true,
+		Args: []interface{}{[]pkgStore.Multicall3Call{{Target: addr, CallData: []byte{0xde, 0xad, 0xbe, 0xef}}}}}
+	results, _, err = agg.Aggregate(ctx, []Call{failing}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Success || results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "Multicall3: call failed") {
+		t.Fatalf("allowed failure = %+v", results[0])
+	}
+	// 不允许失败时整个 aggregate3 回滚
+	failing.AllowFailure = false
+	if _, _, err := agg.Aggregate(ctx, []Call{failing}, Options{}); err == nil || !strings.Contains(err.Error(), "Multicall3: call failed") {
+		t.Fatalf("required failure = %v", err)
+	}
+}
+
+func TestAggregateWithoutContract(t *testing.T) {
+	sim, _ := newBackend(t)
+	agg, err := New(common.HexToAddress("0x1234"), sim.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mcABI, _ := pkgStore.Multicall3MetaData.GetAbi()
+	_, _, err = agg.Aggregate(context.Background(), []Call{{Target: common.HexToAddress("0x1234"), ABI: mcABI, Method: "getChainId"}}, Options{})
+	if !errors.Is(err, ErrNotDeployed) {
+		t.Fatalf("Aggregate on an empty address = %v, want ErrNotDeployed", err)
+	}
+}
@@ -0,0 +1,106 @@
+package multicall
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	pkgStore "level2/pkg"
+)
+
+// tokenABI pkg.Token（ERC-20）的 ABI，TokenCaller 的每个方法都能打包进 aggregate3
+var tokenABI, _ = pkgStore.TokenMetaData.GetAbi()
+
+// TokenCall 构造一个 ERC-20 只读调用，单个代币失败不影响其它调用
+func TokenCall(token common.Address, method string, args ...interface{}) Call {
+	return Call{Target: token, ABI: tokenABI, Method: method, Args: args, AllowFailure: true}
+}
+
+// TokenInfo 代币元数据，读取失败的字段为空并记录在 Errors 里
+type TokenInfo struct {
+	Address     common.Address    `json:"address"`
+	Name        string            `json:"name,omitempty"`
+	Symbol      string            `json:"symbol,omitempty"`
+	Decimals    *uint8            `json:"decimals,omitempty"`
+	TotalSupply *big.Int          `json:"totalSupply,omitempty"`
+	Errors      map[string]string `json:"errors,omitempty"` // 方法名 -> 错误
+}
+
+// Balance 一个持有人在一个代币上的余额
+type Balance struct {
+	Token   common.Address `json:"token"`
+	Holder  common.Address `json:"holder"`
+	Balance *big.Int       `json:"balance,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// tokenMetaMethods 每个代币读取的元数据方法
+var tokenMetaMethods = []string{"name", "symbol", "decimals", "totalSupply"}
+
+// TokenMetadata 用一次（或按 ChunkSize 分几次）aggregate3 读取多个代币的 name/symbol/decimals/totalSupply
+func (a *Aggregator) TokenMetadata(ctx context.Context, tokens []common.Address, opts Options) ([]*TokenInfo, int, error) {
+	calls := make([]Call, 0, len(tokens)*len(tokenMetaMethods))
+	for _, token := range tokens {
+		for _, method := range tokenMetaMethods {
+			calls = append(calls, TokenCall(token, method))
+		}
+	}
+	results, chunks, err := a.Aggregate(ctx, calls, opts)
+	if err != nil {
+		return nil, chunks, err
+	}
+
+	infos := make([]*TokenInfo, len(tokens))
+	for i, token := range tokens {
+		info := &TokenInfo{Address: token}
+		for j, method := range tokenMetaMethods {
+			r := results[i*len(tokenMetaMethods)+j]
+			if r.Err != nil {
+				if info.Errors == nil {
+					info.Errors = make(map[string]string)
+				}
+				info.Errors[method] = r.Err.Error()
+				continue
+			}
+			switch method {
+			case "name":
+				info.Name = *abi.ConvertType(r.Values[0], new(string)).(*string)
+			case "symbol":
+				info.Symbol = *abi.ConvertType(r.Values[0], new(string)).(*string)
+			case "decimals":
+				info.Decimals = abi.ConvertType(r.Values[0], new(uint8)).(*uint8)
+			case "totalSupply":
+				info.TotalSupply = abi.ConvertType(r.Values[0], new(big.Int)).(*big.Int)
+			}
+		}
+		infos[i] = info
+	}
+	return infos, chunks, nil
+}
+
+// Balances 读取每个代币上每个持有人的 balanceOf，结果按代币、再按持有人排列
+func (a *Aggregator) Balances(ctx context.Context, tokens, holders []common.Address, opts Options) ([]*Balance, int, error) {
+	calls := make([]Call, 0, len(tokens)*len(holders))
+	for _, token := range tokens {
+		for _, holder := range holders {
+			calls = append(calls, TokenCall(token, "balanceOf", holder))
+		}
+	}
+	results, chunks, err := a.Aggregate(ctx, calls, opts)
+	if err != nil {
+		return nil, chunks, err
+	}
+
+	balances := make([]*Balance, 0, len(calls))
+	for i, r := range results {
+		b := &Balance{Token: calls[i].Target, Holder: calls[i].Args[0].(common.Address)}
+		if r.Err != nil {
+			b.Error = r.Err.Error()
+		} else {
+			b.Balance = abi.ConvertType(r.Values[0], new(big.Int)).(*big.Int)
+		}
+		balances = append(balances, b)
+	}
+	return balances, chunks, nil
+}
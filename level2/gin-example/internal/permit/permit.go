@@ -0,0 +1,225 @@
+// Package permit ERC-2612 permit：检测代币是否支持、构造并签名 Permit 类型数据、由中继账户代为提交 permit 交易，
+// 以及一个可以部署到本地开发链的测试代币
+package permit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"level2/gin-example/internal/signature"
+)
+
+// DefaultVersion 代币没有 version() 时使用的域版本，OpenZeppelin ERC20Permit 默认就是 "1"
+const DefaultVersion = "1"
+
+var (
+	// ErrNoCode 地址上没有合约代码
+	ErrNoCode = errors.New("no contract code at address")
+	// ErrNotSupported 代币没有实现 DOMAIN_SEPARATOR() 或 nonces(address)
+	ErrNotSupported = errors.New("token does not implement ERC-2612 permit")
+	// ErrDomainMismatch 按 name、version、chainId、代币地址算出的域分隔符和链上的不一致，
+	// 代币用了别的域字段（比如 salt）或者 version 猜错了，签出来的 permit 会被拒绝
+	ErrDomainMismatch = errors.New("computed domain separator does not match DOMAIN_SEPARATOR()")
+)
+
+// ABI ERC-2612 用到的方法，name() 和 version() 用来推出 EIP-712 域
+var ABI, _ = abi.JSON(strings.NewReader(`[
+{"inputs":[],"name":"name","outputs":[{"type":"string"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"version","outputs":[{"type":"string"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"DOMAIN_SEPARATOR","outputs":[{"type":"bytes32"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"owner","type":"address"}],"name":"nonces","outputs":[{"type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"name":"allowance","outputs":[{"type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"},{"name":"value","type":"uint256"},{"name":"deadline","type":"uint256"},{"name":"v","type":"uint8"},{"name":"r","type":"bytes32"},{"name":"s","type":"bytes32"}],"name":"permit","outputs":[],"stateMutability":"nonpayable","type":"function"}
+]`))
+
+var (
+	domainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	permitTypeHash = crypto.Keccak256Hash([]byte("Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)"))
+)
+
+// Info 代币的 permit 支持情况和 EIP-712 域
+type Info struct {
+	Token     common.Address `json:"token"`
+	Supported bool           `json:"supported"`
+	Name      string         `json:"name,omitempty"`
+	Version   string         `json:"version,omitempty"`
+	// VersionSource version 的来源：version()，或者代币没有这个方法时的 default
+	VersionSource   string       `json:"versionSource,omitempty"`
+	ChainID         *big.Int     `json:"chainId"`
+	DomainSeparator *common.Hash `json:"domainSeparator,omitempty"` // 链上 DOMAIN_SEPARATOR()
+	// ComputedDomainSeparator 按 name、version、chainId、代币地址算出的值，DomainMatches 表示两者是否一致
+	ComputedDomainSeparator *common.Hash      `json:"computedDomainSeparator,omitempty"`
+	DomainMatches           bool              `json:"domainMatches"`
+	Owner                   *common.Address   `json:"owner,omitempty"`
+	Nonce                   *big.Int          `json:"nonce,omitempty"`  // owner 当前的 nonce
+	Errors                  map[string]string `json:"errors,omitempty"` // 方法名 -> 错误
+}
+
+// Check 能否为这个代币签 permit
+func (i *Info) Check() error {
+	if !i.Supported {
+		return ErrNotSupported
+	}
+	if !i.DomainMatches {
+		return fmt.Errorf("%w: on-chain %s, computed %s (name %q, version %q)",
+			ErrDomainMismatch, i.DomainSeparator.Hex(), i.ComputedDomainSeparator.Hex(), i.Name, i.Version)
+	}
+	return nil
+}
+
+// Detect 读取 name、version、DOMAIN_SEPARATOR 和 owner 的 nonce，判断代币是否支持 permit；owner 为空时用零地址探测 nonces。
+// 方法调用失败记在 Errors 里，只有查询代码失败或地址上没有合约时才返回错误
+func Detect(ctx context.Context, caller bind.ContractCaller, token common.Address, chainID *big.Int, owner *common.Address) (*Info, error) {
+	code, err := caller.CodeAt(ctx, token, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoCode, token.Hex())
+	}
+	info := &Info{Token: token, ChainID: chainID, Owner: owner}
+	bound := bind.NewBoundContract(token, ABI, caller, nil, nil)
+	call := func(method string, args ...interface{}) (interface{}, bool) {
+		var out []interface{}
+		if err := bound.Call(&bind.CallOpts{Context: ctx}, &out, method, args...); err != nil {
+			if info.Errors == nil {
+				info.Errors = make(map[string]string)
+			}
+			info.Errors[method] = err.Error()
+			return nil, false
+		}
+		return out[0], true
+	}
+
+	if v, ok := call("name"); ok {
+		info.Name = v.(string)
+	}
+	info.Version, info.VersionSource = DefaultVersion, "default"
+	if v, ok := call("version"); ok {
+		info.Version, info.VersionSource = v.(string), "version()"
+	} else {
+		// 没有 version() 是常见情况，不算错误
+		delete(info.Errors, "version")
+	}
+	separator, hasSeparator := call("DOMAIN_SEPARATOR")
+	var probe common.Address
+	if owner != nil {
+		probe = *owner
+	}
+	nonce, hasNonces := call("nonces", probe)
+	if hasNonces && owner != nil {
+		info.Nonce = nonce.(*big.Int)
+	}
+	if hasSeparator {
+		onChain := common.Hash(separator.([32]byte))
+		computed := DomainSeparator(info.Name, info.Version, chainID, token)
+		info.DomainSeparator, info.ComputedDomainSeparator = &onChain, &computed
+		info.DomainMatches = onChain == computed
+	}
+	info.Supported = hasSeparator && hasNonces
+	if len(info.Errors) == 0 {
+		info.Errors = nil
+	}
+	return info, nil
+}
+
+// DomainSeparator keccak256(typeHash ‖ keccak256(name) ‖ keccak256(version) ‖ chainId ‖ verifyingContract)
+func DomainSeparator(name, version string, chainID *big.Int, token common.Address) common.Hash {
+	return crypto.Keccak256Hash(
+		domainTypeHash.Bytes(),
+		crypto.Keccak256([]byte(name)),
+		crypto.Keccak256([]byte(version)),
+		math.U256Bytes(new(big.Int).Set(chainID)),
+		common.LeftPadBytes(token.Bytes(), 32),
+	)
+}
+
+// Permit 一次授权的内容
+type Permit struct {
+	Owner    common.Address `json:"owner"`
+	Spender  common.Address `json:"spender"`
+	Value    *big.Int       `json:"value"`
+	Nonce    *big.Int       `json:"nonce"`
+	Deadline *big.Int       `json:"deadline"` // unix 秒
+}
+
+// TypedData Permit 的 EIP-712 类型数据，和钱包 eth_signTypedData_v4 收到的一样
+func TypedData(info *Info, p *Permit) *apitypes.TypedData {
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Permit": {
+				{Name: "owner", Type: "address"},
+				{Name: "spender", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain: apitypes.TypedDataDomain{
+			Name:              info.Name,
+			Version:           info.Version,
+			ChainId:           (*math.HexOrDecimal256)(new(big.Int).Set(info.ChainID)),
+			VerifyingContract: info.Token.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"owner":    p.Owner.Hex(),
+			"spender":  p.Spender.Hex(),
+			"value":    p.Value.String(),
+			"nonce":    p.Nonce.String(),
+			"deadline": p.Deadline.String(),
+		},
+	}
+}
+
+// Hash Permit 的签名摘要；代币的域和算出来的不一致时返回 ErrDomainMismatch
+func Hash(info *Info, p *Permit) (*apitypes.TypedData, *signature.TypedHash, error) {
+	if err := info.Check(); err != nil {
+		return nil, nil, err
+	}
+	td := TypedData(info, p)
+	hash, err := signature.HashTypedData(td, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return td, hash, nil
+}
+
+// Submit 用 opts 的账户（中继者）提交 permit，sig 为 65 字节 r‖s‖v，v 可以是 0/1 或 27/28
+func Submit(opts *bind.TransactOpts, backend bind.ContractBackend, token common.Address, p *Permit, sig []byte) (*types.Transaction, error) {
+	normalized, err := signature.NormalizeV(sig)
+	if err != nil {
+		return nil, err
+	}
+	var r, s [32]byte
+	copy(r[:], normalized[:32])
+	copy(s[:], normalized[32:64])
+	bound := bind.NewBoundContract(token, ABI, backend, backend, backend)
+	return bound.Transact(opts, "permit", p.Owner, p.Spender, p.Value, p.Deadline, normalized[64]+27, r, s)
+}
+
+// Allowance 当前 allowance(owner, spender)，提交后用来确认授权已经生效
+func Allowance(ctx context.Context, caller bind.ContractCaller, token, owner, spender common.Address) (*big.Int, error) {
+	var out []interface{}
+	bound := bind.NewBoundContract(token, ABI, caller, nil, nil)
+	if err := bound.Call(&bind.CallOpts{Context: ctx}, &out, "allowance", owner, spender); err != nil {
+		return nil, err
+	}
+	return out[0].(*big.Int), nil
+}
@@ -0,0 +1,113 @@
+package permit
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// TestPermitFlow 在模拟链上部署测试代币，走一遍检测 → owner 签名 → 中继者提交
+func TestPermitFlow(t *testing.T) {
+	ownerKey, _ := crypto.GenerateKey()
+	relayerKey, _ := crypto.GenerateKey()
+	owner := crypto.PubkeyToAddress(ownerKey.PublicKey)
+	relayer := crypto.PubkeyToAddress(relayerKey.PublicKey)
+	funds := new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))
+	sim := simulated.NewBackend(types.GenesisAlloc{owner: {Balance: funds}, relayer: {Balance: funds}})
+	// 后台定时出块，部署和提交时的 bind.WaitMined 才能返回
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				sim.Commit()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		sim.Close()
+	})
+	client := sim.Client()
+	ctx := context.Background()
+	chainID := params.AllDevChainProtocolChanges.ChainID
+
+	ownerOpts, _ := bind.NewKeyedTransactorWithChainID(ownerKey, chainID)
+	relayerOpts, _ := bind.NewKeyedTransactorWithChainID(relayerKey, chainID)
+	result, err := DeployTestToken(ctx, ownerOpts, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := result.Address
+
+	info, err := Detect(ctx, client, token, chainID, &owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := info.Check(); err != nil {
+		t.Fatalf("detect: %v (%+v)", err, info)
+	}
+	if info.Name != TestTokenName || info.Version != TestTokenVersion || info.VersionSource != "version()" || info.Nonce.Sign() != 0 {
+		t.Fatalf("info = %+v", info)
+	}
+
+	spender := relayer
+	p := &Permit{
+		Owner:    owner,
+		Spender:  spender,
+		Value:    big.NewInt(1234),
+		Nonce:    info.Nonce,
+		Deadline: big.NewInt(time.Now().Add(time.Hour).Unix()),
+	}
+	_, hash, err := Hash(info, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := crypto.Sign(hash.Digest.Bytes(), ownerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := Submit(relayerOpts, client, token, p, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := bind.WaitMined(ctx, client, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("permit reverted")
+	}
+	allowance, err := Allowance(ctx, client, token, owner, spender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowance.Cmp(p.Value) != 0 {
+		t.Fatalf("allowance = %s, want %s", allowance, p.Value)
+	}
+	if info, _ = Detect(ctx, client, token, chainID, &owner); info.Nonce.Int64() != 1 {
+		t.Fatalf("nonce after permit = %s, want 1", info.Nonce)
+	}
+
+	// 同一个签名不能再用一次：nonce 已经变了
+	relayerOpts.GasLimit = 200_000 // 跳过估算，直接发出会回滚的交易
+	if tx, err = Submit(relayerOpts, client, token, p, sig); err != nil {
+		t.Fatal(err)
+	}
+	if receipt, err = bind.WaitMined(ctx, client, tx); err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Status != types.ReceiptStatusFailed {
+		t.Fatalf("replayed permit succeeded")
+	}
+}
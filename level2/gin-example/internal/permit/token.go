@@ -0,0 +1,43 @@
+package permit
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"level2/gin-example/internal/deploy"
+	pkgStore "level2/pkg"
+)
+
+// 本地开发链上用来走通 permit 流程的测试代币（ERC-20 + ERC-2612），源码在 pkg/PermitToken.sol，
+// 绑定 pkg/PermitToken.go 由 abigen 生成。这不是审计过的代码，不要在公共网络上使用。
+const (
+	TestTokenName     = "Permit Test Token"
+	TestTokenSymbol   = "PTT"
+	TestTokenVersion  = "1"
+	TestTokenDecimals = 18
+)
+
+// TestTokenABI 测试代币的完整 ABI，部署后登记到合约注册表，/contracts 下的通用接口就能调用它
+var TestTokenABI = pkgStore.PermitTokenMetaData.ABI
+
+// TestTokenContract 测试代币在注册表里的名字
+const TestTokenContract = "PermitTestToken"
+
+// TestTokenSource 登记到注册表时记录的源码位置
+const TestTokenSource = "pkg/PermitToken.sol"
+
+// TestTokenSupply 部署时铸给部署者的数量：100 万枚
+var TestTokenSupply = new(big.Int).Mul(big.NewInt(1_000_000), big.NewInt(params.Ether))
+
+// TestTokenInitCode 测试代币的部署代码，没有构造参数
+func TestTokenInitCode() []byte {
+	return common.FromHex(pkgStore.PermitTokenMetaData.Bin)
+}
+
+// DeployTestToken 用 opts 的账户部署测试代币，只应该在本地开发链上调用
+func DeployTestToken(ctx context.Context, opts *bind.TransactOpts, backend deploy.Backend) (*deploy.Result, error) {
+	return deploy.DeployBytecode(ctx, opts, backend, TestTokenContract, TestTokenInitCode())
+}
@@ -0,0 +1,252 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"level2/gin-example/internal/multicall"
+	"level2/network"
+)
+
+// MaxPoints 时间序列模式最多的采样点数
+const MaxPoints = 200
+
+// ErrNotArchive 节点已经裁剪掉了该区块的状态，查询历史余额需要归档节点
+var ErrNotArchive = errors.New("historical state not available, an archive node is required")
+
+const (
+	// serverErrorCode 各家客户端在历史状态被裁剪时返回的 JSON-RPC 错误码，需要再看错误信息
+	serverErrorCode = -32000
+	// prunedHistoryCode EIP-4444 约定的 "Pruned history unavailable"
+	prunedHistoryCode = 4444
+)
+
+// missingStateErrors 错误码为 serverErrorCode 时，各家客户端 / 服务商表示历史状态不可用的错误信息
+var missingStateErrors = []string{
+	"missing trie node",                          // geth、nethermind
+	"historical state",                           // geth 1.14：historical state xxx is not available
+	"state not available",                        // erigon、reth
+	"state is not available",                     // besu
+	"state histories haven't been fully indexed", // geth path-based 状态历史还在建索引
+	"does not have access to archive state",      // infura 免费套餐
+}
+
+// ArchiveError 某个区块的历史状态查询失败
+type ArchiveError struct {
+	Block uint64
+	Err   error
+}
+
+func (e *ArchiveError) Error() string {
+	return fmt.Sprintf("block %d: %v (%v)", e.Block, ErrNotArchive, e.Err)
+}
+
+func (e *ArchiveError) Unwrap() error { return e.Err }
+
+func (e *ArchiveError) Is(target error) bool { return target == ErrNotArchive }
+
+// IsMissingState 判断节点返回的错误是不是历史状态不可用。
+// 只看节点返回的 JSON-RPC 错误，网络错误、超时以及 HTTP 状态码错误都不算
+func IsMissingState(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	switch rpcErr.ErrorCode() {
+	case prunedHistoryCode:
+		return true
+	case serverErrorCode:
+	default:
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Error())
+	for _, s := range missingStateErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Backend 读取 ETH 余额和区块头
+type Backend interface {
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// Asset 一项资产，Token 为空表示 ETH
+type Asset struct {
+	Token     *common.Address `json:"token,omitempty"`
+	Symbol    string          `json:"symbol"`
+	Decimals  uint8           `json:"decimals"`
+	Balance   string          `json:"balance,omitempty"`   // 最小单位
+	Formatted string          `json:"formatted,omitempty"` // 按 decimals 换算后的值
+	Error     string          `json:"error,omitempty"`
+}
+
+// Snapshot 某个区块上的资产组合
+type Snapshot struct {
+	Block     uint64   `json:"block"`
+	Timestamp uint64   `json:"timestamp"`
+	Assets    []*Asset `json:"assets,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Archive   bool     `json:"archiveRequired,omitempty"` // 失败原因是节点不是归档节点
+}
+
+// Reader 按代币列表读取地址的资产组合
+type Reader struct {
+	backend Backend
+	agg     *multicall.Aggregator
+	tokens  []network.Token
+}
+
+// New 创建 Reader；tokens 中缺少 symbol / decimals 的代币会用 Multicall3 在最新区块上补齐
+func New(ctx context.Context, backend Backend, agg *multicall.Aggregator, tokens []network.Token) (*Reader, error) {
+	resolved := make([]network.Token, len(tokens))
+	copy(resolved, tokens)
+	var missing []common.Address
+	var index []int
+	for i, t := range resolved {
+		if t.Symbol == "" || t.Decimals == nil {
+			missing = append(missing, t.Address)
+			index = append(index, i)
+		}
+	}
+	if len(missing) > 0 {
+		infos, _, err := agg.TokenMetadata(ctx, missing, multicall.Options{})
+		if err != nil {
+			return nil, fmt.Errorf("token metadata: %w", err)
+		}
+		for j, info := range infos {
+			t := &resolved[index[j]]
+			if t.Symbol == "" {
+				t.Symbol = info.Symbol
+			}
+			if t.Decimals == nil {
+				t.Decimals = info.Decimals
+			}
+		}
+	}
+	return &Reader{backend: backend, agg: agg, tokens: resolved}, nil
+}
+
+// Snapshot 读取 block 上的 ETH 和代币余额，block 为 nil 时读取最新区块。
+// 历史状态被裁剪时返回 *ArchiveError；单个代币读取失败只记录在该资产的 Error 里
+func (r *Reader) Snapshot(ctx context.Context, holder common.Address, block *big.Int) (*Snapshot, error) {
+	header, err := r.backend.HeaderByNumber(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	// 固定在同一个区块上读，避免 latest 在两次调用之间变化
+	number := header.Number
+	snap := &Snapshot{Block: number.Uint64(), Timestamp: header.Time}
+
+	balance, err := r.backend.BalanceAt(ctx, holder, number)
+	if err != nil {
+		return nil, r.wrap(snap.Block, err)
+	}
+	snap.Assets = append(snap.Assets, &Asset{
+		Symbol:    "ETH",
+		Decimals:  18,
+		Balance:   balance.String(),
+		Formatted: FormatUnits(balance, 18),
+	})
+	if len(r.tokens) == 0 {
+		return snap, nil
+	}
+
+	addrs := make([]common.Address, len(r.tokens))
+	for i, t := range r.tokens {
+		addrs[i] = t.Address
+	}
+	balances, _, err := r.agg.Balances(ctx, addrs, []common.Address{holder}, multicall.Options{Block: number})
+	if err != nil {
+		return nil, r.wrap(snap.Block, err)
+	}
+	for i, b := range balances {
+		t := r.tokens[i]
+		asset := &Asset{Token: &r.tokens[i].Address, Symbol: t.Symbol}
+		switch {
+		case b.Error != "":
+			asset.Error = b.Error
+		case t.Decimals == nil:
+			asset.Balance = b.Balance.String()
+			asset.Error = "unknown decimals"
+		default:
+			asset.Decimals = *t.Decimals
+			asset.Balance = b.Balance.String()
+			asset.Formatted = FormatUnits(b.Balance, *t.Decimals)
+		}
+		snap.Assets = append(snap.Assets, asset)
+	}
+	return snap, nil
+}
+
+// Series 从 from 到 to 每 step 个区块采样一次（包含 to）。
+// 某个点失败不会中断整个序列，错误记录在该点的 Error 里
+func (r *Reader) Series(ctx context.Context, holder common.Address, from, to, step uint64) ([]*Snapshot, error) {
+	if step == 0 || from > to {
+		return nil, errors.New("invalid range")
+	}
+	points := (to-from)/step + 1
+	if (to-from)%step != 0 {
+		points++
+	}
+	if points > MaxPoints {
+		return nil, fmt.Errorf("range has %d points, at most %d", points, MaxPoints)
+	}
+
+	snaps := make([]*Snapshot, 0, points)
+	for n := from; ; {
+		snap, err := r.Snapshot(ctx, holder, new(big.Int).SetUint64(n))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			snap = &Snapshot{Block: n, Error: err.Error(), Archive: errors.Is(err, ErrNotArchive)}
+		}
+		snaps = append(snaps, snap)
+		if n == to {
+			return snaps, nil
+		}
+		// 先比较再相加，to 接近 MaxUint64 时 n+step 不会溢出
+		if n > to-step {
+			n = to
+		} else {
+			n += step
+		}
+	}
+}
+
+// wrap 历史状态不可用的错误转换成 ArchiveError
+func (r *Reader) wrap(block uint64, err error) error {
+	if IsMissingState(err) {
+		return &ArchiveError{Block: block, Err: err}
+	}
+	return err
+}
+
+// FormatUnits 按 decimals 把最小单位换算成十进制字符串，不经过浮点数，末尾的 0 会去掉
+func FormatUnits(v *big.Int, decimals uint8) string {
+	if decimals == 0 {
+		return v.String()
+	}
+	base := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	abs := new(big.Int).Abs(v)
+	whole, frac := new(big.Int).QuoRem(abs, base, new(big.Int))
+	s := whole.String()
+	if frac.Sign() > 0 {
+		f := fmt.Sprintf("%0*s", decimals, frac.String())
+		s += "." + strings.TrimRight(f, "0")
+	}
+	if v.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeBackend 每个区块的余额都是区块号
+type fakeBackend struct{}
+
+func (fakeBackend) BalanceAt(_ context.Context, _ common.Address, number *big.Int) (*big.Int, error) {
+	return new(big.Int).Set(number), nil
+}
+
+func (fakeBackend) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).Set(number)}, nil
+}
+
+func TestSeriesNearMaxUint64(t *testing.T) {
+	r := &Reader{backend: fakeBackend{}}
+	from := uint64(math.MaxUint64 - 5)
+	snaps, err := r.Series(context.Background(), common.Address{}, from, math.MaxUint64, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint64{from, math.MaxUint64 - 1, math.MaxUint64}
+	if len(snaps) != len(want) {
+		t.Fatalf("got %d points, want %v", len(snaps), want)
+	}
+	for i, s := range snaps {
+		if s.Block != want[i] {
+			t.Fatalf("point %d = block %d, want %d", i, s.Block, want[i])
+		}
+	}
+}
+
+// rpcError 节点返回的 JSON-RPC 错误
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func (e *rpcError) Error() string  { return e.msg }
+func (e *rpcError) ErrorCode() int { return e.code }
+
+func TestIsMissingState(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&rpcError{-32000, "missing trie node 1234 (path )"}, true},
+		{fmt.Errorf("balance: %w", &rpcError{-32000, "historical state abc is not available"}), true},
+		{&rpcError{4444, "pruned history unavailable"}, true},
+		// 只在信息里提到 archive / pruned 的其它错误不算
+		{&rpcError{-32601, "method eth_getBalance not available on archive tier"}, false},
+		{&rpcError{-32000, "block pruned by rate limiter"}, false},
+		{errors.New("dial tcp: missing trie node"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsMissingState(tt.err); got != tt.want {
+			t.Errorf("IsMissingState(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
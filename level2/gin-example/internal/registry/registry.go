@@ -0,0 +1,176 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrNotFound 注册表中没有对应的 ABI
+var ErrNotFound = errors.New("abi not found in registry")
+
+// Entry 注册表中的一条记录：合约名 + ABI，部署过的合约还会带上地址
+type Entry struct {
+	Name    string          `json:"name"`
+	Address *common.Address `json:"address,omitempty"`
+	Source  string          `json:"source,omitempty"`
+	RawABI  json.RawMessage `json:"abi"`
+	ABI     abi.ABI         `json:"-"`
+}
+
+// Registry 保存已知合约的 ABI，可以按地址或按合约名查找
+type Registry struct {
+	mu     sync.RWMutex
+	byName map[string]*Entry
+	byAddr map[common.Address]*Entry
+}
+
+// New 创建一个空的注册表
+func New() *Registry {
+	return &Registry{
+		byName: make(map[string]*Entry),
+		byAddr: make(map[common.Address]*Entry),
+	}
+}
+
+// Register 按合约名登记一份 ABI（JSON 数组），同名的会被覆盖
+func (r *Registry) Register(name string, rawABI []byte, source string) (*Entry, error) {
+	parsed, err := abi.JSON(strings.NewReader(string(rawABI)))
+	if err != nil {
+		return nil, fmt.Errorf("parse abi of %s: %w", name, err)
+	}
+	entry := &Entry{Name: name, Source: source, RawABI: json.RawMessage(rawABI), ABI: parsed}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byName[name] = entry
+	return entry, nil
+}
+
+// Bind 把一个已登记的合约名绑定到链上地址
+func (r *Registry) Bind(name string, address common.Address) (*Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	base, ok := r.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	addr := address
+	entry := &Entry{Name: base.Name, Address: &addr, Source: base.Source, RawABI: base.RawABI, ABI: base.ABI}
+	r.byAddr[address] = entry
+	return entry, nil
+}
+
+// ByName 按合约名查找
+func (r *Registry) ByName(name string) (*Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if entry, ok := r.byName[name]; ok {
+		return entry, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// ByAddress 按合约地址查找
+func (r *Registry) ByAddress(address common.Address) (*Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if entry, ok := r.byAddr[address]; ok {
+		return entry, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, address.Hex())
+}
+
+// Entries 返回所有按名字登记的 ABI，按名字排序
+func (r *Registry) Entries() []*Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := make([]*Entry, 0, len(r.byName))
+	for _, entry := range r.byName {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// ABIs 返回所有已登记的 ABI，用于解码自定义错误、事件等
+func (r *Registry) ABIs() []abi.ABI {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	abis := make([]abi.ABI, 0, len(r.byName))
+	for _, entry := range r.byName {
+		abis = append(abis, entry.ABI)
+	}
+	return abis
+}
+
+// Deployments 返回所有绑定了地址的记录
+func (r *Registry) Deployments() []*Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := make([]*Entry, 0, len(r.byAddr))
+	for _, entry := range r.byAddr {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Address.Hex() < entries[j].Address.Hex() })
+	return entries
+}
+
+// LoadDir 读取目录下的 ABI 文件：
+//   - solc 生成的 *.abi（如 pkg/Store_sol_Store.abi），合约名取最后一个 "_" 之后的部分
+//   - Remix 生成的 artifacts/*.json（如 task2/artifacts/Counter.json），跳过 *_metadata.json
+func (r *Registry) LoadDir(dir string) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, f.Name())
+		switch {
+		case strings.HasSuffix(f.Name(), ".abi"):
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if _, err := r.Register(abiFileContractName(f.Name()), raw, path); err != nil {
+				return err
+			}
+		case strings.HasSuffix(f.Name(), ".json") && !strings.HasSuffix(f.Name(), "_metadata.json"):
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var artifact struct {
+				ABI json.RawMessage `json:"abi"`
+			}
+			if err := json.Unmarshal(raw, &artifact); err != nil || len(artifact.ABI) == 0 {
+				continue // 不是合约 artifact，比如 build-info
+			}
+			name := strings.TrimSuffix(f.Name(), ".json")
+			if _, err := r.Register(name, artifact.ABI, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// abiFileContractName Store_sol_Store.abi -> Store, erc20_sol_ERC20.abi -> ERC20
+func abiFileContractName(file string) string {
+	name := strings.TrimSuffix(file, ".abi")
+	if idx := strings.LastIndex(name, "_"); idx >= 0 {
+		return name[idx+1:]
+	}
+	return name
+}
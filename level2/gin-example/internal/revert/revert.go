@@ -0,0 +1,214 @@
+package revert
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"level2/gin-example/internal/contract"
+)
+
+var (
+	// require(cond, "msg") / revert("msg") 编码为 Error(string)
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	// assert 失败、溢出、除零等编码为 Panic(uint256)
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+
+	stringArgs, _  = abi.NewType("string", "", nil)
+	uint256Args, _ = abi.NewType("uint256", "", nil)
+)
+
+// PanicCode Solidity 内置的 panic 码
+// https://docs.soliditylang.org/en/latest/control-structures.html#panic-via-assert-and-error-via-require
+type PanicCode struct {
+	Name        string
+	Description string
+}
+
+var panicCodes = map[uint64]PanicCode{
+	0x00: {"generic", "generic compiler inserted panic"},
+	0x01: {"assert", "assert(false)"},
+	0x11: {"overflow", "arithmetic underflow or overflow outside of an unchecked block"},
+	0x12: {"division_by_zero", "division or modulo by zero"},
+	0x21: {"enum_conversion", "conversion of a value too big or negative into an enum type"},
+	0x22: {"storage_encoding", "access to an incorrectly encoded storage byte array"},
+	0x31: {"pop_empty_array", ".pop() on an empty array"},
+	0x32: {"array_out_of_bounds", "out-of-bounds or negative index access to an array or bytesN"},
+	0x41: {"out_of_memory", "too much memory allocated or array too large"},
+	0x51: {"zero_function", "call to a zero-initialized variable of internal function type"},
+}
+
+// Reason 解码后的回滚原因
+type Reason struct {
+	Kind      string                 `json:"kind"` // error / panic / custom / empty / unknown
+	Message   string                 `json:"message,omitempty"`
+	PanicCode *uint64                `json:"panicCode,omitempty"`
+	PanicName string                 `json:"panicName,omitempty"`
+	Error     string                 `json:"error,omitempty"` // 自定义错误的签名，如 InsufficientBalance(uint256,uint256)
+	Args      map[string]interface{} `json:"args,omitempty"`
+	Data      string                 `json:"data"`
+}
+
+// Decoder 根据已知 ABI 中声明的 error 解码自定义错误
+type Decoder struct {
+	errors map[string]abi.Error // key 为 4 字节选择器
+}
+
+// NewDecoder 收集这些 ABI 中声明的所有自定义错误
+func NewDecoder(abis ...abi.ABI) *Decoder {
+	d := &Decoder{errors: make(map[string]abi.Error)}
+	for _, parsed := range abis {
+		for _, e := range parsed.Errors {
+			d.errors[string(e.ID[:4])] = e
+		}
+	}
+	return d
+}
+
+// Decode 解码 revert 数据
+func (d *Decoder) Decode(data []byte) *Reason {
+	reason := &Reason{Data: hexutil.Encode(data)}
+	switch {
+	case len(data) == 0:
+		reason.Kind = "empty"
+		reason.Message = "reverted without data"
+	case len(data) < 4:
+		reason.Kind = "unknown"
+	case bytes.Equal(data[:4], errorSelector):
+		values, err := abi.Arguments{{Type: stringArgs}}.Unpack(data[4:])
+		if err != nil {
+			reason.Kind = "unknown"
+			break
+		}
+		reason.Kind = "error"
+		reason.Message = values[0].(string)
+	case bytes.Equal(data[:4], panicSelector):
+		values, err := abi.Arguments{{Type: uint256Args}}.Unpack(data[4:])
+		if err != nil {
+			reason.Kind = "unknown"
+			break
+		}
+		raw := values[0].(*big.Int)
+		reason.Kind = "panic"
+		reason.PanicName = "unknown"
+		if !raw.IsUint64() {
+			// 超出 uint64 的码不是编译器生成的，原样报告，不截断
+			reason.Message = fmt.Sprintf("unknown panic code 0x%x", raw)
+			break
+		}
+		code := raw.Uint64()
+		reason.PanicCode = &code
+		if pc, ok := panicCodes[code]; ok {
+			reason.PanicName = pc.Name
+			reason.Message = pc.Description
+		} else {
+			reason.Message = fmt.Sprintf("unknown panic code 0x%x", code)
+		}
+	default:
+		e, ok := d.errors[string(data[:4])]
+		if !ok {
+			reason.Kind = "unknown"
+			break
+		}
+		values, err := e.Inputs.Unpack(data[4:])
+		if err != nil {
+			reason.Kind = "unknown"
+			break
+		}
+		reason.Kind = "custom"
+		reason.Error = e.Sig
+		reason.Message = e.Name
+		reason.Args = contract.FormatValues(e.Inputs, values)
+	}
+	return reason
+}
+
+// DecodeError 从 eth_call / eth_estimateGas 返回的 JSON-RPC 错误中取出 revert 数据并解码
+func (d *Decoder) DecodeError(err error) (*Reason, bool) {
+	var re *Error
+	if errors.As(err, &re) {
+		return re.Reason, true
+	}
+	data, ok := Data(err)
+	if !ok {
+		// 有些节点对不带数据的 revert 只返回 "execution reverted"
+		if strings.Contains(err.Error(), "execution reverted") {
+			return d.Decode(nil), true
+		}
+		return nil, false
+	}
+	return d.Decode(data), true
+}
+
+// Data 取出 JSON-RPC 错误里 data 字段携带的 revert 数据
+func Data(err error) ([]byte, bool) {
+	var de rpc.DataError
+	if !errors.As(err, &de) {
+		return nil, false
+	}
+	s, ok := de.ErrorData().(string)
+	if !ok || !strings.HasPrefix(s, "0x") {
+		return nil, false
+	}
+	data, decodeErr := hexutil.Decode(s)
+	if decodeErr != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Error 交易回执失败后重新模拟得到的回滚错误
+type Error struct {
+	TxHash string
+	Reason *Reason
+}
+
+func (e *Error) Error() string {
+	if e.Reason.Message != "" {
+		return fmt.Sprintf("transaction %s reverted: %s", e.TxHash, e.Reason.Message)
+	}
+	return fmt.Sprintf("transaction %s reverted", e.TxHash)
+}
+
+// FromReceipt 对失败的交易在出块前的状态（父区块）上重新执行一次 eth_call，拿到回滚原因。
+// 交易成功时返回 nil。
+func (d *Decoder) FromReceipt(ctx context.Context, caller ethereum.ContractCaller, tx *types.Transaction, receipt *types.Receipt) (*Reason, error) {
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		return nil, nil
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, err
+	}
+	msg := ethereum.CallMsg{
+		From:      from,
+		To:        tx.To(),
+		Gas:       tx.Gas(),
+		GasFeeCap: tx.GasFeeCap(),
+		GasTipCap: tx.GasTipCap(),
+		Value:     tx.Value(),
+		Data:      tx.Data(),
+	}
+	if tx.Type() == types.LegacyTxType {
+		msg.GasPrice, msg.GasFeeCap, msg.GasTipCap = tx.GasPrice(), nil, nil
+	}
+	parent := new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1))
+	_, callErr := caller.CallContract(ctx, msg, parent)
+	if callErr == nil {
+		// 同一区块里前面的交易改变了状态，单独重放不一定能复现
+		return &Reason{Kind: "unknown", Message: "transaction failed but replay on parent block succeeded"}, nil
+	}
+	if reason, ok := d.DecodeError(callErr); ok {
+		return reason, nil
+	}
+	return &Reason{Kind: "unknown", Message: callErr.Error()}, nil
+}
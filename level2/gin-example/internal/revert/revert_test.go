@@ -0,0 +1,112 @@
+package revert
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// rpcError 模拟节点返回的带 data 的 JSON-RPC 错误
+type rpcError struct{ data interface{} }
+
+func (e *rpcError) Error() string          { return "execution reverted" }
+func (e *rpcError) ErrorData() interface{} { return e.data }
+
+func encode(t *testing.T, selector []byte, typ abi.Type, v interface{}) []byte {
+	t.Helper()
+	packed, err := abi.Arguments{{Type: typ}}.Pack(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(append([]byte{}, selector...), packed...)
+}
+
+func TestDecodeError(t *testing.T) {
+	r := NewDecoder().Decode(encode(t, errorSelector, stringArgs, "ERC20: insufficient allowance"))
+	if r.Kind != "error" || r.Message != "ERC20: insufficient allowance" {
+		t.Fatalf("reason = %+v", r)
+	}
+}
+
+func TestDecodePanic(t *testing.T) {
+	wide, _ := new(big.Int).SetString("10000000000000011", 16) // 低 64 位是 0x11，截断后会误报成溢出
+	tests := []struct {
+		code    *big.Int
+		name    string
+		numeric bool
+		message string
+	}{
+		{big.NewInt(0x01), "assert", true, "assert(false)"},
+		{big.NewInt(0x11), "overflow", true, "arithmetic underflow or overflow outside of an unchecked block"},
+		{big.NewInt(0x99), "unknown", true, "unknown panic code 0x99"},
+		{wide, "unknown", false, "unknown panic code 0x10000000000000011"},
+	}
+	d := NewDecoder()
+	for _, tt := range tests {
+		r := d.Decode(encode(t, panicSelector, uint256Args, tt.code))
+		if r.Kind != "panic" || r.PanicName != tt.name || r.Message != tt.message {
+			t.Errorf("panic 0x%x: reason = %+v", tt.code, r)
+		}
+		if (r.PanicCode != nil) != tt.numeric || (r.PanicCode != nil && *r.PanicCode != tt.code.Uint64()) {
+			t.Errorf("panic 0x%x: panicCode = %v", tt.code, r.PanicCode)
+		}
+	}
+}
+
+func TestDecodeCustom(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(`[{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := parsed.Errors["InsufficientBalance"]
+	packed, err := e.Inputs.Pack(big.NewInt(5), big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append(append([]byte{}, e.ID[:4]...), packed...)
+
+	r := NewDecoder(parsed).Decode(data)
+	if r.Kind != "custom" || r.Error != "InsufficientBalance(uint256,uint256)" || r.Message != "InsufficientBalance" {
+		t.Fatalf("reason = %+v", r)
+	}
+	if r.Args["available"] != "5" || r.Args["required"] != "10" {
+		t.Fatalf("args = %v", r.Args)
+	}
+	// 不认识的选择器
+	if r := NewDecoder().Decode(data); r.Kind != "unknown" || r.Data != hexutil.Encode(data) {
+		t.Fatalf("without abi: reason = %+v", r)
+	}
+}
+
+func TestDecodeEdgeCases(t *testing.T) {
+	d := NewDecoder()
+	if r := d.Decode(nil); r.Kind != "empty" {
+		t.Errorf("empty: %+v", r)
+	}
+	if r := d.Decode([]byte{0x08, 0xc3}); r.Kind != "unknown" {
+		t.Errorf("short: %+v", r)
+	}
+	// 选择器对但数据截断
+	if r := d.Decode(errorSelector); r.Kind != "unknown" {
+		t.Errorf("truncated: %+v", r)
+	}
+}
+
+func TestDecodeRPCError(t *testing.T) {
+	d := NewDecoder()
+	data := encode(t, errorSelector, stringArgs, "nope")
+	r, ok := d.DecodeError(&rpcError{data: hexutil.Encode(data)})
+	if !ok || r.Message != "nope" {
+		t.Fatalf("DecodeError = %+v, %v", r, ok)
+	}
+	if r, ok := d.DecodeError(errors.New("execution reverted")); !ok || r.Kind != "empty" {
+		t.Fatalf("bare revert = %+v, %v", r, ok)
+	}
+	if _, ok := d.DecodeError(errors.New("connection refused")); ok {
+		t.Fatal("non-revert error decoded")
+	}
+}
@@ -0,0 +1,278 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrBusy 已经有一个扫描任务在运行
+var ErrBusy = errors.New("scan already running")
+
+// saveInterval 每扫描这么多个区块写一次文件，整个存储每次都要重新序列化，逐块保存在长范围扫描时是 O(n²)
+const saveInterval = 100
+
+// transferTopic Transfer(address,address,uint256)，ERC-20 和 ERC-721 共用
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// Backend 扫描需要的节点接口，*ethclient.Client 满足
+type Backend interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// Status 扫描进度
+type Status struct {
+	Running       bool             `json:"running"`
+	From          uint64           `json:"from,omitempty"`
+	To            uint64           `json:"to,omitempty"`
+	Current       uint64           `json:"current,omitempty"`
+	LastBlock     uint64           `json:"lastBlock"`
+	BlockReceipts bool             `json:"blockReceipts"` // 节点是否支持 eth_getBlockReceipts
+	Watched       []common.Address `json:"watched"`
+	Error         string           `json:"error,omitempty"` // 上一次扫描的错误
+}
+
+// Scanner 按区块范围扫描，记录被监控地址作为发送方、接收方或出现在 Transfer 事件里的交易
+type Scanner struct {
+	backend Backend
+	store   *Store
+
+	mu              sync.Mutex
+	status          Status
+	noBlockReceipts bool
+}
+
+// New 创建扫描器
+func New(backend Backend, store *Store) *Scanner {
+	return &Scanner{backend: backend, store: store}
+}
+
+// Store 扫描结果存储
+func (s *Scanner) Store() *Store {
+	return s.store
+}
+
+// Status 当前扫描进度
+func (s *Scanner) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.status
+	st.BlockReceipts = !s.noBlockReceipts
+	st.LastBlock = s.store.LastBlock()
+	st.Watched = s.store.Watched()
+	return st
+}
+
+// Start 在后台扫描 [from, to]，已有任务在运行时返回 ErrBusy
+func (s *Scanner) Start(from, to uint64) error {
+	if from > to {
+		return fmt.Errorf("invalid range %d-%d", from, to)
+	}
+	s.mu.Lock()
+	if s.status.Running {
+		s.mu.Unlock()
+		return ErrBusy
+	}
+	s.status = Status{Running: true, From: from, To: to}
+	s.mu.Unlock()
+
+	go func() {
+		err := s.ScanRange(context.Background(), from, to)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.status.Running = false
+		if err != nil {
+			s.status.Error = err.Error()
+		}
+	}()
+	return nil
+}
+
+// ScanRange 同步扫描 [from, to]，每 saveInterval 个区块以及结束（包括出错）时保存进度
+func (s *Scanner) ScanRange(ctx context.Context, from, to uint64) (err error) {
+	watched := make(map[common.Address]bool)
+	for _, a := range s.store.Watched() {
+		watched[a] = true
+	}
+	if len(watched) == 0 {
+		return errors.New("no watched address")
+	}
+	chainID, err := s.backend.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	signer := types.LatestSignerForChainID(chainID)
+
+	dirty := false
+	defer func() {
+		if dirty {
+			if saveErr := s.store.Save(); err == nil {
+				err = saveErr
+			}
+		}
+	}()
+	for n := from; ; n++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.status.Current = n
+		s.mu.Unlock()
+
+		if err := s.scanBlock(ctx, n, signer, watched); err != nil {
+			return fmt.Errorf("block %d: %w", n, err)
+		}
+		s.store.MarkScanned(n)
+		dirty = true
+		if (n-from+1)%saveInterval == 0 {
+			if err := s.store.Save(); err != nil {
+				return err
+			}
+			dirty = false
+		}
+		// to 可能是 MaxUint64，先判断再自增，避免回绕
+		if n == to {
+			return nil
+		}
+	}
+}
+
+func (s *Scanner) scanBlock(ctx context.Context, n uint64, signer types.Signer, watched map[common.Address]bool) error {
+	block, err := s.backend.BlockByNumber(ctx, new(big.Int).SetUint64(n))
+	if err != nil {
+		return err
+	}
+	if len(block.Transactions()) == 0 {
+		return nil
+	}
+	receipts, err := s.receipts(ctx, block)
+	if err != nil {
+		return err
+	}
+
+	for i, tx := range block.Transactions() {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return fmt.Errorf("recover sender of %s: %w", tx.Hash().Hex(), err)
+		}
+		receipt := receipts[i]
+		base := Record{
+			TxHash:    tx.Hash(),
+			Block:     n,
+			TxIndex:   uint(i),
+			Timestamp: block.Time(),
+			Status:    receipt.Status,
+		}
+
+		to := tx.To()
+		for _, addr := range involved(from, to, watched) {
+			r := base
+			r.Via, r.From, r.To, r.Value = "tx", from, to, tx.Value().String()
+			r.Direction = direction(addr, from, to)
+			s.store.Add(addr, r)
+		}
+
+		for _, log := range receipt.Logs {
+			r, ok := transferRecord(base, log)
+			if !ok {
+				continue
+			}
+			for _, addr := range involved(r.From, r.To, watched) {
+				rec := r
+				rec.Direction = direction(addr, r.From, r.To)
+				s.store.Add(addr, rec)
+			}
+		}
+	}
+	return nil
+}
+
+// receipts 优先用 eth_getBlockReceipts 一次取回整个区块的收据，节点不支持时退回逐笔查询
+func (s *Scanner) receipts(ctx context.Context, block *types.Block) ([]*types.Receipt, error) {
+	s.mu.Lock()
+	useBlock := !s.noBlockReceipts
+	s.mu.Unlock()
+
+	if useBlock {
+		receipts, err := s.backend.BlockReceipts(ctx, rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+		if err == nil && len(receipts) == len(block.Transactions()) {
+			return receipts, nil
+		}
+		if err != nil && !isMethodNotFound(err) {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.noBlockReceipts = true
+		s.mu.Unlock()
+	}
+
+	receipts := make([]*types.Receipt, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		receipt, err := s.backend.TransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+// transferRecord 解析 Transfer 事件：3 个 topic 是 ERC-20（金额在 data），4 个 topic 是 ERC-721（tokenId 在 topic3）
+func transferRecord(base Record, log *types.Log) (Record, bool) {
+	if len(log.Topics) < 3 || log.Topics[0] != transferTopic {
+		return Record{}, false
+	}
+	r := base
+	token := log.Address
+	to := common.BytesToAddress(log.Topics[2].Bytes())
+	idx := log.Index
+	r.Via, r.Token, r.LogIndex = "log", &token, &idx
+	r.From, r.To = common.BytesToAddress(log.Topics[1].Bytes()), &to
+	switch {
+	case len(log.Topics) == 3 && len(log.Data) == 32:
+		r.Value = new(big.Int).SetBytes(log.Data).String()
+	case len(log.Topics) == 4 && len(log.Data) == 0:
+		r.TokenID = log.Topics[3].Big().String()
+	default:
+		return Record{}, false
+	}
+	return r, true
+}
+
+// involved 被监控的地址中哪些出现在 from / to 里
+func involved(from common.Address, to *common.Address, watched map[common.Address]bool) []common.Address {
+	var out []common.Address
+	if watched[from] {
+		out = append(out, from)
+	}
+	if to != nil && *to != from && watched[*to] {
+		out = append(out, *to)
+	}
+	return out
+}
+
+func direction(addr, from common.Address, to *common.Address) string {
+	switch {
+	case to != nil && from == *to:
+		return "self"
+	case addr == from:
+		return "out"
+	}
+	return "in"
+}
+
+// isMethodNotFound JSON-RPC -32601，节点没有实现该方法
+func isMethodNotFound(err error) bool {
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32601
+}
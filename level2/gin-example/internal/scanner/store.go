@@ -0,0 +1,192 @@
+package scanner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"level2/gin-example/internal/explorer"
+)
+
+// Record 与被监控地址相关的一条记录：普通交易（via=tx）或 Transfer 事件（via=log）
+type Record struct {
+	TxHash    common.Hash     `json:"txHash"`
+	Block     uint64          `json:"block"`
+	TxIndex   uint            `json:"txIndex"`
+	Timestamp uint64          `json:"timestamp"`
+	Via       string          `json:"via"`       // tx / log
+	Direction string          `json:"direction"` // in / out / self
+	From      common.Address  `json:"from"`
+	To        *common.Address `json:"to"`              // 合约创建交易为 null
+	Value     string          `json:"value,omitempty"` // ERC-721 Transfer 没有金额
+	Status    uint64          `json:"status"`
+	Token     *common.Address `json:"token,omitempty"`    // Transfer 事件的合约地址
+	TokenID   string          `json:"tokenId,omitempty"`  // ERC-721 Transfer 的 tokenId
+	LogIndex  *uint           `json:"logIndex,omitempty"` // via=log 时的日志序号
+}
+
+func (r *Record) key() string {
+	if r.LogIndex != nil {
+		return fmt.Sprintf("%s/%d", r.TxHash.Hex(), *r.LogIndex)
+	}
+	return r.TxHash.Hex()
+}
+
+// Store 把扫描结果保存在本地 JSON 文件里
+type Store struct {
+	mu     sync.RWMutex
+	saveMu sync.Mutex // 串行化 Save，后取的快照一定后写入
+	path   string
+	data   storeData
+	seen   map[string]bool // 地址 + 记录 key，防止重复扫描同一区块时重复记录
+}
+
+type storeData struct {
+	Watched   []common.Address            `json:"watched"`
+	LastBlock uint64                      `json:"lastBlock"` // 已扫描到的最高区块
+	Records   map[common.Address][]Record `json:"records"`
+}
+
+// OpenStore 读取本地文件，不存在时返回空的存储
+func OpenStore(path string) (*Store, error) {
+	s := &Store{path: path, data: storeData{Records: make(map[common.Address][]Record)}, seen: make(map[string]bool)}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return nil, err
+	}
+	if s.data.Records == nil {
+		s.data.Records = make(map[common.Address][]Record)
+	}
+	for addr, records := range s.data.Records {
+		for i := range records {
+			s.seen[addr.Hex()+records[i].key()] = true
+		}
+	}
+	return s, nil
+}
+
+// Watch 增加监控地址，返回是否是新地址
+func (s *Store) Watch(address common.Address) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.data.Watched {
+		if a == address {
+			return false
+		}
+	}
+	s.data.Watched = append(s.data.Watched, address)
+	return true
+}
+
+// Watched 当前监控的地址
+func (s *Store) Watched() []common.Address {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]common.Address(nil), s.data.Watched...)
+}
+
+// LastBlock 已扫描到的最高区块
+func (s *Store) LastBlock() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.LastBlock
+}
+
+// Add 追加记录，已经存在的记录会被忽略
+func (s *Store) Add(address common.Address, records ...Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range records {
+		k := address.Hex() + r.key()
+		if s.seen[k] {
+			continue
+		}
+		s.seen[k] = true
+		s.data.Records[address] = append(s.data.Records[address], r)
+	}
+}
+
+// MarkScanned 记录扫描进度
+func (s *Store) MarkScanned(block uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if block > s.data.LastBlock {
+		s.data.LastBlock = block
+	}
+}
+
+// History 按区块倒序分页返回地址的记录，page 从 1 开始
+func (s *Store) History(address common.Address, page, size int) ([]Record, int) {
+	s.mu.RLock()
+	records := append([]Record(nil), s.data.Records[address]...)
+	s.mu.RUnlock()
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Block != records[j].Block {
+			return records[i].Block > records[j].Block
+		}
+		if records[i].TxIndex != records[j].TxIndex {
+			return records[i].TxIndex > records[j].TxIndex
+		}
+		return logIndex(records[i]) < logIndex(records[j])
+	})
+	start, end := explorer.PageBounds(len(records), page, size)
+	return records[start:end], len(records)
+}
+
+// Save 写回文件，先写同目录下的临时文件、fsync 后再改名，避免中途失败损坏已有数据。
+// 监控地址的接口和扫描器会同时调用，快照、写入和改名在 saveMu 下完成，旧快照不会覆盖新快照
+func (s *Store) Save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.mu.RLock()
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // 改名成功后文件已经不在了
+	if _, err := f.Write(raw); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path)
+}
+
+// logIndex 同一笔交易内，交易本身排在它产生的日志之前
+func logIndex(r Record) int {
+	if r.LogIndex == nil {
+		return -1
+	}
+	return int(*r.LogIndex)
+}
@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestHistoryPaging(t *testing.T) {
+	s, err := OpenStore(filepath.Join(t.TempDir(), "scan.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := common.HexToAddress("0x1")
+	for i := 0; i < 10; i++ {
+		s.Add(addr, Record{TxHash: common.Hash{byte(i + 1)}, Block: uint64(i)})
+	}
+	// 重复的记录被忽略
+	s.Add(addr, Record{TxHash: common.Hash{1}, Block: 0})
+
+	records, total := s.History(addr, 3, 4)
+	if total != 10 || len(records) != 2 || records[0].Block != 1 {
+		t.Fatalf("page 3 = %d records of %d, want blocks 1 and 0 of 10", len(records), total)
+	}
+	// (page-1)*size 溢出时不能 panic
+	records, total = s.History(addr, 1<<62, 4)
+	if total != 10 || len(records) != 0 {
+		t.Fatalf("huge page = %d records of %d, want 0 of 10", len(records), total)
+	}
+}
+
+// 监控地址的接口和扫描器并发保存时，最后写入的文件要包含所有已经加入的地址
+func TestConcurrentWatchAndSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.json")
+	s, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 32
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			s.Watch(common.BigToAddress(big.NewInt(int64(i + 1))))
+			errs <- s.Save()
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			s.MarkScanned(uint64(i))
+			errs <- s.Save()
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(reopened.Watched()); got != n {
+		t.Fatalf("saved %d watched addresses, want %d", got, n)
+	}
+	if got := reopened.LastBlock(); got != n-1 {
+		t.Fatalf("saved last block %d, want %d", got, n-1)
+	}
+	// 临时文件都已经改名或删除
+	if matches, _ := filepath.Glob(path + ".*.tmp"); len(matches) != 0 {
+		t.Fatalf("left temp files %v", matches)
+	}
+}
@@ -0,0 +1,173 @@
+package signature
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// domainType EIP-712 域的类型名
+const domainType = "EIP712Domain"
+
+// domainFields 域字段的规范顺序，ethers.js 等库不在 types 里写 EIP712Domain，按这个顺序从 domain 推出
+var domainFields = []apitypes.Type{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+	{Name: "chainId", Type: "uint256"},
+	{Name: "verifyingContract", Type: "address"},
+	{Name: "salt", Type: "bytes32"},
+}
+
+// ParseTypedData 解析 eth_signTypedData_v4 格式的 JSON（types、primaryType、domain、message）。
+// 数字按字符串保留，避免大整数经过 float64 丢失精度；types 里缺少 EIP712Domain 时按 domain 里出现的字段补上
+func ParseTypedData(raw []byte) (*apitypes.TypedData, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var td apitypes.TypedData
+	if err := dec.Decode(&td); err != nil {
+		return nil, fmt.Errorf("invalid typed data: %w", err)
+	}
+	if td.PrimaryType == "" {
+		return nil, errors.New("invalid typed data: primaryType is required")
+	}
+	if _, ok := td.Types[td.PrimaryType]; !ok {
+		return nil, fmt.Errorf("invalid typed data: primaryType %s is not defined in types", td.PrimaryType)
+	}
+	if _, ok := td.Types[domainType]; !ok {
+		domain := td.Domain.Map()
+		for _, f := range domainFields {
+			if _, ok := domain[f.Name]; ok {
+				td.Types[domainType] = append(td.Types[domainType], f)
+			}
+		}
+	}
+	td.Message = numbersToStrings(td.Message).(map[string]interface{})
+	return &td, nil
+}
+
+// numbersToStrings 把 json.Number 换成字符串，apitypes 能按十进制解析字符串，但不认识 json.Number
+func numbersToStrings(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		return v.String()
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = numbersToStrings(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = numbersToStrings(item)
+		}
+		return v
+	}
+	return v
+}
+
+// StructHash 一个结构体的中间结果：hashStruct(s) = keccak256(typeHash ‖ encodeData(s))
+type StructHash struct {
+	Path       string        `json:"path"` // 在文档里的位置，如 domain、message.from、message.items[0]
+	Type       string        `json:"type"`
+	EncodeType string        `json:"encodeType"`
+	TypeHash   common.Hash   `json:"typeHash"`
+	Encoded    hexutil.Bytes `json:"encodedData"` // typeHash ‖ enc(value₁) ‖ … ‖ enc(valueₙ)
+	Hash       common.Hash   `json:"hash"`
+	Children   []*StructHash `json:"children,omitempty"` // 嵌套结构体，各自的 hash 出现在父结构体的 encodedData 里
+}
+
+// TypedHash 签名摘要及其组成部分：digest = keccak256(0x1901 ‖ domainSeparator ‖ hashStruct(message))
+type TypedHash struct {
+	DomainSeparator common.Hash   `json:"domainSeparator"`
+	StructHash      common.Hash   `json:"structHash"`
+	RawData         hexutil.Bytes `json:"rawData"` // 0x1901 ‖ domainSeparator ‖ structHash
+	Digest          common.Hash   `json:"digest"`
+	// 调试模式下才有，便于和其他实现逐项对比
+	Domain  *StructHash `json:"domain,omitempty"`
+	Message *StructHash `json:"message,omitempty"`
+}
+
+// HashTypedData 计算 EIP-712 摘要；debug 为 true 时附带域和消息（含嵌套结构体）每一层的 encodeType、typeHash、encodedData 和 hash
+func HashTypedData(td *apitypes.TypedData, debug bool) (*TypedHash, error) {
+	digest, rawData, err := apitypes.TypedDataAndHash(*td)
+	if err != nil {
+		return nil, err
+	}
+	raw := []byte(rawData)
+	h := &TypedHash{
+		DomainSeparator: common.BytesToHash(raw[2:34]),
+		StructHash:      common.BytesToHash(raw[34:66]),
+		RawData:         raw,
+		Digest:          common.BytesToHash(digest),
+	}
+	if !debug {
+		return h, nil
+	}
+	if h.Domain, err = explainStruct(td, "domain", domainType, td.Domain.Map()); err != nil {
+		return nil, err
+	}
+	if h.Message, err = explainStruct(td, "message", td.PrimaryType, td.Message); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func explainStruct(td *apitypes.TypedData, path, typ string, data map[string]interface{}) (*StructHash, error) {
+	encoded, err := td.EncodeData(typ, data, 1)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	s := &StructHash{
+		Path:       path,
+		Type:       typ,
+		EncodeType: string(td.EncodeType(typ)),
+		TypeHash:   common.BytesToHash(td.TypeHash(typ)),
+		Encoded:    encoded,
+		Hash:       crypto.Keccak256Hash(encoded),
+	}
+	for _, field := range td.Types[typ] {
+		children, err := explainValue(td, path+"."+field.Name, field.Type, data[field.Name])
+		if err != nil {
+			return nil, err
+		}
+		s.Children = append(s.Children, children...)
+	}
+	return s, nil
+}
+
+// explainValue 字段是结构体或结构体数组时展开，基本类型没有中间哈希
+func explainValue(td *apitypes.TypedData, path, typ string, value interface{}) ([]*StructHash, error) {
+	if i := strings.LastIndexByte(typ, '['); i > 0 {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, nil
+		}
+		var out []*StructHash
+		for n, item := range items {
+			children, err := explainValue(td, fmt.Sprintf("%s[%d]", path, n), typ[:i], item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, children...)
+		}
+		return out, nil
+	}
+	if _, ok := td.Types[typ]; !ok {
+		return nil, nil
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected %s object", path, typ)
+	}
+	s, err := explainStruct(td, path, typ, m)
+	if err != nil {
+		return nil, err
+	}
+	return []*StructHash{s}, nil
+}
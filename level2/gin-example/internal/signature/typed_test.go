@@ -0,0 +1,104 @@
+package signature
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// mailExample EIP-712 规范里的 Mail 示例（assets/eip-712/Example.js）
+const mailExample = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+// 规范示例给出的中间结果和最终摘要
+var (
+	mailDomainSeparator = common.HexToHash("0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f")
+	mailStructHash      = common.HexToHash("0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e")
+	mailDigest          = common.HexToHash("0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2")
+	mailTypeHash        = common.HexToHash("0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2")
+)
+
+func TestHashTypedDataMail(t *testing.T) {
+	// ethers.js 风格不写 EIP712Domain，按 domain 里的字段推出来，结果应该相同
+	withoutDomainType := strings.Replace(mailExample, `"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],`, "", 1)
+	for name, raw := range map[string]string{"spec": mailExample, "inferred domain type": withoutDomainType} {
+		t.Run(name, func(t *testing.T) {
+			td, err := ParseTypedData([]byte(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			h, err := HashTypedData(td, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.DomainSeparator != mailDomainSeparator {
+				t.Errorf("domainSeparator = %s, want %s", h.DomainSeparator, mailDomainSeparator)
+			}
+			if h.StructHash != mailStructHash {
+				t.Errorf("structHash = %s, want %s", h.StructHash, mailStructHash)
+			}
+			if h.Digest != mailDigest {
+				t.Errorf("digest = %s, want %s", h.Digest, mailDigest)
+			}
+			if want := append([]byte{0x19, 0x01}, append(mailDomainSeparator.Bytes(), mailStructHash.Bytes()...)...); !bytes.Equal(h.RawData, want) {
+				t.Errorf("rawData = %x, want %x", h.RawData, want)
+			}
+
+			// 调试输出和摘要用的是同一组值
+			if h.Domain.Hash != mailDomainSeparator || h.Message.Hash != mailStructHash {
+				t.Errorf("debug hashes = %s, %s", h.Domain.Hash, h.Message.Hash)
+			}
+			if want := "Mail(Person from,Person to,string contents)Person(string name,address wallet)"; h.Message.EncodeType != want {
+				t.Errorf("encodeType = %q, want %q", h.Message.EncodeType, want)
+			}
+			if h.Message.TypeHash != mailTypeHash {
+				t.Errorf("typeHash = %s, want %s", h.Message.TypeHash, mailTypeHash)
+			}
+			if len(h.Message.Children) != 2 || h.Message.Children[0].Path != "message.from" || h.Message.Children[1].Path != "message.to" {
+				t.Fatalf("children = %+v, want message.from and message.to", h.Message.Children)
+			}
+			// 子结构体的 hash 按字段顺序出现在父结构体的 encodedData 里，紧跟在 typeHash 后面
+			for i, child := range h.Message.Children {
+				if got := common.BytesToHash(h.Message.Encoded[32*(i+1) : 32*(i+2)]); got != child.Hash {
+					t.Errorf("encodedData[%d] = %s, want %s hash %s", i+1, got, child.Path, child.Hash)
+				}
+			}
+		})
+	}
+}
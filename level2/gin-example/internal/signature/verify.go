@@ -0,0 +1,181 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Magic1271 isValidSignature 校验通过时返回的值：bytes4(keccak256("isValidSignature(bytes32,bytes)"))
+var Magic1271 = [4]byte{0x16, 0x26, 0xba, 0x7e}
+
+var (
+	// ErrLength 签名不是 65 字节 r||s||v
+	ErrLength = errors.New("signature must be 65 bytes")
+	// ErrMismatch 签名有效，但既不是该地址的私钥签的，合约钱包也不认可
+	ErrMismatch = errors.New("signature does not match address")
+	// ErrHighS s 在曲线阶的上半部分。(r, N-s) 对同一条消息同样能恢复出签名者，
+	// 按 EIP-2 和 OpenZeppelin ECDSA 的做法视为可延展签名拒绝
+	ErrHighS = errors.New("malleable signature: s is in the upper half of the curve order")
+)
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+var isValidSignatureABI, _ = abi.JSON(bytes.NewReader([]byte(`[{"inputs":[{"internalType":"bytes32","name":"hash","type":"bytes32"},{"internalType":"bytes","name":"signature","type":"bytes"}],"name":"isValidSignature","outputs":[{"internalType":"bytes4","name":"magicValue","type":"bytes4"}],"stateMutability":"view","type":"function"}]`)))
+
+// Method 签名最终是怎么被认可的
+const (
+	MethodECDSA   = "ecdsa"   // EOA 私钥签名
+	MethodEIP1271 = "eip1271" // 合约钱包 isValidSignature 返回 magic value
+)
+
+// Result 校验结果
+type Result struct {
+	Valid     bool            `json:"valid"`
+	Method    string          `json:"method,omitempty"`
+	Hash      common.Hash     `json:"hash"`
+	Recovered *common.Address `json:"recovered,omitempty"` // ECDSA 恢复出的地址，签名格式有问题时为空
+	HighS     bool            `json:"highS"`
+	Reason    string          `json:"reason,omitempty"` // 无效的原因
+}
+
+// PersonalHash EIP-191 personal_sign 的摘要：keccak256("\x19Ethereum Signed Message:\n" + len(msg) + msg)
+func PersonalHash(msg []byte) common.Hash {
+	return common.BytesToHash(accounts.TextHash(msg))
+}
+
+// NormalizeV 返回 v 为 0/1 的签名副本，钱包常见的 27/28 会被转换
+func NormalizeV(sig []byte) ([]byte, error) {
+	if len(sig) != crypto.SignatureLength {
+		return nil, ErrLength
+	}
+	out := common.CopyBytes(sig)
+	if out[64] >= 27 {
+		out[64] -= 27
+	}
+	if out[64] > 1 {
+		return nil, fmt.Errorf("invalid recovery id v=%d", sig[64])
+	}
+	return out, nil
+}
+
+// Recover 从摘要和签名中恢复签名者地址
+func Recover(hash common.Hash, sig []byte) (common.Address, error) {
+	normalized, err := NormalizeV(sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	pub, err := crypto.SigToPub(hash.Bytes(), normalized)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// IsHighS 65 字节签名的 s 是否大于 N/2
+func IsHighS(sig []byte) bool {
+	if len(sig) != crypto.SignatureLength {
+		return false
+	}
+	return new(big.Int).SetBytes(sig[32:64]).Cmp(secp256k1HalfN) > 0
+}
+
+// LowS 返回同一签名的低 s 形式：s' = N - s，恢复标识 v 翻转，v 的表示方式（0/1 或 27/28）保持不变
+func LowS(sig []byte) ([]byte, error) {
+	if _, err := NormalizeV(sig); err != nil {
+		return nil, err
+	}
+	out := common.CopyBytes(sig)
+	if !IsHighS(sig) {
+		return out, nil
+	}
+	s := new(big.Int).Sub(secp256k1N, new(big.Int).SetBytes(sig[32:64]))
+	s.FillBytes(out[32:64])
+	if out[64] >= 27 {
+		out[64] = 27 + ((out[64] - 27) ^ 1)
+	} else {
+		out[64] ^= 1
+	}
+	return out, nil
+}
+
+// Verify 校验 address 对 hash 的签名：先按 ECDSA 恢复，不匹配且 address 是合约时调用 EIP-1271 isValidSignature。
+// 合约钱包的签名格式由钱包自己定义（可能不是 65 字节），所以 ECDSA 失败不会直接返回错误。
+// 能恢复出 address 但 s 偏高的签名判为无效（HighS，Reason 为 ErrHighS）
+func Verify(ctx context.Context, caller bind.ContractCaller, address common.Address, hash common.Hash, sig []byte) (*Result, error) {
+	result := &Result{Hash: hash, HighS: IsHighS(sig)}
+	if recovered, err := Recover(hash, sig); err == nil {
+		result.Recovered = &recovered
+		if recovered == address {
+			if result.HighS {
+				result.Reason = ErrHighS.Error()
+				return result, nil
+			}
+			result.Valid = true
+			result.Method = MethodECDSA
+			return result, nil
+		}
+	}
+	if caller == nil {
+		result.Reason = ErrMismatch.Error()
+		return result, nil
+	}
+	ok, err := IsValidSignature(ctx, caller, address, hash, sig)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		result.Valid = true
+		result.Method = MethodEIP1271
+	} else {
+		result.Reason = ErrMismatch.Error()
+	}
+	return result, nil
+}
+
+// IsValidSignature 调用 EIP-1271 isValidSignature(hash, sig)。地址没有代码、调用回滚或返回值不是 magic value 都视为无效
+func IsValidSignature(ctx context.Context, caller bind.ContractCaller, address common.Address, hash common.Hash, sig []byte) (bool, error) {
+	code, err := caller.CodeAt(ctx, address, nil)
+	if err != nil {
+		return false, err
+	}
+	if len(code) == 0 {
+		return false, nil
+	}
+	data, err := isValidSignatureABI.Pack("isValidSignature", hash, sig)
+	if err != nil {
+		return false, err
+	}
+	out, err := caller.CallContract(ctx, ethereum.CallMsg{To: &address, Data: data}, nil)
+	if err != nil {
+		// 回滚表示签名无效，节点错误才返回
+		if isRevert(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	// 返回值是 ABI 编码的 bytes4，左对齐在 32 字节里
+	return len(out) >= 4 && bytes.Equal(out[:4], Magic1271[:]), nil
+}
+
+// isRevert eth_call 回滚的错误（节点返回 execution reverted）
+func isRevert(err error) bool {
+	var dataErr interface{ ErrorData() interface{} }
+	if errors.As(err, &dataErr) {
+		return true
+	}
+	return strings.Contains(err.Error(), "revert")
+}
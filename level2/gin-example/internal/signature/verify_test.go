@@ -0,0 +1,217 @@
+package signature
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// sign 用新私钥签 hash，返回地址和 v 为 0/1 的签名；go-ethereum 签出来的 s 总是低的
+func sign(t *testing.T, hash common.Hash) (common.Address, []byte) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crypto.PubkeyToAddress(key.PublicKey), sig
+}
+
+// highS 同一签名的高 s 形式：s' = N - s，v 翻转
+func highS(sig []byte) []byte {
+	out := common.CopyBytes(sig)
+	new(big.Int).Sub(secp256k1N, new(big.Int).SetBytes(sig[32:64])).FillBytes(out[32:64])
+	out[64] ^= 1
+	return out
+}
+
+func withV(sig []byte, v byte) []byte {
+	out := common.CopyBytes(sig)
+	out[64] = v
+	return out
+}
+
+func TestNormalizeV(t *testing.T) {
+	_, sig := sign(t, PersonalHash([]byte("hello")))
+	tests := []struct {
+		v, want byte
+		err     bool
+	}{
+		{0, 0, false},
+		{1, 1, false},
+		{27, 0, false},
+		{28, 1, false},
+		{2, 0, true},
+		{26, 0, true},
+		{29, 0, true},
+	}
+	for _, tt := range tests {
+		out, err := NormalizeV(withV(sig, tt.v))
+		if tt.err {
+			if err == nil {
+				t.Errorf("v=%d: want error", tt.v)
+			}
+			continue
+		}
+		if err != nil || out[64] != tt.want {
+			t.Errorf("v=%d: got %v, %v, want v=%d", tt.v, out, err, tt.want)
+		}
+	}
+	if _, err := NormalizeV(sig[:64]); !errors.Is(err, ErrLength) {
+		t.Errorf("64 bytes: err = %v, want ErrLength", err)
+	}
+}
+
+func TestVerifyECDSA(t *testing.T) {
+	hash := PersonalHash([]byte("hello"))
+	signer, sig := sign(t, hash)
+	other := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	v := sig[64]
+
+	tests := []struct {
+		name    string
+		address common.Address
+		sig     []byte
+		valid   bool
+		highS   bool
+		reason  error
+	}{
+		{"v=0/1", signer, sig, true, false, nil},
+		{"v=27/28", signer, withV(sig, v+27), true, false, nil},
+		{"wrong v", signer, withV(sig, v^1), false, false, ErrMismatch},
+		{"other address", other, sig, false, false, ErrMismatch},
+		{"high s", signer, highS(sig), false, true, ErrHighS},
+		{"high s with v=27/28", signer, withV(highS(sig), (v^1)+27), false, true, ErrHighS},
+		{"bad v", signer, withV(sig, 5), false, false, ErrMismatch},
+		{"short", signer, sig[:64], false, false, ErrMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Verify(context.Background(), nil, tt.address, hash, tt.sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Valid != tt.valid || result.HighS != tt.highS {
+				t.Fatalf("result = %+v, want valid=%v highS=%v", result, tt.valid, tt.highS)
+			}
+			if tt.valid && result.Method != MethodECDSA {
+				t.Fatalf("method = %q, want %q", result.Method, MethodECDSA)
+			}
+			if tt.reason != nil && result.Reason != tt.reason.Error() {
+				t.Fatalf("reason = %q, want %q", result.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+// 高 s 签名经过 LowS 转换后恢复出同一地址并通过校验，v 的表示方式保持不变
+func TestLowS(t *testing.T) {
+	hash := PersonalHash([]byte("hello"))
+	signer, sig := sign(t, hash)
+	for _, offset := range []byte{0, 27} {
+		high := withV(highS(sig), highS(sig)[64]+offset)
+		if !IsHighS(high) {
+			t.Fatal("constructed signature is not high-s")
+		}
+		low, err := LowS(high)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := withV(sig, sig[64]+offset); common.Bytes2Hex(low) != common.Bytes2Hex(want) {
+			t.Fatalf("LowS(v offset %d) = %x, want %x", offset, low, want)
+		}
+		result, err := Verify(context.Background(), nil, signer, hash, low)
+		if err != nil || !result.Valid {
+			t.Fatalf("low-s signature: %+v, %v", result, err)
+		}
+		// 已经是低 s 的签名原样返回
+		again, err := LowS(low)
+		if err != nil || common.Bytes2Hex(again) != common.Bytes2Hex(low) {
+			t.Fatalf("LowS(low) = %x, %v", again, err)
+		}
+	}
+}
+
+// revertError 模拟节点返回的 execution reverted，带 ErrorData
+type revertError struct{}
+
+func (revertError) Error() string          { return "execution reverted" }
+func (revertError) ErrorData() interface{} { return "0x" }
+
+// fakeWallet 合约钱包替身：code 为空表示地址没有代码，callErr 是 isValidSignature 调用的错误
+type fakeWallet struct {
+	code    []byte
+	out     []byte
+	callErr error
+	called  bool
+}
+
+func (w *fakeWallet) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return w.code, nil
+}
+
+func (w *fakeWallet) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	w.called = true
+	if method, err := isValidSignatureABI.MethodById(msg.Data); err != nil || method.Name != "isValidSignature" {
+		return nil, errors.New("unexpected call")
+	}
+	return w.out, w.callErr
+}
+
+func TestVerifyEIP1271(t *testing.T) {
+	hash := PersonalHash([]byte("hello"))
+	_, sig := sign(t, hash)
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000c0de1")
+	code := []byte{0x60, 0x00}
+	magic := common.RightPadBytes(Magic1271[:], 32)
+	nodeDown := errors.New("connection refused")
+
+	tests := []struct {
+		name   string
+		wallet *fakeWallet
+		valid  bool
+		err    error
+	}{
+		{"magic value", &fakeWallet{code: code, out: magic}, true, nil},
+		{"magic value in a short return", &fakeWallet{code: code, out: Magic1271[:]}, true, nil},
+		{"wrong value", &fakeWallet{code: code, out: common.RightPadBytes([]byte{0xff, 0xff, 0xff, 0xff}, 32)}, false, nil},
+		{"empty return", &fakeWallet{code: code}, false, nil},
+		{"revert", &fakeWallet{code: code, callErr: revertError{}}, false, nil},
+		{"no code", &fakeWallet{out: magic}, false, nil},
+		{"node error", &fakeWallet{code: code, callErr: nodeDown}, false, nodeDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Verify(context.Background(), tt.wallet, wallet, hash, sig)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Valid != tt.valid {
+				t.Fatalf("result = %+v, want valid=%v", result, tt.valid)
+			}
+			if tt.valid && result.Method != MethodEIP1271 {
+				t.Fatalf("method = %q, want %q", result.Method, MethodEIP1271)
+			}
+			if !tt.valid && result.Reason != ErrMismatch.Error() {
+				t.Fatalf("reason = %q, want %q", result.Reason, ErrMismatch)
+			}
+			if len(tt.wallet.code) == 0 && tt.wallet.called {
+				t.Fatal("isValidSignature called on an address without code")
+			}
+		})
+	}
+}
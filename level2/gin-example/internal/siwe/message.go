@@ -0,0 +1,248 @@
+package siwe
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// EIP-4361 消息格式：
+//
+//	${scheme}://${domain} wants you to sign in with your Ethereum account:
+//	${address}
+//
+//	${statement}
+//
+//	URI: ${uri}
+//	Version: ${version}
+//	Chain ID: ${chain-id}
+//	Nonce: ${nonce}
+//	Issued At: ${issued-at}
+//	Expiration Time: ${expiration-time}
+//	Not Before: ${not-before}
+//	Request ID: ${request-id}
+//	Resources:
+//	- ${resources[0]}
+//
+// scheme、statement 以及 Expiration Time 之后的字段都是可选的
+const headerSuffix = " wants you to sign in with your Ethereum account:"
+
+// ErrInvalidMessage 消息格式不符合 EIP-4361
+var ErrInvalidMessage = errors.New("invalid siwe message")
+
+// nonceRe 至少 8 位字母数字
+var nonceRe = regexp.MustCompile(`^[A-Za-z0-9]{8,}$`)
+
+// Message 解析后的 EIP-4361 消息
+type Message struct {
+	Scheme         string         `json:"scheme,omitempty"`
+	Domain         string         `json:"domain"`
+	Address        common.Address `json:"address"`
+	Statement      string         `json:"statement,omitempty"`
+	URI            string         `json:"uri"`
+	Version        string         `json:"version"`
+	ChainID        uint64         `json:"chainId"`
+	Nonce          string         `json:"nonce"`
+	IssuedAt       time.Time      `json:"issuedAt"`
+	ExpirationTime *time.Time     `json:"expirationTime,omitempty"`
+	NotBefore      *time.Time     `json:"notBefore,omitempty"`
+	RequestID      string         `json:"requestId,omitempty"`
+	Resources      []string       `json:"resources,omitempty"`
+}
+
+// Parse 按 EIP-4361 解析消息，字段顺序、地址校验和、时间格式不对都会返回 ErrInvalidMessage
+func Parse(text string) (*Message, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	p := &parser{lines: lines}
+	m := &Message{}
+
+	header, ok := strings.CutSuffix(p.next(), headerSuffix)
+	if !ok {
+		return nil, p.fail("first line must end with %q", headerSuffix)
+	}
+	if scheme, domain, ok := strings.Cut(header, "://"); ok {
+		m.Scheme, header = scheme, domain
+	}
+	if header == "" || strings.ContainsAny(header, " /") {
+		return nil, p.fail("invalid domain %q", header)
+	}
+	m.Domain = header
+
+	addr := p.next()
+	if !common.IsHexAddress(addr) || !strings.HasPrefix(addr, "0x") {
+		return nil, p.fail("invalid address %q", addr)
+	}
+	m.Address = common.HexToAddress(addr)
+	// EIP-4361 要求地址使用 EIP-55 校验和格式
+	if m.Address.Hex() != addr {
+		return nil, p.fail("address %s is not EIP-55 checksummed", addr)
+	}
+
+	if p.next() != "" {
+		return nil, p.fail("expected empty line after address")
+	}
+	// 可选的 statement 后面跟一个空行；没有 statement 时也有一个空行
+	if line := p.peek(); !strings.HasPrefix(line, "URI: ") {
+		if line != "" {
+			m.Statement = p.next()
+		}
+		if p.next() != "" {
+			return nil, p.fail("expected empty line after statement")
+		}
+	}
+
+	var err error
+	if m.URI, err = p.field("URI", true); err != nil {
+		return nil, err
+	}
+	if _, err := url.Parse(m.URI); err != nil || m.URI == "" {
+		return nil, p.fail("invalid uri %q", m.URI)
+	}
+	if m.Version, err = p.field("Version", true); err != nil {
+		return nil, err
+	}
+	if m.Version != "1" {
+		return nil, p.fail("unsupported version %q", m.Version)
+	}
+	chainID, err := p.field("Chain ID", true)
+	if err != nil {
+		return nil, err
+	}
+	if m.ChainID, err = strconv.ParseUint(chainID, 10, 64); err != nil {
+		return nil, p.fail("invalid chain id %q", chainID)
+	}
+	if m.Nonce, err = p.field("Nonce", true); err != nil {
+		return nil, err
+	}
+	if !nonceRe.MatchString(m.Nonce) {
+		return nil, p.fail("nonce must be at least 8 alphanumeric characters")
+	}
+	issuedAt, err := p.field("Issued At", true)
+	if err != nil {
+		return nil, err
+	}
+	if m.IssuedAt, err = time.Parse(time.RFC3339, issuedAt); err != nil {
+		return nil, p.fail("invalid issued-at %q", issuedAt)
+	}
+	if m.ExpirationTime, err = p.timeField("Expiration Time"); err != nil {
+		return nil, err
+	}
+	if m.NotBefore, err = p.timeField("Not Before"); err != nil {
+		return nil, err
+	}
+	if m.RequestID, err = p.field("Request ID", false); err != nil {
+		return nil, err
+	}
+	if p.peek() == "Resources:" {
+		p.next()
+		for strings.HasPrefix(p.peek(), "- ") {
+			m.Resources = append(m.Resources, strings.TrimPrefix(p.next(), "- "))
+		}
+	}
+	// 允许末尾有一个换行
+	for p.pos < len(p.lines) {
+		if p.next() != "" {
+			return nil, p.fail("unexpected content")
+		}
+	}
+	return m, nil
+}
+
+// VerifyOptions 服务端期望的消息内容
+type VerifyOptions struct {
+	Domains []string // 允许的 domain，消息的 domain 必须是其中之一
+	ChainID uint64
+	Now     time.Time
+	Leeway  time.Duration // 允许的时钟误差
+}
+
+// Validate 检查 domain、chain id 和时间窗口；nonce 由调用方对照服务端保存的 nonce 检查
+func (m *Message) Validate(opts VerifyOptions) error {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if !m.domainAllowed(opts.Domains) {
+		return fmt.Errorf("domain mismatch: message is for %q, expected one of %q", m.Domain, opts.Domains)
+	}
+	if opts.ChainID != 0 && m.ChainID != opts.ChainID {
+		return fmt.Errorf("chain id mismatch: message is for %d, expected %d", m.ChainID, opts.ChainID)
+	}
+	if m.IssuedAt.After(now.Add(opts.Leeway)) {
+		return errors.New("message issued in the future")
+	}
+	if m.ExpirationTime != nil && !now.Before(m.ExpirationTime.Add(opts.Leeway)) {
+		return errors.New("message expired")
+	}
+	if m.NotBefore != nil && now.Add(opts.Leeway).Before(*m.NotBefore) {
+		return errors.New("message not valid yet")
+	}
+	return nil
+}
+
+// parser 按行读取消息
+type parser struct {
+	lines []string
+	pos   int
+}
+
+func (p *parser) next() string {
+	if p.pos >= len(p.lines) {
+		p.pos++
+		return ""
+	}
+	line := p.lines[p.pos]
+	p.pos++
+	return line
+}
+
+func (p *parser) peek() string {
+	if p.pos >= len(p.lines) {
+		return ""
+	}
+	return p.lines[p.pos]
+}
+
+// field 读取 "Name: value" 行；可选字段不存在时返回空字符串
+func (p *parser) field(name string, required bool) (string, error) {
+	value, ok := strings.CutPrefix(p.peek(), name+": ")
+	if !ok {
+		if required {
+			return "", p.fail("missing %s", name)
+		}
+		return "", nil
+	}
+	p.next()
+	return value, nil
+}
+
+func (p *parser) timeField(name string) (*time.Time, error) {
+	value, err := p.field(name, false)
+	if err != nil || value == "" {
+		return nil, err
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, p.fail("invalid %s %q", strings.ToLower(name), value)
+	}
+	return &t, nil
+}
+
+func (p *parser) fail(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: line %d: %s", ErrInvalidMessage, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (m *Message) domainAllowed(domains []string) bool {
+	for _, d := range domains {
+		if strings.EqualFold(m.Domain, d) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package siwe
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"level2/gin-example/internal/signature"
+)
+
+const testMessage = `example.com wants you to sign in with your Ethereum account:
+0x5B38Da6a701c568545dCfcB03FcB875f56beddC4
+
+Sign in to the example app.
+
+URI: https://example.com/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756abcd
+Issued At: 2024-01-01T00:00:00Z
+Expiration Time: 2024-01-01T00:10:00Z
+Not Before: 2024-01-01T00:00:00Z
+Request ID: req-1
+Resources:
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/
+- https://example.com/my-web2-claim.json
+`
+
+func TestParse(t *testing.T) {
+	m, err := Parse(testMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Domain != "example.com" || m.Address != common.HexToAddress("0x5B38Da6a701c568545dCfcB03FcB875f56beddC4") ||
+		m.Statement != "Sign in to the example app." || m.URI != "https://example.com/login" || m.ChainID != 1 ||
+		m.Nonce != "32891756abcd" || m.RequestID != "req-1" || len(m.Resources) != 2 {
+		t.Fatalf("message = %+v", m)
+	}
+	if m.ExpirationTime == nil || !m.ExpirationTime.Equal(time.Date(2024, 1, 1, 0, 10, 0, 0, time.UTC)) {
+		t.Fatalf("expiration = %v", m.ExpirationTime)
+	}
+
+	// 没有 statement 和可选字段
+	minimal := strings.Join([]string{
+		"https://example.com wants you to sign in with your Ethereum account:",
+		"0x5B38Da6a701c568545dCfcB03FcB875f56beddC4",
+		"",
+		"URI: https://example.com",
+		"Version: 1",
+		"Chain ID: 11155111",
+		"Nonce: abcdefgh",
+		"Issued At: 2024-01-01T00:00:00Z",
+	}, "\n")
+	if m, err = Parse(minimal); err != nil {
+		t.Fatal(err)
+	}
+	if m.Scheme != "https" || m.Statement != "" || m.ExpirationTime != nil {
+		t.Fatalf("minimal message = %+v", m)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := map[string]string{
+		"header":      strings.Replace(testMessage, "wants you to sign in", "wants you to log in", 1),
+		"checksum":    strings.Replace(testMessage, "0x5B38Da6a701c568545dCfcB03FcB875f56beddC4", "0x5b38da6a701c568545dcfcb03fcb875f56beddc4", 1),
+		"statement":   strings.Replace(testMessage, "Sign in to the example app.\n", "Sign in\nto the example app.\n", 1),
+		"version":     strings.Replace(testMessage, "Version: 1", "Version: 2", 1),
+		"nonce":       strings.Replace(testMessage, "Nonce: 32891756abcd", "Nonce: short", 1),
+		"issued at":   strings.Replace(testMessage, "Issued At: 2024-01-01T00:00:00Z", "Issued At: yesterday", 1),
+		"field order": strings.Replace(testMessage, "Version: 1\nChain ID: 1", "Chain ID: 1\nVersion: 1", 1),
+		"trailing":    testMessage + "extra\n",
+	}
+	for name, text := range tests {
+		if _, err := Parse(text); !errors.Is(err, ErrInvalidMessage) {
+			t.Errorf("%s: Parse = %v, want ErrInvalidMessage", name, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	m, err := Parse(testMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued := time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		opts VerifyOptions
+		want string // 错误信息包含的内容，空表示通过
+	}{
+		{"ok", VerifyOptions{Domains: []string{"app.example.com", "Example.com"}, ChainID: 1, Now: issued}, ""},
+		{"domain mismatch", VerifyOptions{Domains: []string{"evil.example"}, Now: issued}, "domain mismatch"},
+		{"no domains", VerifyOptions{Now: issued}, "domain mismatch"},
+		{"chain mismatch", VerifyOptions{Domains: []string{"example.com"}, ChainID: 5, Now: issued}, "chain id mismatch"},
+		{"expired", VerifyOptions{Domains: []string{"example.com"}, Now: issued.Add(5 * time.Minute)}, "expired"},
+		{"expired within leeway", VerifyOptions{Domains: []string{"example.com"}, Now: issued.Add(5 * time.Minute), Leeway: time.Minute}, ""},
+		{"issued in the future", VerifyOptions{Domains: []string{"example.com"}, Now: issued.Add(-10 * time.Minute)}, "issued in the future"},
+	}
+	for _, tt := range tests {
+		err := m.Validate(tt.opts)
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%s: Validate = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+// contractWallet 模拟 EIP-1271 合约钱包：owner 对摘要的 ECDSA 签名有效
+type contractWallet struct {
+	owner common.Address
+}
+
+var walletABI, _ = abi.JSON(strings.NewReader(`[{"inputs":[{"name":"hash","type":"bytes32"},{"name":"signature","type":"bytes"}],"name":"isValidSignature","outputs":[{"type":"bytes4"}],"stateMutability":"view","type":"function"}]`))
+
+func (w *contractWallet) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x60, 0x00}, nil
+}
+
+func (w *contractWallet) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	args, err := walletABI.Methods["isValidSignature"].Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	hash, sig := args[0].([32]byte), args[1].([]byte)
+	out := make([]byte, 32)
+	if signer, err := signature.Recover(hash, sig); err == nil && signer == w.owner {
+		copy(out, signature.Magic1271[:])
+	}
+	return out, nil
+}
+
+// 合约钱包登录：消息里的地址是合约，签名由它的 owner 签，通过 isValidSignature 认可
+func TestEIP1271Login(t *testing.T) {
+	ownerKey, _ := crypto.GenerateKey()
+	wallet := common.HexToAddress("0x00000000000000000000000000000000000c0de1")
+	text := strings.Replace(testMessage, "0x5B38Da6a701c568545dCfcB03FcB875f56beddC4", wallet.Hex(), 1)
+	m, err := Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Address != wallet {
+		t.Fatalf("address = %s", m.Address.Hex())
+	}
+	hash := signature.PersonalHash([]byte(text))
+	sig, err := crypto.Sign(hash.Bytes(), ownerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caller := &contractWallet{owner: crypto.PubkeyToAddress(ownerKey.PublicKey)}
+	result, err := signature.Verify(context.Background(), caller, m.Address, hash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Method != signature.MethodEIP1271 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	// 别人签的不行
+	otherKey, _ := crypto.GenerateKey()
+	sig, _ = crypto.Sign(hash.Bytes(), otherKey)
+	if result, err = signature.Verify(context.Background(), caller, m.Address, hash, sig); err != nil || result.Valid {
+		t.Fatalf("foreign signature: %+v, %v", result, err)
+	}
+}
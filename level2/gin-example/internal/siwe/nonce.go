@@ -0,0 +1,131 @@
+package siwe
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	// NonceTTL nonce 签发后多久内必须用掉
+	NonceTTL = 10 * time.Minute
+	// maxNonces 最多同时保存的未使用 nonce，防止被刷接口撑爆内存
+	maxNonces = 10000
+	// MaxNoncesPerClient 同一个客户端（IP）最多同时持有的未使用 nonce。没有这个限制时，
+	// 一个客户端就能占满 maxNonces，让所有人在 NonceTTL 内都拿不到 nonce
+	MaxNoncesPerClient = 10
+	nonceLen           = 17
+)
+
+const nonceAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+var (
+	// ErrUnknownNonce nonce 不是本服务签发的，或者已经用过
+	ErrUnknownNonce = errors.New("unknown or already used nonce")
+	// ErrNonceExpired nonce 超过了 NonceTTL
+	ErrNonceExpired = errors.New("nonce expired")
+	// ErrTooManyNonces 未使用的 nonce 太多
+	ErrTooManyNonces = errors.New("too many pending nonces")
+	// ErrClientLimit 这个客户端未使用的 nonce 达到了 MaxNoncesPerClient
+	ErrClientLimit = errors.New("too many pending nonces for this client")
+)
+
+// NonceStore 服务端保存签发过的 nonce，每个只能消费一次，防止登录消息被重放
+type NonceStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	items   map[string]pendingNonce
+	clients map[string]int // 客户端 -> 未使用的 nonce 数
+}
+
+type pendingNonce struct {
+	expires time.Time
+	client  string
+}
+
+// NewNonceStore ttl 为 0 时使用 NonceTTL
+func NewNonceStore(ttl time.Duration) *NonceStore {
+	if ttl <= 0 {
+		ttl = NonceTTL
+	}
+	return &NonceStore{ttl: ttl, items: make(map[string]pendingNonce), clients: make(map[string]int)}
+}
+
+// Issue 为客户端（一般是 IP）生成并保存一个新的 nonce；客户端用掉或等旧的过期后才能再领
+func (s *NonceStore) Issue(client string) (string, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if s.clients[client] >= MaxNoncesPerClient {
+		s.prune(now)
+		if s.clients[client] >= MaxNoncesPerClient {
+			return "", time.Time{}, ErrClientLimit
+		}
+	}
+	if len(s.items) >= maxNonces {
+		s.prune(now)
+		if len(s.items) >= maxNonces {
+			return "", time.Time{}, ErrTooManyNonces
+		}
+	}
+	nonce, err := randomNonce()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires := now.Add(s.ttl)
+	s.items[nonce] = pendingNonce{expires: expires, client: client}
+	s.clients[client]++
+	return nonce, expires, nil
+}
+
+// Consume 校验并删除 nonce；无论成功与否，同一个 nonce 都不能再用
+func (s *NonceStore) Consume(nonce string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[nonce]
+	if !ok {
+		return ErrUnknownNonce
+	}
+	s.remove(nonce, item)
+	if time.Now().After(item.expires) {
+		return ErrNonceExpired
+	}
+	return nil
+}
+
+// Len 未使用的 nonce 数量
+func (s *NonceStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+func (s *NonceStore) prune(now time.Time) {
+	for nonce, item := range s.items {
+		if now.After(item.expires) {
+			s.remove(nonce, item)
+		}
+	}
+}
+
+func (s *NonceStore) remove(nonce string, item pendingNonce) {
+	delete(s.items, nonce)
+	if s.clients[item.client]--; s.clients[item.client] <= 0 {
+		delete(s.clients, item.client)
+	}
+}
+
+func randomNonce() (string, error) {
+	max := big.NewInt(int64(len(nonceAlphabet)))
+	b := make([]byte, nonceLen)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = nonceAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
@@ -0,0 +1,90 @@
+package siwe
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNonceReuse(t *testing.T) {
+	s := NewNonceStore(0)
+	nonce, _, err := s.Issue("1.2.3.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nonceRe.MatchString(nonce) {
+		t.Fatalf("nonce %q does not fit the siwe grammar", nonce)
+	}
+	if err := s.Consume(nonce); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Consume(nonce); !errors.Is(err, ErrUnknownNonce) {
+		t.Fatalf("reused nonce: %v, want ErrUnknownNonce", err)
+	}
+	if err := s.Consume("neverissued"); !errors.Is(err, ErrUnknownNonce) {
+		t.Fatalf("unknown nonce: %v, want ErrUnknownNonce", err)
+	}
+}
+
+func TestNonceExpired(t *testing.T) {
+	s := NewNonceStore(time.Millisecond)
+	nonce, _, err := s.Issue("1.2.3.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := s.Consume(nonce); !errors.Is(err, ErrNonceExpired) {
+		t.Fatalf("expired nonce: %v, want ErrNonceExpired", err)
+	}
+	// 过期的 nonce 也只能用一次
+	if err := s.Consume(nonce); !errors.Is(err, ErrUnknownNonce) {
+		t.Fatalf("expired nonce reused: %v, want ErrUnknownNonce", err)
+	}
+}
+
+// 一个客户端领满之后只影响它自己，用掉一个后又能再领
+func TestNonceClientLimit(t *testing.T) {
+	s := NewNonceStore(0)
+	var first string
+	for i := 0; i < MaxNoncesPerClient; i++ {
+		nonce, _, err := s.Issue("1.2.3.4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			first = nonce
+		}
+	}
+	if _, _, err := s.Issue("1.2.3.4"); !errors.Is(err, ErrClientLimit) {
+		t.Fatalf("over the limit: %v, want ErrClientLimit", err)
+	}
+	if _, _, err := s.Issue("5.6.7.8"); err != nil {
+		t.Fatalf("other client: %v", err)
+	}
+	if err := s.Consume(first); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Issue("1.2.3.4"); err != nil {
+		t.Fatalf("after consuming one: %v", err)
+	}
+	if s.Len() != MaxNoncesPerClient+1 {
+		t.Fatalf("pending = %d, want %d", s.Len(), MaxNoncesPerClient+1)
+	}
+}
+
+// 过期的 nonce 不再占用客户端的名额
+func TestNonceClientLimitExpiry(t *testing.T) {
+	s := NewNonceStore(time.Millisecond)
+	for i := 0; i < MaxNoncesPerClient; i++ {
+		if _, _, err := s.Issue("1.2.3.4"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, _, err := s.Issue("1.2.3.4"); err != nil {
+		t.Fatalf("after expiry: %v", err)
+	}
+	if s.Len() != 1 {
+		t.Fatalf("pending = %d, want 1", s.Len())
+	}
+}
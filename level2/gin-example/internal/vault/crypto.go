@@ -0,0 +1,146 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"golang.org/x/crypto/scrypt"
+)
+
+// 加密参数与 keystore V3 相同的 scrypt（N=2^18, r=8, p=1），密文改用 AES-256-GCM，不再需要单独的 MAC
+const (
+	kdfName     = "scrypt"
+	cipherName  = "aes-256-gcm"
+	scryptR     = 8
+	scryptDKLen = 32
+	saltBytes   = 32
+)
+
+// ErrWrongPassword 密码错误，或者文件被改过（GCM 认证失败两者无法区分）
+var ErrWrongPassword = errors.New("could not decrypt mnemonic: wrong password or corrupted entry")
+
+// cryptoJSON 加密后的内容，字段命名沿用 keystore V3 的 crypto 段
+type cryptoJSON struct {
+	Cipher       string `json:"cipher"`
+	CipherText   string `json:"ciphertext"`
+	CipherParams struct {
+		Nonce string `json:"nonce"`
+	} `json:"cipherparams"`
+	KDF       string    `json:"kdf"`
+	KDFParams kdfParams `json:"kdfparams"`
+}
+
+type kdfParams struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+}
+
+// ScryptN、ScryptP scrypt 的成本参数，测试或开发时可以调低（比如 keystore.LightScryptN）
+var (
+	ScryptN = keystore.StandardScryptN
+	ScryptP = keystore.StandardScryptP
+)
+
+// encrypt 每次使用新的 salt 和 nonce；additional 是条目名等需要绑定到密文上的数据，防止把一个条目的密文挪到另一个名字下
+func encrypt(plaintext []byte, password string, additional []byte) (*cryptoJSON, error) {
+	salt := make([]byte, saltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	params := kdfParams{N: ScryptN, R: scryptR, P: ScryptP, DKLen: scryptDKLen, Salt: hex.EncodeToString(salt)}
+	key, err := scrypt.Key([]byte(password), salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(key)
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	c := &cryptoJSON{
+		Cipher:     cipherName,
+		CipherText: hex.EncodeToString(gcm.Seal(nil, nonce, plaintext, additional)),
+		KDF:        kdfName,
+		KDFParams:  params,
+	}
+	c.CipherParams.Nonce = hex.EncodeToString(nonce)
+	return c, nil
+}
+
+// decrypt 返回的明文由调用方用完后清零
+func decrypt(c *cryptoJSON, password string, additional []byte) ([]byte, error) {
+	if c.Cipher != cipherName || c.KDF != kdfName {
+		return nil, fmt.Errorf("unsupported cipher %q / kdf %q", c.Cipher, c.KDF)
+	}
+	if err := c.KDFParams.check(); err != nil {
+		return nil, err
+	}
+	salt, err := hex.DecodeString(c.KDFParams.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	if len(salt) != saltBytes {
+		return nil, fmt.Errorf("invalid salt length %d", len(salt))
+	}
+	nonce, err := hex.DecodeString(c.CipherParams.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce: %w", err)
+	}
+	ciphertext, err := hex.DecodeString(c.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	p := c.KDFParams
+	key, err := scrypt.Key([]byte(password), salt, p.N, p.R, p.P, p.DKLen)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(key)
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce length %d", len(nonce))
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, additional)
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return plaintext, nil
+}
+
+// check 只接受 encrypt 会写出的参数：N 是标准值、轻量值或当前的 ScryptN，r、dklen 固定。
+// 文件被改过时，过大的 N/r/p 会让解锁耗尽内存或 CPU，过小的则降低了暴力破解的成本
+func (p kdfParams) check() error {
+	if p.N != keystore.StandardScryptN && p.N != keystore.LightScryptN && p.N != ScryptN {
+		return fmt.Errorf("unsupported scrypt n %d", p.N)
+	}
+	if p.P != keystore.StandardScryptP && p.P != keystore.LightScryptP && p.P != ScryptP {
+		return fmt.Errorf("unsupported scrypt p %d", p.P)
+	}
+	if p.R != scryptR || p.DKLen != scryptDKLen {
+		return fmt.Errorf("unsupported scrypt r %d / dklen %d", p.R, p.DKLen)
+	}
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
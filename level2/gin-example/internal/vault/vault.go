@@ -0,0 +1,537 @@
+// Package vault 加密保存命名助记词的保险库。助记词（以及可选的 BIP-39 密码短语）用 scrypt + AES-GCM 加密后写入文件，
+// 解锁后只在内存里保留派生出的 HD 钱包，超时自动锁定；HD 钱包相关的接口只通过名字引用助记词
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	hdwallet "github.com/miguelmota/go-ethereum-hdwallet"
+	"level2/gin-example/internal/mnemonic"
+)
+
+// DefaultFile 保险库文件，路径相对于 level2
+const DefaultFile = "./data/mnemonics.vault.json"
+
+// 文件格式版本
+const version = 1
+
+// 解锁时长
+const (
+	DefaultUnlockTimeout = 5 * time.Minute
+	MaxUnlockTimeout     = time.Hour
+)
+
+// MinPasswordLength 保险库密码的最小长度
+const MinPasswordLength = 8
+
+// MaxAccountUses 每个助记词最多保留的派生账户使用记录，超出时丢掉最久没用过的
+const MaxAccountUses = 256
+
+// 审计里记录的操作
+const (
+	ActionDerive = "derive" // 派生地址并返回给调用方
+	ActionSign   = "sign"   // 用派生账户签名
+)
+
+var (
+	// ErrNotFound 没有这个名字的助记词
+	ErrNotFound = errors.New("mnemonic not found in vault")
+	// ErrExists 名字已被使用
+	ErrExists = errors.New("mnemonic name already exists in vault")
+	// ErrLocked 需要先解锁
+	ErrLocked = errors.New("mnemonic is locked")
+	// ErrInvalidName 名字只能是 1-64 个字母、数字、- 和 _
+	ErrInvalidName = errors.New("name must be 1-64 letters, digits, '-' or '_'")
+	// ErrInvalidTimeout 解锁时长超过 MaxUnlockTimeout
+	ErrInvalidTimeout = fmt.Errorf("unlock timeout must not exceed %s", MaxUnlockTimeout)
+	// ErrWeakPassword 密码太短
+	ErrWeakPassword = fmt.Errorf("vault password must be at least %d characters", MinPasswordLength)
+)
+
+var nameRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// secret 加密的明文
+type secret struct {
+	Mnemonic   string `json:"mnemonic"`
+	Passphrase string `json:"passphrase,omitempty"` // BIP-39 密码短语
+}
+
+// AccountUse 一个派生账户的使用记录
+type AccountUse struct {
+	Path        string         `json:"path"`
+	Address     common.Address `json:"address"`
+	FirstUsedAt time.Time      `json:"firstUsedAt"`
+	LastUsedAt  time.Time      `json:"lastUsedAt"`
+	LastAction  string         `json:"lastAction"`
+	Actions     map[string]int `json:"actions"` // 操作 -> 次数
+}
+
+// entry 文件里的一条助记词
+type entry struct {
+	Name          string        `json:"name"`
+	Words         int           `json:"words"`
+	HasPassphrase bool          `json:"hasPassphrase"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	RotatedAt     *time.Time    `json:"rotatedAt,omitempty"`
+	Crypto        *cryptoJSON   `json:"crypto"`
+	Accounts      []*AccountUse `json:"accounts,omitempty"`
+}
+
+// fileJSON 保险库文件
+type fileJSON struct {
+	Version int      `json:"version"`
+	Entries []*entry `json:"entries"`
+}
+
+// session 解锁后的状态，只保存钱包，不保存助记词原文
+type session struct {
+	wallet  *hdwallet.Wallet
+	expires time.Time
+	timer   *time.Timer
+}
+
+// View 对外展示的条目信息
+type View struct {
+	Name          string        `json:"name"`
+	Words         int           `json:"words"`
+	HasPassphrase bool          `json:"hasPassphrase"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	RotatedAt     *time.Time    `json:"rotatedAt,omitempty"`
+	Unlocked      bool          `json:"unlocked"`
+	ExpiresAt     *time.Time    `json:"expiresAt,omitempty"`
+	Accounts      []*AccountUse `json:"accounts,omitempty"`
+}
+
+// Vault 保险库
+type Vault struct {
+	mu       sync.Mutex
+	fileMu   sync.Mutex // 串行化文件写入
+	path     string
+	entries  map[string]*entry
+	sessions map[string]*session
+}
+
+// Open 读取保险库文件，文件不存在时从空开始；path 为空时只保存在内存里
+func Open(path string) (*Vault, error) {
+	v := &Vault{path: path, entries: make(map[string]*entry), sessions: make(map[string]*session)}
+	if path == "" {
+		return v, nil
+	}
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f fileJSON
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if f.Version != version {
+		return nil, fmt.Errorf("%s: unsupported vault version %d", path, f.Version)
+	}
+	for _, e := range f.Entries {
+		v.entries[e.Name] = e
+	}
+	return v, nil
+}
+
+// additionalData 绑定到密文上的条目名
+func additionalData(name string) []byte {
+	return []byte(fmt.Sprintf("level2-mnemonic-vault:v%d:%s", version, name))
+}
+
+// Generate 生成新的助记词并加密保存，明文只在返回值里出现这一次，调用方负责让用户离线备份
+func (v *Vault) Generate(name, password string, words int) (string, View, error) {
+	generated, err := mnemonic.Generate(words, nil)
+	if err != nil {
+		return "", View{}, err
+	}
+	view, err := v.Import(name, password, generated.Mnemonic, "")
+	if err != nil {
+		return "", View{}, err
+	}
+	return generated.Mnemonic, view, nil
+}
+
+// Import 校验并加密保存已有的助记词，passphrase 为可选的 BIP-39 密码短语
+func (v *Vault) Import(name, password, words, passphrase string) (View, error) {
+	if !nameRe.MatchString(name) {
+		return View{}, ErrInvalidName
+	}
+	if len(password) < MinPasswordLength {
+		return View{}, ErrWeakPassword
+	}
+	if err := mnemonic.Validate(words); err != nil {
+		return View{}, err
+	}
+	normalized := mnemonic.Normalize(words)
+	plaintext, err := json.Marshal(secret{Mnemonic: normalized, Passphrase: passphrase})
+	if err != nil {
+		return View{}, err
+	}
+	defer clear(plaintext)
+	c, err := encrypt(plaintext, password, additionalData(name))
+	if err != nil {
+		return View{}, err
+	}
+	e := &entry{
+		Name:          name,
+		Words:         len(strings.Fields(normalized)),
+		HasPassphrase: passphrase != "",
+		CreatedAt:     time.Now(),
+		Crypto:        c,
+	}
+	v.mu.Lock()
+	if _, ok := v.entries[name]; ok {
+		v.mu.Unlock()
+		return View{}, ErrExists
+	}
+	v.entries[name] = e
+	view := v.view(e)
+	v.mu.Unlock()
+	return view, v.save()
+}
+
+// Unlock 解密并在内存里保留 HD 钱包，timeout 后自动锁定；已经解锁时重新计时
+func (v *Vault) Unlock(name, password string, timeout time.Duration) (View, error) {
+	if timeout <= 0 {
+		timeout = DefaultUnlockTimeout
+	}
+	if timeout > MaxUnlockTimeout {
+		return View{}, ErrInvalidTimeout
+	}
+	v.mu.Lock()
+	e, ok := v.entries[name]
+	v.mu.Unlock()
+	if !ok {
+		return View{}, ErrNotFound
+	}
+	// scrypt 很慢，不持有锁
+	s, err := open(e, password)
+	if err != nil {
+		return View{}, err
+	}
+	wallet, err := mnemonic.Wallet(s.Mnemonic, s.Passphrase)
+	if err != nil {
+		return View{}, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if cur, ok := v.entries[name]; !ok || cur != e {
+		// 解密期间被删除或轮换过密码
+		return View{}, ErrNotFound
+	}
+	if old, ok := v.sessions[name]; ok {
+		old.timer.Stop()
+	}
+	sess := &session{wallet: wallet, expires: time.Now().Add(timeout)}
+	sess.timer = time.AfterFunc(timeout, func() { v.expire(name, sess) })
+	v.sessions[name] = sess
+	return v.view(e), nil
+}
+
+// expire 计时器到期时锁定，期间重新解锁过的会话不受影响
+func (v *Vault) expire(name string, sess *session) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.sessions[name] == sess {
+		delete(v.sessions, name)
+	}
+}
+
+// Lock 立即锁定，本来就是锁定状态时不报错
+func (v *Vault) Lock(name string) (View, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	e, ok := v.entries[name]
+	if !ok {
+		return View{}, ErrNotFound
+	}
+	v.lockLocked(name)
+	return v.view(e), nil
+}
+
+// LockAll 锁定所有条目，服务退出时调用
+func (v *Vault) LockAll() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for name := range v.sessions {
+		v.lockLocked(name)
+	}
+}
+
+// lockLocked 调用方持有 v.mu
+func (v *Vault) lockLocked(name string) {
+	if s, ok := v.sessions[name]; ok {
+		s.timer.Stop()
+		delete(v.sessions, name)
+	}
+}
+
+// Rotate 用旧密码解密、新密码重新加密（新的 salt 和 nonce），完成后条目被锁定
+func (v *Vault) Rotate(name, oldPassword, newPassword string) (View, error) {
+	if len(newPassword) < MinPasswordLength {
+		return View{}, ErrWeakPassword
+	}
+	v.mu.Lock()
+	e, ok := v.entries[name]
+	v.mu.Unlock()
+	if !ok {
+		return View{}, ErrNotFound
+	}
+	plaintext, err := decrypt(e.Crypto, oldPassword, additionalData(name))
+	if err != nil {
+		return View{}, err
+	}
+	defer clear(plaintext)
+	c, err := encrypt(plaintext, newPassword, additionalData(name))
+	if err != nil {
+		return View{}, err
+	}
+
+	v.mu.Lock()
+	if cur, ok := v.entries[name]; !ok || cur != e {
+		v.mu.Unlock()
+		return View{}, ErrNotFound
+	}
+	now := time.Now()
+	rotated := *e
+	rotated.Crypto, rotated.RotatedAt = c, &now
+	v.entries[name] = &rotated
+	v.lockLocked(name)
+	view := v.view(&rotated)
+	v.mu.Unlock()
+	return view, v.save()
+}
+
+// Delete 删除条目，需要密码确认；审计记录随之删除
+func (v *Vault) Delete(name, password string) error {
+	v.mu.Lock()
+	e, ok := v.entries[name]
+	v.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+	plaintext, err := decrypt(e.Crypto, password, additionalData(name))
+	if err != nil {
+		return err
+	}
+	clear(plaintext)
+	v.mu.Lock()
+	v.lockLocked(name)
+	delete(v.entries, name)
+	v.mu.Unlock()
+	return v.save()
+}
+
+// Get 条目信息和派生账户的使用记录
+func (v *Vault) Get(name string) (View, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	e, ok := v.entries[name]
+	if !ok {
+		return View{}, ErrNotFound
+	}
+	return v.view(e), nil
+}
+
+// List 所有条目，按名字排序
+func (v *Vault) List() []View {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	views := make([]View, 0, len(v.entries))
+	for _, e := range v.entries {
+		views = append(views, v.view(e))
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].Name < views[j].Name })
+	return views
+}
+
+// Address 只读派生地址，不计入审计；用来在签名前做权限检查
+func (v *Vault) Address(name, path string) (common.Address, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	wallet, err := v.walletLocked(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	account, err := derive(wallet, path, false)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return account.Address, nil
+}
+
+// Derive 派生账户并记入审计
+func (v *Vault) Derive(name, path string) (accounts.Account, error) {
+	v.mu.Lock()
+	wallet, err := v.walletLocked(name)
+	if err != nil {
+		v.mu.Unlock()
+		return accounts.Account{}, err
+	}
+	account, err := derive(wallet, path, false)
+	if err != nil {
+		v.mu.Unlock()
+		return accounts.Account{}, err
+	}
+	v.recordLocked(name, path, account.Address, ActionDerive)
+	v.mu.Unlock()
+	return account, v.save()
+}
+
+// SignTx 用派生账户签名交易并记入审计；chainID 为空时按 Homestead 规则签名
+func (v *Vault) SignTx(name, path string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	v.mu.Lock()
+	wallet, err := v.walletLocked(name)
+	if err != nil {
+		v.mu.Unlock()
+		return nil, err
+	}
+	account, err := derive(wallet, path, true)
+	if err != nil {
+		v.mu.Unlock()
+		return nil, err
+	}
+	signed, err := wallet.SignTx(account, tx, chainID)
+	if err != nil {
+		v.mu.Unlock()
+		return nil, err
+	}
+	v.recordLocked(name, path, account.Address, ActionSign)
+	v.mu.Unlock()
+	return signed, v.save()
+}
+
+// walletLocked 调用方持有 v.mu
+func (v *Vault) walletLocked(name string) (*hdwallet.Wallet, error) {
+	if _, ok := v.entries[name]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	s, ok := v.sessions[name]
+	if !ok || time.Now().After(s.expires) {
+		return nil, fmt.Errorf("%w: %s", ErrLocked, name)
+	}
+	return s.wallet, nil
+}
+
+// recordLocked 调用方持有 v.mu；记录数达到 MaxAccountUses 时先删掉最久没用过的一条
+func (v *Vault) recordLocked(name, path string, address common.Address, action string) {
+	e := v.entries[name]
+	now := time.Now()
+	for _, use := range e.Accounts {
+		if use.Path == path {
+			use.LastUsedAt, use.LastAction = now, action
+			use.Actions[action]++
+			return
+		}
+	}
+	if len(e.Accounts) >= MaxAccountUses {
+		oldest := 0
+		for i, use := range e.Accounts {
+			if use.LastUsedAt.Before(e.Accounts[oldest].LastUsedAt) {
+				oldest = i
+			}
+		}
+		e.Accounts = append(e.Accounts[:oldest], e.Accounts[oldest+1:]...)
+	}
+	e.Accounts = append(e.Accounts, &AccountUse{
+		Path:        path,
+		Address:     address,
+		FirstUsedAt: now,
+		LastUsedAt:  now,
+		LastAction:  action,
+		Actions:     map[string]int{action: 1},
+	})
+}
+
+// view 调用方持有 v.mu
+func (v *Vault) view(e *entry) View {
+	view := View{
+		Name:          e.Name,
+		Words:         e.Words,
+		HasPassphrase: e.HasPassphrase,
+		CreatedAt:     e.CreatedAt,
+		RotatedAt:     e.RotatedAt,
+	}
+	if s, ok := v.sessions[e.Name]; ok && time.Now().Before(s.expires) {
+		expires := s.expires
+		view.Unlocked, view.ExpiresAt = true, &expires
+	}
+	for _, use := range e.Accounts {
+		u := *use
+		u.Actions = make(map[string]int, len(use.Actions))
+		for k, n := range use.Actions {
+			u.Actions[k] = n
+		}
+		view.Accounts = append(view.Accounts, &u)
+	}
+	return view
+}
+
+// open 解密条目
+func open(e *entry, password string) (*secret, error) {
+	plaintext, err := decrypt(e.Crypto, password, additionalData(e.Name))
+	if err != nil {
+		return nil, err
+	}
+	defer clear(plaintext)
+	var s secret
+	if err := json.Unmarshal(plaintext, &s); err != nil {
+		return nil, fmt.Errorf("invalid vault entry %s: %w", e.Name, err)
+	}
+	return &s, nil
+}
+
+// derive pin 为 true 时钱包记住这个账户，签名前必须这样派生
+func derive(wallet *hdwallet.Wallet, path string, pin bool) (accounts.Account, error) {
+	parsed, err := hdwallet.ParseDerivationPath(path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	return wallet.Derive(parsed, pin)
+}
+
+// save 写入文件（先写临时文件再改名），权限 0600
+func (v *Vault) save() error {
+	v.fileMu.Lock()
+	defer v.fileMu.Unlock()
+	if v.path == "" {
+		return nil
+	}
+	v.mu.Lock()
+	f := fileJSON{Version: version, Entries: make([]*entry, 0, len(v.entries))}
+	for _, e := range v.entries {
+		f.Entries = append(f.Entries, e)
+	}
+	sort.Slice(f.Entries, func(i, j int) bool { return f.Entries[i].Name < f.Entries[j].Name })
+	raw, err := json.MarshalIndent(f, "", "  ")
+	v.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(v.path), 0o700); err != nil {
+		return err
+	}
+	tmp := v.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, v.path)
+}
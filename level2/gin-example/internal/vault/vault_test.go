@@ -0,0 +1,249 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	testPassword = "correct horse"
+	testPath     = "m/44'/60'/0'/0/0"
+)
+
+// testWords BIP-39 全零熵的助记词，m/44'/60'/0'/0/0 是 testAddress
+var (
+	testWords   = strings.Repeat("abandon ", 11) + "about"
+	testAddress = common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+)
+
+func init() {
+	// 标准参数每次解密要几百毫秒，测试里用轻量参数
+	ScryptN, ScryptP = keystore.LightScryptN, keystore.LightScryptP
+}
+
+func newTestVault(t *testing.T) (*Vault, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vault.json")
+	v, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Import("ops", testPassword, testWords, ""); err != nil {
+		t.Fatal(err)
+	}
+	return v, path
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	plaintext := []byte(`{"mnemonic":"abandon ... about"}`)
+	ad := additionalData("ops")
+	c, err := encrypt(plaintext, testPassword, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decrypt(c, testPassword, ad)
+	if err != nil || string(got) != string(plaintext) {
+		t.Fatalf("decrypt = %q, %v", got, err)
+	}
+	// 每次加密用新的 salt 和 nonce
+	again, err := encrypt(plaintext, testPassword, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.KDFParams.Salt == c.KDFParams.Salt || again.CipherParams.Nonce == c.CipherParams.Nonce || again.CipherText == c.CipherText {
+		t.Fatal("salt, nonce or ciphertext reused")
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		ad       []byte
+	}{
+		{"wrong password", "wrong password", ad},
+		{"moved to another name", testPassword, additionalData("other")},
+	}
+	for _, tt := range tests {
+		if _, err := decrypt(c, tt.password, tt.ad); !errors.Is(err, ErrWrongPassword) {
+			t.Errorf("%s: err = %v, want ErrWrongPassword", tt.name, err)
+		}
+	}
+}
+
+func TestKDFParamsRejected(t *testing.T) {
+	c, err := encrypt([]byte("secret"), testPassword, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		modify func(p *kdfParams)
+	}{
+		{"huge n", func(p *kdfParams) { p.N = 1 << 30 }},
+		{"tiny n", func(p *kdfParams) { p.N = 2 }},
+		{"huge p", func(p *kdfParams) { p.P = 1 << 20 }},
+		{"zero p", func(p *kdfParams) { p.P = 0 }},
+		{"huge r", func(p *kdfParams) { p.R = 1 << 20 }},
+		{"short key", func(p *kdfParams) { p.DKLen = 16 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := *c
+			tt.modify(&tampered.KDFParams)
+			_, err := decrypt(&tampered, testPassword, nil)
+			if err == nil || errors.Is(err, ErrWrongPassword) || !strings.Contains(err.Error(), "unsupported scrypt") {
+				t.Fatalf("err = %v, want unsupported scrypt parameters", err)
+			}
+		})
+	}
+	// 标准参数和轻量参数都接受
+	for _, n := range []int{keystore.StandardScryptN, keystore.LightScryptN} {
+		p := kdfParams{N: n, R: scryptR, P: 1, DKLen: scryptDKLen}
+		if err := p.check(); err != nil {
+			t.Errorf("check(n=%d): %v", n, err)
+		}
+	}
+}
+
+// 文件里的 scrypt 参数被改大后，解锁直接拒绝，不会先跑一遍 scrypt
+func TestUnlockRejectsTamperedParams(t *testing.T) {
+	_, path := newTestVault(t)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(raw), `"n": 4096`, `"n": 1073741824`, 1)
+	if tampered == string(raw) {
+		t.Fatal("scrypt n not found in vault file")
+	}
+	if err := os.WriteFile(path, []byte(tampered), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	v, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Unlock("ops", testPassword, 0); err == nil || !strings.Contains(err.Error(), "unsupported scrypt n") {
+		t.Fatalf("err = %v, want unsupported scrypt n", err)
+	}
+}
+
+func TestImportAndUnlock(t *testing.T) {
+	v, path := newTestVault(t)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("vault file mode = %v, want 0600", info.Mode())
+	}
+	if raw, _ := os.ReadFile(path); strings.Contains(string(raw), "abandon") {
+		t.Fatal("mnemonic stored in plaintext")
+	}
+	if _, err := v.Address("ops", testPath); !errors.Is(err, ErrLocked) {
+		t.Fatalf("locked: err = %v, want ErrLocked", err)
+	}
+
+	// 重新打开文件后用同一个密码解锁
+	v, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Unlock("ops", "wrong password", 0); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("wrong password: err = %v", err)
+	}
+	if _, err := v.Unlock("missing", testPassword, 0); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing entry: err = %v", err)
+	}
+	view, err := v.Unlock("ops", testPassword, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !view.Unlocked || view.Words != 12 || view.HasPassphrase {
+		t.Fatalf("view = %+v", view)
+	}
+	if addr, err := v.Address("ops", testPath); err != nil || addr != testAddress {
+		t.Fatalf("address = %s, %v, want %s", addr, err, testAddress)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	v, path := newTestVault(t)
+	const newPassword = "battery staple"
+	if _, err := v.Unlock("ops", testPassword, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Rotate("ops", "wrong password", newPassword); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("wrong old password: err = %v", err)
+	}
+	if _, err := v.Rotate("ops", testPassword, "short"); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("weak new password: err = %v", err)
+	}
+	view, err := v.Rotate("ops", testPassword, newPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 轮换后条目被锁定
+	if view.Unlocked || view.RotatedAt == nil {
+		t.Fatalf("view = %+v, want locked with rotatedAt", view)
+	}
+
+	v, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Unlock("ops", testPassword, 0); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("old password after rotation: err = %v", err)
+	}
+	if _, err := v.Unlock("ops", newPassword, 0); err != nil {
+		t.Fatalf("new password: %v", err)
+	}
+	if addr, err := v.Address("ops", testPath); err != nil || addr != testAddress {
+		t.Fatalf("address after rotation = %s, %v", addr, err)
+	}
+}
+
+func TestUnlockTimeout(t *testing.T) {
+	v, _ := newTestVault(t)
+	if _, err := v.Unlock("ops", testPassword, MaxUnlockTimeout+time.Second); !errors.Is(err, ErrInvalidTimeout) {
+		t.Fatalf("err = %v, want ErrInvalidTimeout", err)
+	}
+
+	const timeout = 50 * time.Millisecond
+	view, err := v.Unlock("ops", testPassword, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !view.Unlocked || view.ExpiresAt == nil || time.Until(*view.ExpiresAt) > timeout {
+		t.Fatalf("view = %+v, want unlocked for %s", view, timeout)
+	}
+	if _, err := v.Address("ops", testPath); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(2 * timeout)
+	if _, err := v.Address("ops", testPath); !errors.Is(err, ErrLocked) {
+		t.Fatalf("after timeout: err = %v, want ErrLocked", err)
+	}
+	if view, err := v.Get("ops"); err != nil || view.Unlocked {
+		t.Fatalf("after timeout: view = %+v, %v", view, err)
+	}
+	// 计时器到期后会话连同钱包一起删掉
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		v.mu.Lock()
+		_, ok := v.sessions["ops"]
+		v.mu.Unlock()
+		if !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expired session still holds the wallet")
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/inspect"
+)
+
+// InspectAddress 校验 EIP-55 校验和，区分 EOA / 合约，返回余额、nonce、代码长度；
+// 合约地址还会探测 ERC-165 接口、ERC-20/721/1155 函数以及 EIP-1967/EIP-1167 代理
+// GET /addresses/:addr?block=
+func (u *UserHandler) InspectAddress(ctx *gin.Context) {
+	u.inspectAddress(ctx, ctx.Param("addr"))
+}
+
+func (u *UserHandler) inspectAddress(ctx *gin.Context, raw string) {
+	address, checksummed, err := inspect.ParseAddress(raw)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, inspect.ErrBadChecksum) {
+			status = http.StatusUnprocessableEntity
+		}
+		u.writeError(ctx, status, err)
+		return
+	}
+	block, err := parseBigInt(ctx.Query("block"))
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	report, err := inspect.Inspect(ctx.Request.Context(), u.client(ctx), address, block)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	report.Checksummed = checksummed
+	ctx.JSON(http.StatusOK, report)
+}
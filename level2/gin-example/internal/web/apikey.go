@@ -0,0 +1,171 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/apikey"
+	"level2/gin-example/internal/auth"
+	"level2/gin-example/internal/web/middleware"
+)
+
+// APIKeyHandler 管理内部服务使用的 API 密钥，接口需要 admin 权限
+type APIKeyHandler struct {
+	keys  *apikey.Store
+	authz *middleware.Authorizer
+}
+
+func NewAPIKeyHandler(keys *apikey.Store, authz *middleware.Authorizer) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys, authz: authz}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/apikeys", h.authz.Require(auth.PermAdmin))
+	g.POST("", h.Create)
+	g.GET("", h.List)
+	g.GET("/:id", h.Get)
+	g.POST("/:id/rotate", h.Rotate)
+	g.DELETE("/:id", h.Revoke)
+}
+
+// createKeyReq 没有给出的额度用 apikey.DefaultLimits；dailyValue 为 "unlimited" 时不限转出金额
+type createKeyReq struct {
+	Name   string   `json:"name"`
+	Roles  []string `json:"roles"`
+	Limits struct {
+		RatePerSecond *float64 `json:"ratePerSecond"`
+		Burst         *int     `json:"burst"`
+		DailyRPCCalls *int64   `json:"dailyRpcCalls"`
+		DailyValue    *string  `json:"dailyValue"` // wei，十进制或 0x 十六进制
+	} `json:"limits"`
+}
+
+// Create 新建密钥，明文只在这个响应里出现一次
+// POST /apikeys {"name": "indexer", "roles": ["viewer"], "limits": {"ratePerSecond": 5, "dailyRpcCalls": 50000}}
+func (h *APIKeyHandler) Create(ctx *gin.Context) {
+	var req createKeyReq
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	if req.Name == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": "name is required"})
+		return
+	}
+	if len(req.Roles) == 0 {
+		req.Roles = []string{auth.RoleViewer}
+	}
+	for _, role := range req.Roles {
+		if _, ok := h.authz.Policy().Roles[role]; !ok {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": fmt.Sprintf("unknown role %q", role), "roles": h.authz.Policy().RoleNames()})
+			return
+		}
+	}
+
+	limits := apikey.DefaultLimits()
+	if v := req.Limits.RatePerSecond; v != nil {
+		limits.RatePerSecond = *v
+	}
+	if v := req.Limits.Burst; v != nil {
+		limits.Burst = *v
+	}
+	if v := req.Limits.DailyRPCCalls; v != nil {
+		limits.DailyRPCCalls = *v
+	}
+	if v := req.Limits.DailyValue; v != nil {
+		if *v == "unlimited" {
+			limits.DailyValue = nil
+		} else {
+			value, err := parseBigInt(*v)
+			if err != nil || value == nil {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": fmt.Sprintf("invalid dailyValue %q", *v)})
+				return
+			}
+			limits.DailyValue = value
+		}
+	}
+
+	key, view, err := h.keys.Create(req.Name, req.Roles, limits)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{
+		"key":    key,
+		"apiKey": view,
+	})
+}
+
+// List 所有密钥（不含明文和哈希）及当天用量
+// GET /apikeys
+func (h *APIKeyHandler) List(ctx *gin.Context) {
+	keys := h.keys.List()
+	ctx.JSON(http.StatusOK, gin.H{
+		"count": len(keys),
+		"keys":  keys,
+	})
+}
+
+// Get 一个密钥的配置和当天用量
+// GET /apikeys/:id
+func (h *APIKeyHandler) Get(ctx *gin.Context) {
+	view, err := h.keys.Get(ctx.Param("id"))
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, view)
+}
+
+// Rotate 生成新的明文；graceSeconds 大于 0 时旧密钥在这段时间内仍然可用
+// POST /apikeys/:id/rotate {"graceSeconds": 3600}
+func (h *APIKeyHandler) Rotate(ctx *gin.Context) {
+	var req struct {
+		GraceSeconds int64 `json:"graceSeconds"`
+	}
+	if ctx.Request.ContentLength != 0 {
+		if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+			return
+		}
+	}
+	if req.GraceSeconds < 0 {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": "graceSeconds must not be negative"})
+		return
+	}
+	key, view, err := h.keys.Rotate(ctx.Param("id"), time.Duration(req.GraceSeconds)*time.Second)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"key":    key,
+		"apiKey": view,
+	})
+}
+
+// Revoke 吊销密钥
+// DELETE /apikeys/:id
+func (h *APIKeyHandler) Revoke(ctx *gin.Context) {
+	view, err := h.keys.Revoke(ctx.Param("id"))
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, view)
+}
+
+func (h *APIKeyHandler) writeError(ctx *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, apikey.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, apikey.ErrRevoked):
+		status = http.StatusConflict
+	}
+	ctx.AbortWithStatusJSON(status, gin.H{"msg": err.Error()})
+}
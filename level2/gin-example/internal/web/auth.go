@@ -0,0 +1,234 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/auth"
+	"level2/gin-example/internal/signature"
+	"level2/gin-example/internal/siwe"
+	"level2/gin-example/internal/web/middleware"
+	"level2/network"
+)
+
+// siweLeeway 校验 SIWE 消息时间时允许的时钟误差
+const siweLeeway = time.Minute
+
+// PublicPaths 不需要登录就能访问的路径，注册 JWT 中间件时传给 IgnorePaths
+var PublicPaths = []string{
+	"/users/index",
+	"/networks",
+	"/auth/refresh",
+	"/auth/nonce",
+	"/auth/siwe",
+}
+
+// AuthHandler 以太坊钱包登录（SIWE）、令牌刷新、退出登录和当前用户信息
+type AuthHandler struct {
+	tokens  *auth.Manager
+	pools   *network.Pools // 合约钱包（EIP-1271）登录时按消息里的链 ID 调用 isValidSignature
+	nonces  *siwe.NonceStore
+	domains []string // SIWE 消息允许的 domain，第一个是前端默认使用的
+}
+
+// NewAuthHandler 允许的 SIWE domain 从环境变量 SIWE_DOMAIN 读取（逗号分隔，如 app.example.com,localhost:3000），
+// 没有配置时返回错误：domain 不能从请求的 Host 推断，Host 头由客户端控制
+func NewAuthHandler(tokens *auth.Manager, pools *network.Pools) (*AuthHandler, error) {
+	var domains []string
+	for _, d := range strings.Split(os.Getenv("SIWE_DOMAIN"), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	if len(domains) == 0 {
+		return nil, errors.New("SIWE_DOMAIN is required, e.g. SIWE_DOMAIN=app.example.com")
+	}
+	return &AuthHandler{
+		tokens:  tokens,
+		pools:   pools,
+		nonces:  siwe.NewNonceStore(0),
+		domains: domains,
+	}, nil
+}
+
+func (a *AuthHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/auth")
+	g.GET("/nonce", a.Nonce)
+	g.POST("/siwe", a.SIWE)
+	g.POST("/refresh", a.Refresh)
+	g.POST("/logout", a.Logout)
+	g.GET("/me", a.Me)
+}
+
+// Nonce 签发一个一次性 nonce，前端把它写进 SIWE 消息的 Nonce 字段。
+// 按客户端 IP 限制未使用的 nonce 数（siwe.MaxNoncesPerClient），超出时返回 429
+// GET /auth/nonce
+func (a *AuthHandler) Nonce(ctx *gin.Context) {
+	nonce, expires, err := a.nonces.Issue(ctx.ClientIP())
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, siwe.ErrClientLimit):
+			status = http.StatusTooManyRequests
+			ctx.Header("Retry-After", strconv.Itoa(int(siwe.NonceTTL.Seconds())))
+		case errors.Is(err, siwe.ErrTooManyNonces):
+			status = http.StatusServiceUnavailable
+		}
+		ctx.AbortWithStatusJSON(status, gin.H{"msg": err.Error()})
+		return
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, gin.H{
+		"nonce":     nonce,
+		"domain":    a.domains[0],
+		"expiresAt": expires,
+	})
+}
+
+// SIWE 用 EIP-4361 消息和 personal_sign 签名登录，成功后签发一对令牌，钱包地址写在令牌的 wallets 里。
+// EOA 直接恢复签名者；地址是合约时调用 EIP-1271 isValidSignature
+// POST /auth/siwe {"message": "...", "signature": "0x..."}
+func (a *AuthHandler) SIWE(ctx *gin.Context) {
+	var req struct {
+		Message   string `json:"message"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil || req.Message == "" || req.Signature == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": "message and signature are required"})
+		return
+	}
+	sig, err := hexutil.Decode(req.Signature)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": "invalid signature hex: " + err.Error()})
+		return
+	}
+	msg, err := siwe.Parse(req.Message)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	pool := a.poolFor(msg.ChainID)
+	if pool == nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": fmt.Sprintf("unsupported chain id %d", msg.ChainID)})
+		return
+	}
+	// 消息里的 domain 必须是配置的 domain 之一，防止别的网站骗用户签名后拿来登录
+	if err := msg.Validate(siwe.VerifyOptions{Domains: a.domains, Leeway: siweLeeway}); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": err.Error()})
+		return
+	}
+	// 先消费 nonce 再验签，签名错误的消息也不能拿同一个 nonce 重试
+	if err := a.nonces.Consume(msg.Nonce); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": err.Error()})
+		return
+	}
+
+	result, err := signature.Verify(ctx.Request.Context(), pool.Client(), msg.Address, signature.PersonalHash([]byte(req.Message)), sig)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{"msg": "eip-1271 check failed: " + err.Error()})
+		return
+	}
+	if !result.Valid {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": result.Reason})
+		return
+	}
+
+	pair, err := a.tokens.Issue(auth.Subject{
+		UserID: msg.Address.Hex(),
+		// 钱包登录的用户默认只读，更高的角色由运维用 cmd/token 签发
+		Roles:   []string{auth.RoleViewer},
+		Wallets: []common.Address{msg.Address},
+	})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"msg": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"address": msg.Address,
+		"chainId": msg.ChainID,
+		"method":  result.Method,
+		"network": pool.Network.Name,
+		"tokens":  pair,
+	})
+}
+
+// poolFor 找到链 ID 对应的网络
+func (a *AuthHandler) poolFor(chainID uint64) *network.Pool {
+	if a.pools == nil {
+		return nil
+	}
+	for _, pool := range a.pools.All() {
+		if pool.Network.ChainID == chainID {
+			return pool
+		}
+	}
+	return nil
+}
+
+// Refresh 用刷新令牌换一对新令牌，旧的刷新令牌随即失效；重复使用旧的刷新令牌会吊销整个会话
+// POST /auth/refresh {"refreshToken": "..."}
+func (a *AuthHandler) Refresh(ctx *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": "refreshToken is required"})
+		return
+	}
+	pair, err := a.tokens.Refresh(req.RefreshToken)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if !isTokenError(err) {
+			status = http.StatusInternalServerError
+		}
+		ctx.AbortWithStatusJSON(status, gin.H{"msg": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, pair)
+}
+
+// Logout 吊销当前会话，会话内签发的访问令牌和刷新令牌都不能再用
+// POST /auth/logout
+func (a *AuthHandler) Logout(ctx *gin.Context) {
+	claims, ok := middleware.Claims(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "not logged in"})
+		return
+	}
+	if err := a.tokens.RevokeSession(claims); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"msg": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"session": claims.Session, "revoked": true})
+}
+
+// Me 当前令牌里的用户、角色和钱包地址
+// GET /auth/me
+func (a *AuthHandler) Me(ctx *gin.Context) {
+	claims, ok := middleware.Claims(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "not logged in"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"userId":    claims.UserID,
+		"roles":     claims.Roles,
+		"wallets":   claims.Wallets,
+		"session":   claims.Session,
+		"expiresAt": claims.ExpiresAt,
+	})
+}
+
+// isTokenError 令牌本身的问题（签名、过期、吊销、类型），其余是服务端错误（如写吊销列表失败）
+func isTokenError(err error) bool {
+	return errors.Is(err, auth.ErrRevoked) || errors.Is(err, auth.ErrTokenType) || errors.Is(err, auth.ErrInvalidToken)
+}
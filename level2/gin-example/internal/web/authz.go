@@ -0,0 +1,84 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthzRoles 当前的角色和授权
+// GET /authz/roles
+func (u *UserHandler) AuthzRoles(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, u.authz.Policy())
+}
+
+// AuthzDenials 最近被拒绝的请求，新的在前
+// GET /authz/denials?limit=100
+func (u *UserHandler) AuthzDenials(ctx *gin.Context) {
+	limit := 100
+	if raw := ctx.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid limit %q", raw))
+			return
+		}
+		limit = n
+	}
+	denials := u.authz.Denials(limit)
+	ctx.JSON(http.StatusOK, gin.H{
+		"count":   len(denials),
+		"denials": denials,
+	})
+}
+
+// defaultSigner 请求里没有 from 时处理函数会用默认托管账户签名，权限也按它检查
+func (u *UserHandler) defaultSigner(*gin.Context) (*common.Address, error) {
+	addr, err := u.accounts.Default()
+	if err != nil {
+		// 没有托管账户时交给处理函数报错
+		return nil, nil
+	}
+	return &addr, nil
+}
+
+// vaultSigner Transaction 用 ?vault= 指定的助记词的第一个账户签名，权限按它检查。
+// 未解锁或不存在时直接拒绝：如果放行，检查之后才解锁的助记词会用没有检查过的账户签名
+func (u *UserHandler) vaultSigner(ctx *gin.Context) (*common.Address, error) {
+	addr, err := u.vault.Address(ctx.Query("vault"), defaultDerivationBase+"/0")
+	if err != nil {
+		return nil, err
+	}
+	return &addr, nil
+}
+
+// typedDataContract 签名 typedData 时按 domain.verifyingContract 检查合约范围；请求体和处理函数用同样的方式解析，
+// 读完后放回去。解析失败或没有 verifyingContract 时返回 nil，交给处理函数报错
+func (u *UserHandler) typedDataContract(ctx *gin.Context) (*common.Address, error) {
+	if ctx.Request.Body == nil {
+		return nil, nil
+	}
+	raw, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(raw))
+	var req typedDataReq
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return nil, nil
+	}
+	td, err := req.parse()
+	if err != nil || td.Domain.VerifyingContract == "" {
+		return nil, nil
+	}
+	if !common.IsHexAddress(td.Domain.VerifyingContract) {
+		return nil, fmt.Errorf("invalid verifyingContract %q", td.Domain.VerifyingContract)
+	}
+	addr := common.HexToAddress(td.Domain.VerifyingContract)
+	return &addr, nil
+}
@@ -0,0 +1,98 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/batch"
+	"level2/gin-example/internal/inspect"
+)
+
+// maxBalanceAddresses 单次 POST /balances 最多查询的地址数
+const maxBalanceAddresses = 5000
+
+// balancesReq 批量查询余额的请求体
+type balancesReq struct {
+	Addresses []string `json:"addresses"`
+	Block     string   `json:"block"`     // 区块号，或 latest / pending / safe / finalized，默认 latest
+	Fields    []string `json:"fields"`    // balance / nonce / code，默认全部
+	ChunkSize int      `json:"chunkSize"` // 每个批量请求的调用数，默认 100，不超过网络配置的 maxBatchSize
+}
+
+// Balances 批量查询余额、nonce 和代码长度，用 JSON-RPC 批量请求代替逐个地址查询。
+// 地址格式错误或单个调用失败只体现在该地址的 errors 里，不影响其它地址
+// POST /balances
+func (u *UserHandler) Balances(ctx *gin.Context) {
+	var req balancesReq
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.Addresses) == 0 || len(req.Addresses) > maxBalanceAddresses {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("addresses must contain 1-%d items", maxBalanceAddresses))
+		return
+	}
+	for _, f := range req.Fields {
+		if f != batch.FieldBalance && f != batch.FieldNonce && f != batch.FieldCode {
+			u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("unknown field %q", f))
+			return
+		}
+	}
+	block, err := blockArg(req.Block)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	// 格式错误的地址不发请求，直接记错误
+	results := make([]*batch.Account, len(req.Addresses))
+	var valid []common.Address
+	var index []int
+	for i, raw := range req.Addresses {
+		addr, _, err := inspect.ParseAddress(raw)
+		if err != nil {
+			results[i] = &batch.Account{Address: raw, Errors: map[string]string{"address": err.Error()}}
+			continue
+		}
+		valid = append(valid, addr)
+		index = append(index, i)
+	}
+	pool := u.pool(ctx)
+	accounts, batches := batch.Lookup(ctx.Request.Context(), pool.Client().Client(), valid,
+		batch.Options{Block: block, Fields: req.Fields, ChunkSize: req.ChunkSize, MaxChunkSize: pool.Network.MaxBatchSize})
+	for i, a := range accounts {
+		results[index[i]] = a
+	}
+
+	failed := 0
+	for _, a := range results {
+		if len(a.Errors) > 0 {
+			failed++
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"block":    block,
+		"count":    len(results),
+		"failed":   failed,
+		"batches":  batches,
+		"accounts": results,
+	})
+}
+
+// blockArg 区块标签原样使用，数字转换成 0x 十六进制
+func blockArg(s string) (string, error) {
+	switch s {
+	case "", "latest":
+		return "latest", nil
+	case "pending", "safe", "finalized", "earliest":
+		return s, nil
+	}
+	n, err := parseBigInt(s)
+	if err != nil {
+		return "", err
+	}
+	return batch.BlockArg(n), nil
+}
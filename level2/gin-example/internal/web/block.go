@@ -0,0 +1,83 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/explorer"
+)
+
+const (
+	defaultPageSize = 25
+	maxPageSize     = 100
+	maxPage         = 1_000_000
+)
+
+// LatestBlock 最新区块
+// GET /blocks/latest
+func (u *UserHandler) LatestBlock(ctx *gin.Context) {
+	u.blockJSON(ctx, "latest")
+}
+
+// GetBlock 按区块号（十进制或 0x）或区块哈希查询区块
+// GET /blocks/:block
+func (u *UserHandler) GetBlock(ctx *gin.Context) {
+	u.blockJSON(ctx, ctx.Param("block"))
+}
+
+func (u *UserHandler) blockJSON(ctx *gin.Context, id string) {
+	block, err := explorer.BlockByID(ctx.Request.Context(), u.client(ctx), id)
+	if err != nil {
+		u.writeError(ctx, blockErrorStatus(err), err)
+		return
+	}
+	ctx.JSON(http.StatusOK, explorer.NewBlockView(block))
+}
+
+// BlockTransactions 区块内交易分页，发送者地址从签名恢复
+// GET /blocks/:block/txs?page=&pageSize=
+func (u *UserHandler) BlockTransactions(ctx *gin.Context) {
+	page, size, err := parsePage(ctx)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	block, err := explorer.BlockByID(ctx.Request.Context(), u.client(ctx), ctx.Param("block"))
+	if err != nil {
+		u.writeError(ctx, blockErrorStatus(err), err)
+		return
+	}
+	chainID, err := u.client(ctx).ChainID(ctx.Request.Context())
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, explorer.BlockTxs(block, explorer.Signer(chainID), page, size))
+}
+
+// parsePage page 从 1 开始，最大 1000000；pageSize 默认 25，最大 100
+func parsePage(ctx *gin.Context) (int, int, error) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 || page > maxPage {
+		return 0, 0, fmt.Errorf("invalid page %q, must be 1-%d", ctx.Query("page"), maxPage)
+	}
+	size, err := strconv.Atoi(ctx.DefaultQuery("pageSize", strconv.Itoa(defaultPageSize)))
+	if err != nil || size < 1 || size > maxPageSize {
+		return 0, 0, fmt.Errorf("invalid pageSize %q, must be 1-%d", ctx.Query("pageSize"), maxPageSize)
+	}
+	return page, size, nil
+}
+
+func blockErrorStatus(err error) int {
+	if errors.Is(err, explorer.ErrInvalidBlockID) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, ethereum.NotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadGateway
+}
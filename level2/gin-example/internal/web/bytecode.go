@@ -0,0 +1,95 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/bytecode"
+	"level2/gin-example/internal/deploy"
+)
+
+// VerifyContract 比对链上 runtime 字节码与本地编译产物
+// GET /contracts/:address/verify?contract=Counter&block=
+func (u *UserHandler) VerifyContract(ctx *gin.Context) {
+	if !common.IsHexAddress(ctx.Param("address")) {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid address %s", ctx.Param("address")))
+		return
+	}
+	address := common.HexToAddress(ctx.Param("address"))
+	block, err := parseBigInt(ctx.Query("block"))
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	// 没有指定合约名时，用注册表里登记的名字
+	name := ctx.Query("contract")
+	if name == "" {
+		entry, err := u.registry(ctx).ByAddress(address)
+		if err != nil {
+			u.writeError(ctx, http.StatusBadRequest, errors.New("contract is required: "+err.Error()))
+			return
+		}
+		name = entry.Name
+	}
+	artifact, err := deploy.FindArtifact(artifactDirs, name)
+	if err != nil {
+		u.writeError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	code, err := u.client(ctx).CodeAt(ctx.Request.Context(), address, block)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	result, err := bytecode.Verify(code, artifact)
+	if err != nil {
+		u.writeError(ctx, http.StatusUnprocessableEntity, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"address":      address.Hex(),
+		"artifact":     artifact.Source,
+		"verification": result,
+	})
+}
+
+// DisassembleContract 反汇编链上代码，列出分发器中的函数选择器并与注册表 ABI 匹配，标记代理和 SELFDESTRUCT
+// GET /contracts/:address/disasm?block=&instructions=true
+func (u *UserHandler) DisassembleContract(ctx *gin.Context) {
+	if !common.IsHexAddress(ctx.Param("address")) {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid address %s", ctx.Param("address")))
+		return
+	}
+	address := common.HexToAddress(ctx.Param("address"))
+	block, err := parseBigInt(ctx.Query("block"))
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	code, err := u.client(ctx).CodeAt(ctx.Request.Context(), address, block)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	if len(code) == 0 {
+		u.writeError(ctx, http.StatusNotFound, fmt.Errorf("no code at %s", address.Hex()))
+		return
+	}
+	analysis := bytecode.Analyze(code, u.registry(ctx).ABIs()...)
+	if ctx.Query("instructions") != "true" {
+		analysis.Instructions = nil // 指令列表很长，默认不返回
+	}
+	resp := gin.H{
+		"address":  address.Hex(),
+		"analysis": analysis,
+	}
+	if entry, err := u.registry(ctx).ByAddress(address); err == nil {
+		resp["contract"] = entry.Name
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
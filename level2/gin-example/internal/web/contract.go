@@ -0,0 +1,181 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/contract"
+	"level2/gin-example/internal/revert"
+)
+
+// contractReq 动态调用合约的请求体
+type contractReq struct {
+	ABI      json.RawMessage   `json:"abi"`      // 可选，不传则按地址从注册表里找
+	Args     []json.RawMessage `json:"args"`     // 参数按 ABI 顺序排列
+	From     *common.Address   `json:"from"`     // 可选，默认第一个托管账户
+	Value    string            `json:"value"`    // wei，十进制或 0x 十六进制
+	Block    string            `json:"block"`    // 仅 call 使用，默认最新区块
+	GasLimit uint64            `json:"gasLimit"` // 仅 send 使用，0 表示自动估算
+	Wait     bool              `json:"wait"`     // 仅 send 使用，等待回执，失败时返回回滚原因
+}
+
+// CallContract 通过方法名或完整签名做 eth_call，返回解码后的结果
+// POST /contracts/:address/call/:method
+func (u *UserHandler) CallContract(ctx *gin.Context) {
+	address, parsed, method, req, err := u.parseContractReq(ctx)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	value, err := parseBigInt(req.Value)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	block, err := parseBigInt(req.Block)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	var from common.Address
+	if req.From != nil {
+		from = *req.From
+	}
+	result, err := contract.Call(ctx.Request.Context(), u.client(ctx), address, method, req.Args, from, value, block)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err, parsed)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// SendContract 用托管账户签名并发送交易，返回交易哈希
+// POST /contracts/:address/send/:method
+func (u *UserHandler) SendContract(ctx *gin.Context) {
+	address, parsed, method, req, err := u.parseContractReq(ctx)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	value, err := parseBigInt(req.Value)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	opts, err := u.transactor(ctx, req.From)
+	if err != nil {
+		u.writeError(ctx, http.StatusForbidden, err)
+		return
+	}
+	from := opts.From
+	opts.Value = value
+	opts.GasLimit = req.GasLimit
+
+	// 没有指定 gasLimit 时 bind 会先 EstimateGas，合约回滚的原因在这里就能解码出来
+	tx, err := contract.Send(opts, u.client(ctx), address, parsed, method, req.Args)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err, parsed)
+		return
+	}
+	if !req.Wait {
+		ctx.JSON(http.StatusOK, gin.H{
+			"method": method.Sig,
+			"from":   from.Hex(),
+			"txHash": tx.Hash().Hex(),
+		})
+		return
+	}
+	receipt, err := bind.WaitMined(ctx.Request.Context(), u.client(ctx), tx)
+	if err != nil {
+		u.writeError(ctx, http.StatusGatewayTimeout, err)
+		return
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		reason, err := u.revertDecoder(ctx, parsed).FromReceipt(ctx.Request.Context(), u.client(ctx), tx, receipt)
+		if err != nil {
+			u.writeError(ctx, http.StatusBadGateway, err)
+			return
+		}
+		u.writeError(ctx, http.StatusConflict, &revert.Error{TxHash: tx.Hash().Hex(), Reason: reason})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"method":  method.Sig,
+		"from":    from.Hex(),
+		"txHash":  tx.Hash().Hex(),
+		"block":   receipt.BlockNumber.String(),
+		"gasUsed": receipt.GasUsed,
+	})
+}
+
+// parseContractReq 解析路径里的地址和方法、请求体，并确定使用的 ABI
+func (u *UserHandler) parseContractReq(ctx *gin.Context) (common.Address, abi.ABI, abi.Method, *contractReq, error) {
+	var req contractReq
+	if !common.IsHexAddress(ctx.Param("address")) {
+		return common.Address{}, abi.ABI{}, abi.Method{}, nil, fmt.Errorf("invalid address %s", ctx.Param("address"))
+	}
+	address := common.HexToAddress(ctx.Param("address"))
+	if ctx.Request.ContentLength != 0 {
+		if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+			return address, abi.ABI{}, abi.Method{}, nil, err
+		}
+	}
+
+	var parsed abi.ABI
+	if len(req.ABI) > 0 {
+		var err error
+		if parsed, err = abi.JSON(strings.NewReader(string(req.ABI))); err != nil {
+			return address, abi.ABI{}, abi.Method{}, nil, err
+		}
+	} else {
+		entry, err := u.registry(ctx).ByAddress(address)
+		if err != nil {
+			return address, abi.ABI{}, abi.Method{}, nil, errors.New("abi is required: " + err.Error())
+		}
+		parsed = entry.ABI
+	}
+	method, err := contract.FindMethod(parsed, ctx.Param("method"))
+	if err != nil {
+		return address, parsed, abi.Method{}, nil, err
+	}
+	return address, parsed, method, &req, nil
+}
+
+// transactor 为请求指定的（或默认的）托管账户生成交易选项
+func (u *UserHandler) transactor(ctx *gin.Context, from *common.Address) (*bind.TransactOpts, error) {
+	addr, err := u.accounts.Resolve(from)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := u.client(ctx).ChainID(ctx.Request.Context())
+	if err != nil {
+		return nil, err
+	}
+	opts, err := u.accounts.Transactor(addr, chainID)
+	if err != nil {
+		return nil, err
+	}
+	opts.Context = ctx.Request.Context()
+	return opts, nil
+}
+
+// parseBigInt 空字符串返回 nil，支持十进制和 0x 十六进制
+func parseBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,169 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/deploy"
+	"level2/gin-example/internal/revert"
+)
+
+// create2Req CREATE2 预测 / 部署的请求体
+type create2Req struct {
+	deployReq
+	Salt    string          `json:"salt"`    // 0x 开头的十六进制，不足 32 字节左侧补零
+	Factory *common.Address `json:"factory"` // 默认使用标准工厂 0x4e59b448...
+}
+
+// PredictCreate2 计算 CREATE2 地址并检查是否已有代码
+// POST /contracts/create2/predict
+func (u *UserHandler) PredictCreate2(ctx *gin.Context) {
+	req, _, initCode, salt, factory, err := u.parseCreate2Req(ctx)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	prediction, err := deploy.Predict(ctx.Request.Context(), u.client(ctx), factory, salt, initCode)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"contract":   req.Contract,
+		"prediction": prediction,
+	})
+}
+
+// DeployCreate2 通过 CREATE2 工厂部署合约，同一个 salt 在不同链上得到相同的地址
+// POST /contracts/create2/deploy
+func (u *UserHandler) DeployCreate2(ctx *gin.Context) {
+	req, artifact, initCode, salt, factory, err := u.parseCreate2Req(ctx)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	value, err := parseBigInt(req.Value)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	opts, err := u.transactor(ctx, req.From)
+	if err != nil {
+		u.writeError(ctx, http.StatusForbidden, err)
+		return
+	}
+	opts.Value = value
+	opts.GasLimit = req.GasLimit
+
+	result, prediction, err := deploy.Create2Deploy(ctx.Request.Context(), opts, u.client(ctx), factory, salt, req.Contract, initCode)
+	if errors.Is(err, deploy.ErrCollision) {
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"msg":        err.Error(),
+			"prediction": prediction,
+		})
+		return
+	}
+	var failed *deploy.FailedError
+	if errors.As(err, &failed) {
+		// 构造函数回滚：在出块前的状态上重新模拟构造函数，拿到回滚原因
+		receipt, rerr := u.client(ctx).TransactionReceipt(ctx.Request.Context(), failed.Tx.Hash())
+		if rerr == nil {
+			parent := new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1))
+			if simErr := deploy.SimulateCreate2(ctx.Request.Context(), u.client(ctx), factory, opts.From, value, initCode, parent); simErr != nil {
+				if reason, ok := u.revertDecoder(ctx, artifact.ABI).DecodeError(simErr); ok {
+					err = &revert.Error{TxHash: failed.Tx.Hash().Hex(), Reason: reason}
+				}
+			}
+		}
+	}
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err, artifact.ABI)
+		return
+	}
+
+	entry, err := u.bindDeployment(ctx, req.Contract, result.Address)
+	if err != nil {
+		u.writeError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"deployment": result,
+		"prediction": prediction,
+		"registry":   entry,
+	})
+}
+
+// EnsureCreate2Factory 本地链上没有标准 CREATE2 工厂时部署一个。其它网络上只查询：部署要用托管账户给预签名交易的
+// 签名者转真实的 ETH，而拒绝不带 chainId 交易的节点会让这笔钱取不出来，没有工厂时返回 403
+// POST /contracts/create2/factory
+func (u *UserHandler) EnsureCreate2Factory(ctx *gin.Context) {
+	n := u.pool(ctx).Network
+	if !n.Devnet {
+		code, err := u.client(ctx).CodeAt(ctx.Request.Context(), deploy.FactoryAddress, nil)
+		if err != nil {
+			u.writeError(ctx, http.StatusBadGateway, err)
+			return
+		}
+		if len(code) == 0 {
+			u.writeError(ctx, http.StatusForbidden, fmt.Errorf("%w on %s, and it can only be deployed on devnet networks", deploy.ErrFactoryNotDeployed, n.Name))
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"factory": deploy.FactoryAddress.Hex(),
+		})
+		return
+	}
+	var req struct {
+		From *common.Address `json:"from"`
+	}
+	if ctx.Request.ContentLength != 0 {
+		if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+			u.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+	opts, err := u.transactor(ctx, req.From)
+	if err != nil {
+		u.writeError(ctx, http.StatusForbidden, err)
+		return
+	}
+	factory, err := deploy.EnsureFactory(ctx.Request.Context(), opts, u.client(ctx))
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"factory": factory.Hex(),
+	})
+}
+
+// parseCreate2Req 解析请求，找到构件并生成 initCode、salt 和工厂地址
+func (u *UserHandler) parseCreate2Req(ctx *gin.Context) (*create2Req, *deploy.Artifact, []byte, common.Hash, common.Address, error) {
+	var req create2Req
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		return nil, nil, nil, common.Hash{}, common.Address{}, err
+	}
+	saltBytes, err := hexutil.Decode(req.Salt)
+	if err != nil || len(saltBytes) > common.HashLength {
+		return nil, nil, nil, common.Hash{}, common.Address{}, fmt.Errorf("invalid salt %q", req.Salt)
+	}
+	artifact, err := deploy.FindArtifact(artifactDirs, req.Contract)
+	if err != nil {
+		return nil, nil, nil, common.Hash{}, common.Address{}, err
+	}
+	initCode, err := deploy.InitCode(artifact, req.Args, req.Libraries)
+	if err != nil {
+		return nil, nil, nil, common.Hash{}, common.Address{}, err
+	}
+	factory := deploy.FactoryAddress
+	if req.Factory != nil {
+		factory = *req.Factory
+	}
+	return &req, artifact, initCode, common.BytesToHash(saltBytes), factory, nil
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/deploy"
+	"level2/gin-example/internal/registry"
+	"level2/gin-example/internal/revert"
+)
+
+// deployReq 部署合约的请求体
+type deployReq struct {
+	Contract  string                    `json:"contract"`  // 合约名，如 Counter、MyToken、Store
+	Args      []json.RawMessage         `json:"args"`      // 构造函数参数
+	Libraries map[string]common.Address `json:"libraries"` // 库名（或 源文件:库名）-> 已部署的库地址
+	From      *common.Address           `json:"from"`
+	Value     string                    `json:"value"`
+	GasLimit  uint64                    `json:"gasLimit"`
+}
+
+// DeployContract 从 task2/artifacts 或 pkg 下的 .abi + .bin 部署任意合约，并登记到注册表
+// POST /contracts/deploy
+func (u *UserHandler) DeployContract(ctx *gin.Context) {
+	var req deployReq
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	artifact, err := deploy.FindArtifact(artifactDirs, req.Contract)
+	if err != nil {
+		u.writeError(ctx, http.StatusNotFound, err)
+		return
+	}
+	value, err := parseBigInt(req.Value)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	opts, err := u.transactor(ctx, req.From)
+	if err != nil {
+		u.writeError(ctx, http.StatusForbidden, err)
+		return
+	}
+	opts.Value = value
+	opts.GasLimit = req.GasLimit
+
+	result, err := deploy.Deploy(ctx.Request.Context(), opts, u.client(ctx), artifact, req.Args, req.Libraries)
+	var failed *deploy.FailedError
+	if errors.As(err, &failed) {
+		// 构造函数回滚：取回执重新模拟，拿到回滚原因
+		receipt, rerr := u.client(ctx).TransactionReceipt(ctx.Request.Context(), failed.Tx.Hash())
+		if rerr == nil {
+			if reason, rerr := u.revertDecoder(ctx, artifact.ABI).FromReceipt(ctx.Request.Context(), u.client(ctx), failed.Tx, receipt); rerr == nil && reason != nil {
+				err = &revert.Error{TxHash: failed.Tx.Hash().Hex(), Reason: reason}
+			}
+		}
+	}
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err, artifact.ABI)
+		return
+	}
+
+	entry, err := u.bindDeployment(ctx, artifact.Name, result.Address)
+	if err != nil {
+		u.writeError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"deployment": result,
+		"registry":   entry,
+	})
+}
+
+// bindDeployment 把新部署的合约登记到注册表，之后 /contracts/:address/call 可以直接使用
+func (u *UserHandler) bindDeployment(ctx *gin.Context, name string, address common.Address) (*registry.Entry, error) {
+	if _, err := u.registry(ctx).ByName(name); err != nil {
+		artifact, err := deploy.FindArtifact(artifactDirs, name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := u.registry(ctx).Register(artifact.Name, artifact.RawABI, artifact.Source); err != nil {
+			return nil, err
+		}
+	}
+	return u.registry(ctx).Bind(name, address)
+}
@@ -0,0 +1,105 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/explorer"
+	"level2/gin-example/internal/inspect"
+	"level2/gin-example/internal/scanner"
+)
+
+// AddressHistory 本地扫描器记录的地址交易（发送、接收以及 Transfer 事件），按区块倒序分页
+// GET /addresses/:addr/txs?page=&pageSize=
+func (u *UserHandler) AddressHistory(ctx *gin.Context) {
+	address, _, err := inspect.ParseAddress(ctx.Param("addr"))
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	page, size, err := parsePage(ctx)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	records, total := u.scanner(ctx).Store().History(address, page, size)
+	ctx.JSON(http.StatusOK, gin.H{
+		"address":   address.Hex(),
+		"watched":   u.isWatched(ctx, address),
+		"lastBlock": u.scanner(ctx).Store().LastBlock(),
+		"txs":       &explorer.Page{Page: page, PageSize: size, Total: total, Items: records},
+	})
+}
+
+// WatchAddress 把地址加入扫描器的监控列表，之后的扫描才会记录它的交易
+// POST /addresses/:addr/watch
+func (u *UserHandler) WatchAddress(ctx *gin.Context) {
+	address, _, err := inspect.ParseAddress(ctx.Param("addr"))
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	added := u.scanner(ctx).Store().Watch(address)
+	if err := u.scanner(ctx).Store().Save(); err != nil {
+		u.writeError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"address": address.Hex(), "added": added})
+}
+
+// ScannerStatus 扫描进度
+// GET /scanner/status
+func (u *UserHandler) ScannerStatus(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, u.scanner(ctx).Status())
+}
+
+// StartScan 在后台扫描区块范围，from 默认接着上次扫描的位置，to 默认最新区块；请求体可以为空
+// POST /scanner/scan {"from": 7146892, "to": 7146990}
+func (u *UserHandler) StartScan(ctx *gin.Context) {
+	var req struct {
+		From *uint64
+		To   *uint64
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if req.To == nil {
+		head, err := u.client(ctx).BlockNumber(ctx.Request.Context())
+		if err != nil {
+			u.writeError(ctx, http.StatusBadGateway, err)
+			return
+		}
+		req.To = &head
+	}
+	if req.From == nil {
+		from := u.scanner(ctx).Store().LastBlock() + 1
+		req.From = &from
+	}
+	if len(u.scanner(ctx).Store().Watched()) == 0 {
+		u.writeError(ctx, http.StatusBadRequest, errors.New("no watched address, POST /addresses/:addr/watch first"))
+		return
+	}
+	if err := u.scanner(ctx).Start(*req.From, *req.To); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, scanner.ErrBusy) {
+			status = http.StatusConflict
+		}
+		u.writeError(ctx, status, err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, u.scanner(ctx).Status())
+}
+
+func (u *UserHandler) isWatched(ctx *gin.Context, address common.Address) bool {
+	for _, a := range u.scanner(ctx).Store().Watched() {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/apikey"
+	"level2/gin-example/internal/auth"
+	"level2/network"
+)
+
+const (
+	// APIKeyHeader 携带 API 密钥的请求头
+	APIKeyHeader = "X-API-Key"
+	// APIKeyIDKey gin 上下文中保存密钥 id 的键
+	APIKeyIDKey = "apiKeyId"
+)
+
+// APIKeyMiddlewareBuilder 用 API 密钥认证，供内部服务代替 JWT 使用。
+// 请求带了 X-API-Key 才会处理，没带的交给后面的 JWT 中间件
+type APIKeyMiddlewareBuilder struct {
+	store *apikey.Store
+}
+
+func NewAPIKeyMiddlewareBuilder(store *apikey.Store) *APIKeyMiddlewareBuilder {
+	return &APIKeyMiddlewareBuilder{store: store}
+}
+
+// Build 校验密钥、扣减令牌桶，把密钥的角色作为登录信息交给权限检查；
+// 请求上下文里挂上 CallHook，处理过程中发往节点的调用计入每日额度，超额时调用直接失败。
+// 所有响应都带上额度头：
+//   - X-RateLimit-Limit / X-RateLimit-Remaining    令牌桶容量和剩余
+//   - X-Quota-RPC-Limit / X-Quota-RPC-Remaining    当天的 RPC 调用额度（不限时不返回）
+//   - X-Quota-Value-Limit / X-Quota-Value-Remaining 当天的转出额度 wei（不限时不返回）
+//   - X-Quota-Reset                                 每日额度重置的 Unix 时间
+func (b *APIKeyMiddlewareBuilder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		raw := ctx.GetHeader(APIKeyHeader)
+		if raw == "" {
+			ctx.Next()
+			return
+		}
+		key, err := b.store.Authenticate(raw)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": err.Error()})
+			return
+		}
+		quota, err := b.store.Allow(key.ID)
+		writeQuotaHeaders(ctx.Writer.Header(), quota)
+		if err != nil {
+			if errors.Is(err, apikey.ErrRateLimited) {
+				retry := time.Second
+				if key.Limits.RatePerSecond > 0 {
+					retry = time.Duration(math.Ceil(1/key.Limits.RatePerSecond)) * time.Second
+				}
+				ctx.Header("Retry-After", strconv.Itoa(int(retry.Seconds())))
+				ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"msg": err.Error()})
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"msg": err.Error()})
+			return
+		}
+
+		// 处理函数可能并发发起 RPC 调用，更新响应头时加锁
+		var mu sync.Mutex
+		hook := func(calls []network.Call) error {
+			q, err := b.store.Charge(key.ID, calls)
+			mu.Lock()
+			writeQuotaHeaders(ctx.Writer.Header(), q)
+			mu.Unlock()
+			return err
+		}
+		ctx.Request = ctx.Request.WithContext(network.WithCallHook(ctx.Request.Context(), hook))
+		ctx.Set(APIKeyIDKey, key.ID)
+		ctx.Set(ClaimsKey, &auth.Claims{
+			UserID:  "apikey:" + key.ID,
+			Roles:   key.Roles,
+			Type:    auth.TypeAccess,
+			Session: key.ID,
+		})
+		ctx.Next()
+	}
+}
+
+func writeQuotaHeaders(h http.Header, q apikey.Quota) {
+	if q.RateLimit == 0 {
+		return
+	}
+	h.Set("X-RateLimit-Limit", strconv.Itoa(q.RateLimit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(q.RateRemaining))
+	if q.RPCLimit > 0 {
+		h.Set("X-Quota-RPC-Limit", strconv.FormatInt(q.RPCLimit, 10))
+		h.Set("X-Quota-RPC-Remaining", strconv.FormatInt(q.RPCRemaining, 10))
+	}
+	if q.ValueLimit != nil {
+		h.Set("X-Quota-Value-Limit", q.ValueLimit.String())
+		h.Set("X-Quota-Value-Remaining", q.ValueRemaining.String())
+	}
+	h.Set("X-Quota-Reset", strconv.FormatInt(q.Reset.Unix(), 10))
+}
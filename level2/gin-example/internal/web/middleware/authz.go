@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/auth"
+)
+
+// maxDenials 内存里保留的最近拒绝记录数
+const maxDenials = 1000
+
+// Scope 从请求中取出签名账户或合约地址；返回 nil 表示请求没有指定
+type Scope func(ctx *gin.Context) (*common.Address, error)
+
+// Fixed 接口固定使用的地址（如示例接口里写死的私钥和合约）
+func Fixed(addr common.Address) Scope {
+	return func(*gin.Context) (*common.Address, error) {
+		return &addr, nil
+	}
+}
+
+// Param 路径参数，如 /contracts/:address
+func Param(name string) Scope {
+	return func(ctx *gin.Context) (*common.Address, error) {
+		return parseScope(name, ctx.Param(name))
+	}
+}
+
+// BodyField JSON 请求体里的地址字段。读完后把请求体放回去，处理函数还能再读。
+// 字段名和 encoding/json 一样不区分大小写；同一个字段以不同大小写出现多次的请求直接拒绝，
+// 否则这里检查的值和处理函数实际使用的值可能不是同一个
+func BodyField(name string) Scope {
+	return func(ctx *gin.Context) (*common.Address, error) {
+		if ctx.Request.Body == nil {
+			return nil, nil
+		}
+		raw, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			return nil, err
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(raw))
+		if len(bytes.TrimSpace(raw)) == 0 {
+			return nil, nil
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			// 格式错误交给处理函数报告
+			return nil, nil
+		}
+		var found json.RawMessage
+		for key, v := range fields {
+			if !strings.EqualFold(key, name) {
+				continue
+			}
+			if found != nil {
+				return nil, fmt.Errorf("duplicate field %q", name)
+			}
+			found = v
+		}
+		var value string
+		if found == nil || json.Unmarshal(found, &value) != nil {
+			return nil, nil
+		}
+		return parseScope(name, value)
+	}
+}
+
+// Or 依次尝试，取第一个非空的地址，用于“请求里没写就用默认账户”
+func Or(scopes ...Scope) Scope {
+	return func(ctx *gin.Context) (*common.Address, error) {
+		for _, s := range scopes {
+			addr, err := s(ctx)
+			if err != nil || addr != nil {
+				return addr, err
+			}
+		}
+		return nil, nil
+	}
+}
+
+func parseScope(name, value string) (*common.Address, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if !common.IsHexAddress(value) {
+		return nil, fmt.Errorf("invalid %s %q", name, value)
+	}
+	addr := common.HexToAddress(value)
+	return &addr, nil
+}
+
+// Denial 一次被拒绝的请求
+type Denial struct {
+	Time       time.Time       `json:"time"`
+	UserID     string          `json:"userId"`
+	Roles      []string        `json:"roles,omitempty"`
+	Method     string          `json:"method"`
+	Path       string          `json:"path"`
+	Permission auth.Permission `json:"permission"`
+	Account    *common.Address `json:"account,omitempty"`
+	Contract   *common.Address `json:"contract,omitempty"`
+	Reason     string          `json:"reason"`
+}
+
+// Authorizer 按角色策略检查每个路由声明的权限，拒绝的请求写日志并保留最近的记录
+type Authorizer struct {
+	policy *auth.Policy
+
+	mu      sync.Mutex
+	denials []Denial
+	next    int // 环形缓冲区的写入位置
+}
+
+func NewAuthorizer(policy *auth.Policy) *Authorizer {
+	return &Authorizer{policy: policy}
+}
+
+// RequireOption 限定权限检查的范围
+type RequireOption func(*requirement)
+
+type requirement struct {
+	account  Scope
+	contract Scope
+}
+
+// Account 签名账户从哪里取，授权里配置了 accounts 时只能用这些账户签名
+func Account(s Scope) RequireOption {
+	return func(r *requirement) { r.account = s }
+}
+
+// Contract 目标合约从哪里取，授权里配置了 contracts 时只能操作这些合约
+func Contract(s Scope) RequireOption {
+	return func(r *requirement) { r.contract = s }
+}
+
+// Require 返回检查权限的中间件，在 RegisterRoutes 里放在处理函数前面：
+//
+//	ug.GET("/transferETH", authz.Require(auth.PermTransfer, middleware.Account(middleware.Fixed(signer))), u.TransferETH)
+//
+// 需要放在 JWT 中间件之后；没有登录信息的请求返回 401，权限不够返回 403
+func (a *Authorizer) Require(perm auth.Permission, opts ...RequireOption) gin.HandlerFunc {
+	r := &requirement{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return func(ctx *gin.Context) {
+		req := auth.Request{Permission: perm}
+		var err error
+		if r.account != nil {
+			if req.Account, err = r.account(ctx); err != nil {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+				return
+			}
+		}
+		if r.contract != nil {
+			if req.Contract, err = r.contract(ctx); err != nil {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+				return
+			}
+		}
+		if a.Authorize(ctx, req) {
+			ctx.Next()
+		}
+	}
+}
+
+// Authorize 在处理函数里检查额外的权限（比如要看请求内容才知道需要什么权限），
+// 拒绝时和 Require 一样写响应并记录，返回 false
+func (a *Authorizer) Authorize(ctx *gin.Context, req auth.Request) bool {
+	claims, ok := Claims(ctx)
+	if !ok {
+		unauthorized(ctx, "login required")
+		return false
+	}
+	if err := a.policy.Authorize(claims, req); err != nil {
+		a.deny(ctx, claims, req, err)
+		return false
+	}
+	return true
+}
+
+func (a *Authorizer) deny(ctx *gin.Context, claims *auth.Claims, req auth.Request, err error) {
+	d := Denial{
+		Time:       time.Now(),
+		UserID:     claims.UserID,
+		Roles:      claims.Roles,
+		Method:     ctx.Request.Method,
+		Path:       ctx.Request.URL.Path,
+		Permission: req.Permission,
+		Account:    req.Account,
+		Contract:   req.Contract,
+		Reason:     err.Error(),
+	}
+	log.Printf("authz: denied %s %s for user %s: %v", d.Method, d.Path, d.UserID, err)
+	a.mu.Lock()
+	if len(a.denials) < maxDenials {
+		a.denials = append(a.denials, d)
+	} else {
+		a.denials[a.next] = d
+	}
+	a.next = (a.next + 1) % maxDenials
+	a.mu.Unlock()
+
+	status := http.StatusForbidden
+	if !errors.Is(err, auth.ErrForbidden) {
+		status = http.StatusInternalServerError
+	}
+	ctx.AbortWithStatusJSON(status, gin.H{"msg": err.Error(), "permission": req.Permission})
+}
+
+// Denials 最近的拒绝记录，新的在前；limit <= 0 时全部返回
+func (a *Authorizer) Denials(limit int) []Denial {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := len(a.denials)
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+	out := make([]Denial, 0, limit)
+	for i := 1; i <= limit; i++ {
+		out = append(out, a.denials[(a.next-i+maxDenials)%maxDenials])
+	}
+	return out
+}
+
+// Policy 当前使用的角色策略
+func (a *Authorizer) Policy() *auth.Policy {
+	return a.policy
+}
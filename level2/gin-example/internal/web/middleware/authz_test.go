@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+func bodyContext(body string) *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	return ctx
+}
+
+func TestBodyField(t *testing.T) {
+	allowed := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tests := []struct {
+		body    string
+		want    *common.Address
+		wantErr bool
+	}{
+		{body: `{"from":"` + allowed.Hex() + `"}`, want: &allowed},
+		// encoding/json 不区分大小写，处理函数会用 From 的值
+		{body: `{"From":"` + allowed.Hex() + `"}`, want: &allowed},
+		{body: `{"from":"` + allowed.Hex() + `","FROM":"0x00000000000000000000000000000000000000bb"}`, wantErr: true},
+		{body: `{"to":"` + allowed.Hex() + `"}`},
+		{body: ``},
+	}
+	for _, tt := range tests {
+		ctx := bodyContext(tt.body)
+		got, err := BodyField("from")(ctx)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: err = %v, wantErr %v", tt.body, err, tt.wantErr)
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Fatalf("%s: got %v, want %v", tt.body, got, tt.want)
+		}
+		// 请求体放回去了，处理函数还能读到原样的内容
+		if rest, _ := io.ReadAll(ctx.Request.Body); string(rest) != tt.body {
+			t.Fatalf("body after scope = %q, want %q", rest, tt.body)
+		}
+	}
+}
@@ -1,36 +1,132 @@
 package middleware
 
 import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"level2/gin-example/internal/auth"
 )
 
+// ClaimsKey gin 上下文中保存已校验声明的键
+const ClaimsKey = "claims"
+
 type LoginJWTMiddlewareBuilder struct {
-	paths []string
+	paths   []string
+	manager *auth.Manager
 }
 
-func NewLoginJWTMiddlewareBuilder() *LoginJWTMiddlewareBuilder {
-	return &LoginJWTMiddlewareBuilder{}
+func NewLoginJWTMiddlewareBuilder(manager *auth.Manager) *LoginJWTMiddlewareBuilder {
+	return &LoginJWTMiddlewareBuilder{manager: manager}
 }
 
-func (l *LoginJWTMiddlewareBuilder) IgnorePaths(path string) *LoginJWTMiddlewareBuilder {
-	l.paths = append(l.paths, path)
+// IgnorePaths 不需要登录的路径，支持三种写法：
+//   - /networks          精确匹配
+//   - /blocks/**         前缀匹配，/blocks 以及它下面的所有路径
+//   - /addresses/*/txs   * 匹配一段路径（path.Match 语法）
+func (l *LoginJWTMiddlewareBuilder) IgnorePaths(paths ...string) *LoginJWTMiddlewareBuilder {
+	l.paths = append(l.paths, paths...)
 	return l
 }
 
 func (l *LoginJWTMiddlewareBuilder) Build() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		print("进入登录JWT中间件,当前路径：", ctx.Request.URL.Path)
+		// 前面的中间件（如 API 密钥）已经认证过
+		if _, ok := Claims(ctx); ok {
+			ctx.Next()
+			return
+		}
+		if l.ignored(ctx.Request.URL.Path) {
+			ctx.Next()
+			return
+		}
+
+		raw := bearerToken(ctx)
+		if raw == "" {
+			unauthorized(ctx, "missing bearer token")
+			return
+		}
+		claims, err := l.manager.Verify(raw, auth.TypeAccess)
+		if err != nil {
+			unauthorized(ctx, verifyMessage(err))
+			return
+		}
+		ctx.Set(ClaimsKey, claims)
+		ctx.Next()
+	}
+}
 
-		for _, p := range l.paths {
-			if ctx.Request.URL.Path == p {
-				ctx.Next()
-				return
-			}
+// ignored 路径是否匹配 IgnorePaths 中的某一项
+func (l *LoginJWTMiddlewareBuilder) ignored(p string) bool {
+	for _, pattern := range l.paths {
+		if matchPath(pattern, p) {
+			return true
 		}
+	}
+	return false
+}
 
-		ctx.JSON(401, gin.H{
-			"message": "搞事？",
-		})
+func matchPath(pattern, p string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return p == prefix || strings.HasPrefix(p, prefix+"/")
+	}
+	if strings.ContainsAny(pattern, "*?[") {
+		ok, err := path.Match(pattern, p)
+		return err == nil && ok
+	}
+	return p == pattern
+}
+
+// bearerToken 从 Authorization: Bearer xxx 读取令牌；
+// 浏览器的 WebSocket 不能设置请求头，订阅接口可以用 ?access_token= 传
+func bearerToken(ctx *gin.Context) string {
+	if h := ctx.GetHeader("Authorization"); h != "" {
+		scheme, token, ok := strings.Cut(h, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return ctx.Query("access_token")
+}
+
+// verifyMessage 对外只给出失败的类别，不回显令牌内容
+func verifyMessage(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "token expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return "token not valid yet"
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return "token audience mismatch"
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return "token issuer mismatch"
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return "invalid token signature"
+	case errors.Is(err, auth.ErrRevoked):
+		return "token revoked"
+	case errors.Is(err, auth.ErrTokenType):
+		return "not an access token"
+	}
+	return "invalid token"
+}
+
+func unauthorized(ctx *gin.Context, msg string) {
+	ctx.Header("WWW-Authenticate", `Bearer error="invalid_token", error_description="`+msg+`"`)
+	ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"msg": msg,
+	})
+}
 
+// Claims 取出中间件校验过的声明，免登录的路径上没有
+func Claims(ctx *gin.Context) (*auth.Claims, bool) {
+	v, ok := ctx.Get(ClaimsKey)
+	if !ok {
+		return nil, false
 	}
+	claims, ok := v.(*auth.Claims)
+	return claims, ok
 }
@@ -0,0 +1,127 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	hdwallet "github.com/miguelmota/go-ethereum-hdwallet"
+	"level2/gin-example/internal/mnemonic"
+)
+
+// defaultDerivationBase BIP-44 以太坊账户路径，最后一级是账户下标
+const defaultDerivationBase = "m/44'/60'/0'/0"
+
+// maxDeriveCount 一次最多派生的地址数
+const maxDeriveCount = 100
+
+// hardenedOffset 下标达到 2^31 就是 hardened 路径（等同于 i'），路径里的普通下标必须小于它
+const hardenedOffset = 1 << 31
+
+// GenerateMnemonic 生成 BIP-39 助记词；entropy 为空时使用 crypto/rand，响应里的 entropySource 说明熵的来源。
+// 助记词不会保存在服务端
+// POST /mnemonics/generate {"words": 24} 或 {"entropy": "0x..."}
+func (u *UserHandler) GenerateMnemonic(ctx *gin.Context) {
+	var req struct {
+		Words   int    `json:"words"`   // 12/15/18/21/24，默认 12
+		Entropy string `json:"entropy"` // 可选，0x 开头的 16-32 字节
+	}
+	if ctx.Request.ContentLength != 0 {
+		if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+			u.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+	var entropy []byte
+	if req.Entropy != "" {
+		var err error
+		if entropy, err = hexutil.Decode(req.Entropy); err != nil {
+			u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid entropy hex: %w", err))
+			return
+		}
+	}
+	generated, err := mnemonic.Generate(req.Words, entropy)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, generated)
+}
+
+// ValidateMnemonic 校验助记词，无效时给出出错单词的位置、候选单词或校验和错误
+// POST /mnemonics/validate {"mnemonic": "..."}
+func (u *UserHandler) ValidateMnemonic(ctx *gin.Context) {
+	var req struct {
+		Mnemonic string `json:"mnemonic"`
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	err := mnemonic.Validate(req.Mnemonic)
+	var verr *mnemonic.Error
+	if errors.As(err, &verr) {
+		ctx.JSON(http.StatusOK, gin.H{
+			"valid": false,
+			"msg":   verr.Error(),
+			"error": verr,
+		})
+		return
+	}
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"valid": true})
+}
+
+// DeriveMnemonic 按 BIP-44 路径派生地址（不返回私钥）；vault 是保险库里已解锁的助记词的名字，派生的账户记入它的审计
+// POST /mnemonics/derive {"vault": "ops", "start": 0, "count": 5}
+func (u *UserHandler) DeriveMnemonic(ctx *gin.Context) {
+	var req struct {
+		Vault string `json:"vault"`
+		Base  string `json:"base"` // 默认 m/44'/60'/0'/0
+		Start uint32 `json:"start"`
+		Count int    `json:"count"` // 默认 1
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if req.Base == "" {
+		req.Base = defaultDerivationBase
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.Count < 0 || req.Count > maxDeriveCount {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("count must be between 1 and %d", maxDeriveCount))
+		return
+	}
+	// 在 uint64 上比较，start 接近 2^32 时不会回绕
+	if uint64(req.Start)+uint64(req.Count) > hardenedOffset {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("start + count must not exceed %d (indices at or above 2^31 are hardened)", uint64(hardenedOffset)))
+		return
+	}
+	accounts := make([]gin.H, 0, req.Count)
+	for i := 0; i < req.Count; i++ {
+		path := fmt.Sprintf("%s/%d", req.Base, req.Start+uint32(i))
+		if _, err := hdwallet.ParseDerivationPath(path); err != nil {
+			u.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		account, err := u.vault.Derive(req.Vault, path)
+		if err != nil {
+			u.writeError(ctx, vaultErrorStatus(err), err)
+			return
+		}
+		accounts = append(accounts, gin.H{"path": path, "address": account.Address})
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"vault":    req.Vault,
+		"accounts": accounts,
+	})
+}
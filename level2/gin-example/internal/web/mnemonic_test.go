@@ -0,0 +1,91 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/registry"
+	"level2/gin-example/internal/vault"
+	"level2/network"
+)
+
+// testNetwork 处理函数测试用的网络，只用来查找注册表，不连接节点
+var testNetwork = &network.Network{Name: "test", ChainID: 1337}
+
+// newTestContext 已选好 testNetwork 的请求上下文，相当于经过了网络选择中间件
+func newTestContext(method, path, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(method, path, strings.NewReader(body))
+	ctx.Set(networkKey, &network.Pool{Network: testNetwork})
+	return ctx, w
+}
+
+func TestDeriveMnemonicRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	vault.ScryptN, vault.ScryptP = keystore.LightScryptN, keystore.LightScryptP
+	mnemonics, err := vault.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := strings.Repeat("abandon ", 11) + "about"
+	if _, err := mnemonics.Import("ops", "password123", words, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mnemonics.Unlock("ops", "password123", 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mnemonics.LockAll)
+	u := &UserHandler{vault: mnemonics, registries: map[string]*registry.Registry{testNetwork.Name: registry.New()}}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		paths  []string
+	}{
+		{"default", `{"vault": "ops"}`, http.StatusOK, []string{"m/44'/60'/0'/0/0"}},
+		{"last normal index", `{"vault": "ops", "start": 2147483646, "count": 2}`, http.StatusOK, []string{"m/44'/60'/0'/0/2147483646", "m/44'/60'/0'/0/2147483647"}},
+		{"range reaches 2^31", `{"vault": "ops", "start": 2147483647, "count": 2}`, http.StatusBadRequest, nil},
+		{"hardened start", `{"vault": "ops", "start": 2147483648}`, http.StatusBadRequest, nil},
+		{"start + count wraps uint32", `{"vault": "ops", "start": 4294967295, "count": 2}`, http.StatusBadRequest, nil},
+		{"start beyond uint32", `{"vault": "ops", "start": 4294967296}`, http.StatusBadRequest, nil},
+		{"max count", `{"vault": "ops", "count": 100}`, http.StatusOK, nil},
+		{"count too large", `{"vault": "ops", "count": 101}`, http.StatusBadRequest, nil},
+		{"negative count", `{"vault": "ops", "count": -1}`, http.StatusBadRequest, nil},
+		{"unknown vault", `{"vault": "missing"}`, http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, w := newTestContext(http.MethodPost, "/mnemonics/derive", tt.body)
+			u.DeriveMnemonic(ctx)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.paths == nil {
+				return
+			}
+			var resp struct {
+				Accounts []struct {
+					Path string `json:"path"`
+				} `json:"accounts"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Accounts) != len(tt.paths) {
+				t.Fatalf("accounts = %s, want %v", w.Body, tt.paths)
+			}
+			for i, a := range resp.Accounts {
+				if a.Path != tt.paths[i] {
+					t.Fatalf("accounts[%d].path = %s, want %s", i, a.Path, tt.paths[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package web
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/chaincache"
+	"level2/gin-example/internal/registry"
+	"level2/gin-example/internal/scanner"
+	"level2/network"
+)
+
+// networkKey gin 上下文中保存当前请求所选网络的键
+const networkKey = "network"
+
+// selectNetwork 按 ?network= 选择网络，不传时使用默认网络
+func (u *UserHandler) selectNetwork(ctx *gin.Context) {
+	pool, err := u.pools.Get(ctx.Query("network"))
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	ctx.Set(networkKey, pool)
+	ctx.Next()
+}
+
+// pool 当前请求所选的网络
+func (u *UserHandler) pool(ctx *gin.Context) *network.Pool {
+	if v, ok := ctx.Get(networkKey); ok {
+		return v.(*network.Pool)
+	}
+	return u.pools.Default()
+}
+
+// client 当前请求所选网络的客户端，已确认的区块、交易、收据和代码会被缓存
+func (u *UserHandler) client(ctx *gin.Context) *chaincache.Client {
+	return u.caches[u.pool(ctx).Network.Name]
+}
+
+// scanner 当前请求所选网络的地址扫描器
+func (u *UserHandler) scanner(ctx *gin.Context) *scanner.Scanner {
+	return u.scanners[u.pool(ctx).Network.Name]
+}
+
+// registry 当前请求所选网络的 ABI 注册表，部署绑定的地址只在对应网络上有效
+func (u *UserHandler) registry(ctx *gin.Context) *registry.Registry {
+	return u.registries[u.pool(ctx).Network.Name]
+}
+
+// networkInfo 对外展示的网络信息，不包含节点地址（里面可能有 API key）
+type networkInfo struct {
+	Name      string            `json:"name"`
+	ChainID   uint64            `json:"chainId"`
+	Explorer  *network.Explorer `json:"explorer,omitempty"`
+	Devnet    bool              `json:"devnet,omitempty"`
+	Default   bool              `json:"default"`
+	Websocket bool              `json:"websocket"`
+
+	Endpoints []network.EndpointStats `json:"endpoints"`
+}
+
+// ListNetworks 已连接的网络以及各节点的健康状况（延迟、错误率、落后区块数）
+// GET /networks
+func (u *UserHandler) ListNetworks(ctx *gin.Context) {
+	def := u.pools.Default().Network.Name
+	out := []networkInfo{}
+	for name, pool := range u.pools.All() {
+		n := pool.Network
+		out = append(out, networkInfo{
+			Name:      name,
+			ChainID:   n.ChainID,
+			Explorer:  n.Explorer,
+			Devnet:    n.Devnet,
+			Default:   name == def,
+			Websocket: n.WS != "",
+			Endpoints: pool.Endpoints(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	ctx.JSON(http.StatusOK, out)
+}
+
+// CacheStats 当前网络链上数据缓存的命中统计
+// GET /cache/stats?network=
+func (u *UserHandler) CacheStats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, u.client(ctx).Stats())
+}
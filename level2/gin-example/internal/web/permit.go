@@ -0,0 +1,331 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/account"
+	"level2/gin-example/internal/inspect"
+	"level2/gin-example/internal/permit"
+	"level2/gin-example/internal/revert"
+	"level2/gin-example/internal/signature"
+)
+
+// permitDeadline 请求没有给出 deadline 时 permit 的有效期
+const permitDeadline = time.Hour
+
+// permitReq 一次 permit 授权；owner 不传时用默认托管账户，value 为 "max" 时授权 2^256-1
+type permitReq struct {
+	Owner    *common.Address `json:"owner"`
+	Spender  *common.Address `json:"spender"`
+	Value    string          `json:"value"`    // 最小单位，十进制或 0x 十六进制
+	Deadline string          `json:"deadline"` // unix 秒，默认一小时后
+}
+
+// buildPermit 读取代币的域和 owner 当前的 nonce，组装 Permit
+func (u *UserHandler) buildPermit(ctx *gin.Context, token common.Address, req *permitReq, owner common.Address) (*permit.Info, *permit.Permit, int, error) {
+	if req.Spender == nil {
+		return nil, nil, http.StatusBadRequest, errors.New("spender is required")
+	}
+	var value *big.Int
+	if req.Value == "max" {
+		value = math.MaxBig256
+	} else {
+		var err error
+		if value, err = parseBigInt(req.Value); err != nil {
+			return nil, nil, http.StatusBadRequest, err
+		}
+		if value == nil || value.Sign() < 0 {
+			return nil, nil, http.StatusBadRequest, errors.New("value is required")
+		}
+	}
+	deadline, err := parseBigInt(req.Deadline)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, err
+	}
+	if deadline == nil {
+		deadline = big.NewInt(time.Now().Add(permitDeadline).Unix())
+	}
+	if deadline.Cmp(big.NewInt(time.Now().Unix())) < 0 {
+		return nil, nil, http.StatusBadRequest, fmt.Errorf("deadline %s has already passed", deadline)
+	}
+
+	chainID := new(big.Int).SetUint64(u.pool(ctx).Network.ChainID)
+	info, err := permit.Detect(ctx.Request.Context(), u.client(ctx), token, chainID, &owner)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, permit.ErrNoCode) {
+			status = http.StatusNotFound
+		}
+		return nil, nil, status, err
+	}
+	if err := info.Check(); err != nil {
+		return info, nil, http.StatusUnprocessableEntity, err
+	}
+	return info, &permit.Permit{
+		Owner:    owner,
+		Spender:  *req.Spender,
+		Value:    value,
+		Nonce:    info.Nonce,
+		Deadline: deadline,
+	}, 0, nil
+}
+
+// PermitInfo 检测代币是否支持 ERC-2612，返回 EIP-712 域和 owner 当前的 nonce
+// GET /tokens/:address/permit?owner=0x...
+func (u *UserHandler) PermitInfo(ctx *gin.Context) {
+	token, _, err := inspect.ParseAddress(ctx.Param("address"))
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	var owner *common.Address
+	if raw := ctx.Query("owner"); raw != "" {
+		addr, _, err := inspect.ParseAddress(raw)
+		if err != nil {
+			u.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		owner = &addr
+	}
+	chainID := new(big.Int).SetUint64(u.pool(ctx).Network.ChainID)
+	info, err := permit.Detect(ctx.Request.Context(), u.client(ctx), token, chainID, owner)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, permit.ErrNoCode) {
+			status = http.StatusNotFound
+		}
+		u.writeError(ctx, status, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, info)
+}
+
+// SignPermit 用 owner 的托管账户签 Permit，签名交给中继者（或 /relay）提交，owner 自己不用付 gas
+// POST /tokens/:address/permit/sign {"owner": "0x...", "spender": "0x...", "value": "1000000", "deadline": "1735689600"}
+func (u *UserHandler) SignPermit(ctx *gin.Context) {
+	token, _, err := inspect.ParseAddress(ctx.Param("address"))
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	var req permitReq
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	owner, err := u.accounts.Resolve(req.Owner)
+	if err != nil {
+		u.writeError(ctx, http.StatusForbidden, err)
+		return
+	}
+	info, p, status, err := u.buildPermit(ctx, token, &req, owner)
+	if err != nil {
+		body := gin.H{"msg": err.Error()}
+		if info != nil {
+			body["permit"] = info
+		}
+		ctx.AbortWithStatusJSON(status, body)
+		return
+	}
+	td, hash, err := permit.Hash(info, p)
+	if err != nil {
+		u.writeError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	sig, err := u.accounts.SignHash(owner, hash.Digest.Bytes())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, account.ErrNoAccount) {
+			status = http.StatusNotFound
+		}
+		u.writeError(ctx, status, err)
+		return
+	}
+	sig[64] += 27
+	ctx.JSON(http.StatusOK, gin.H{
+		"token":     token,
+		"permit":    p,
+		"typedData": td,
+		"hash":      hash,
+		"signature": hexutil.Encode(sig),
+		"r":         hexutil.Encode(sig[:32]),
+		"s":         hexutil.Encode(sig[32:64]),
+		"v":         sig[64],
+	})
+}
+
+// RelayPermit 中继账户代 owner 提交 permit 交易，gas 由中继账户支付。
+// 提交前在本地检查签名、nonce 和 deadline，避免发出一定会回滚的交易
+// POST /tokens/:address/permit/relay {"owner": "0x...", "spender": "0x...", "value": "1000000", "deadline": "1735689600", "signature": "0x...", "relayer": "0x...", "wait": true}
+func (u *UserHandler) RelayPermit(ctx *gin.Context) {
+	token, _, err := inspect.ParseAddress(ctx.Param("address"))
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	var req struct {
+		permitReq
+		Signature string          `json:"signature"`
+		Relayer   *common.Address `json:"relayer"` // 可选，默认第一个托管账户
+		GasLimit  uint64          `json:"gasLimit"`
+		Wait      bool            `json:"wait"` // 等待回执，并返回生效后的 allowance
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	// 签名来自外部，owner 不一定是托管账户，必须显式给出
+	if req.Owner == nil {
+		u.writeError(ctx, http.StatusBadRequest, errors.New("owner is required"))
+		return
+	}
+	if req.Deadline == "" {
+		u.writeError(ctx, http.StatusBadRequest, errors.New("deadline is required, it is part of the signed message"))
+		return
+	}
+	sig, err := hexutil.Decode(req.Signature)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid signature hex: %w", err))
+		return
+	}
+	info, p, status, err := u.buildPermit(ctx, token, &req.permitReq, *req.Owner)
+	if err != nil {
+		body := gin.H{"msg": err.Error()}
+		if info != nil {
+			body["permit"] = info
+		}
+		ctx.AbortWithStatusJSON(status, body)
+		return
+	}
+	_, hash, err := permit.Hash(info, p)
+	if err != nil {
+		u.writeError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	// permit 只认 ecrecover，合约钱包的 EIP-1271 签名在这里无效；nonce 用的是链上当前值，签名时的 nonce 已被使用也会在这里失败
+	recovered, err := signature.Recover(hash.Digest, sig)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if recovered != p.Owner {
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"msg":       fmt.Sprintf("%s: recovered %s with nonce %s", signature.ErrMismatch, recovered.Hex(), p.Nonce),
+			"permit":    p,
+			"recovered": recovered,
+		})
+		return
+	}
+	if signature.IsHighS(sig) {
+		u.writeError(ctx, http.StatusUnprocessableEntity, signature.ErrHighS)
+		return
+	}
+
+	opts, err := u.transactor(ctx, req.Relayer)
+	if err != nil {
+		u.writeError(ctx, http.StatusForbidden, err)
+		return
+	}
+	opts.GasLimit = req.GasLimit
+	tx, err := permit.Submit(opts, u.client(ctx), token, p, sig)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err, permit.ABI)
+		return
+	}
+	body := gin.H{
+		"token":   token,
+		"permit":  p,
+		"relayer": opts.From,
+		"txHash":  tx.Hash().Hex(),
+	}
+	if !req.Wait {
+		ctx.JSON(http.StatusOK, body)
+		return
+	}
+	receipt, err := bind.WaitMined(ctx.Request.Context(), u.client(ctx), tx)
+	if err != nil {
+		u.writeError(ctx, http.StatusGatewayTimeout, err)
+		return
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		u.writeReceiptRevert(ctx, tx, receipt, permit.ABI)
+		return
+	}
+	body["block"] = receipt.BlockNumber.String()
+	body["gasUsed"] = receipt.GasUsed
+	if allowance, err := permit.Allowance(ctx.Request.Context(), u.client(ctx), token, p.Owner, p.Spender); err == nil {
+		body["allowance"] = allowance
+	}
+	ctx.JSON(http.StatusOK, body)
+}
+
+// writeReceiptRevert 交易上链后回滚，重新模拟拿到回滚原因
+func (u *UserHandler) writeReceiptRevert(ctx *gin.Context, tx *types.Transaction, receipt *types.Receipt, abis ...abi.ABI) {
+	reason, err := u.revertDecoder(ctx, abis...).FromReceipt(ctx.Request.Context(), u.client(ctx), tx, receipt)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	u.writeError(ctx, http.StatusConflict, &revert.Error{TxHash: tx.Hash().Hex(), Reason: reason})
+}
+
+// DeployPermitToken 在本地开发链上部署支持 permit 的测试代币，全部供应量归部署账户，并登记到合约注册表
+// POST /tokens/permit/test-token {"from": "0x..."}
+func (u *UserHandler) DeployPermitToken(ctx *gin.Context) {
+	n := u.pool(ctx).Network
+	if !n.Devnet {
+		u.writeError(ctx, http.StatusForbidden, fmt.Errorf("permit test token can only be deployed on devnet networks, %s is not one", n.Name))
+		return
+	}
+	var req struct {
+		From *common.Address `json:"from"` // 可选，默认第一个托管账户
+	}
+	if ctx.Request.ContentLength != 0 {
+		if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+			u.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+	opts, err := u.transactor(ctx, req.From)
+	if err != nil {
+		u.writeError(ctx, http.StatusForbidden, err)
+		return
+	}
+	result, err := permit.DeployTestToken(ctx.Request.Context(), opts, u.client(ctx))
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	if _, err := u.registry(ctx).ByName(permit.TestTokenContract); err != nil {
+		if _, err := u.registry(ctx).Register(permit.TestTokenContract, []byte(permit.TestTokenABI), permit.TestTokenSource); err != nil {
+			u.writeError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	entry, err := u.registry(ctx).Bind(permit.TestTokenContract, result.Address)
+	if err != nil {
+		u.writeError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"deployment": result,
+		"registry":   entry,
+		"name":       permit.TestTokenName,
+		"symbol":     permit.TestTokenSymbol,
+		"decimals":   permit.TestTokenDecimals,
+		"supply":     permit.TestTokenSupply,
+		"holder":     opts.From,
+	})
+}
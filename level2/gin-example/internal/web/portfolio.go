@@ -0,0 +1,139 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/inspect"
+	"level2/gin-example/internal/multicall"
+	"level2/gin-example/internal/portfolio"
+	"level2/network"
+)
+
+// Portfolio 地址的 ETH 和代币余额，代币列表来自网络配置的 tokens，也可以用 ?tokens= 指定。
+// 传 from（以及可选的 to、step）时返回每 step 个区块一次的时间序列
+// GET /addresses/:addr/portfolio?block=&tokens=0x..,0x..
+// GET /addresses/:addr/portfolio?from=7000000&to=7100000&step=10000
+func (u *UserHandler) Portfolio(ctx *gin.Context) {
+	address, _, err := inspect.ParseAddress(ctx.Param("addr"))
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	tokens := u.pool(ctx).Network.Tokens
+	if raw := ctx.Query("tokens"); raw != "" {
+		addrs, err := parseAddresses(strings.Split(raw, ","))
+		if err != nil {
+			u.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		tokens = make([]network.Token, len(addrs))
+		for i, addr := range addrs {
+			tokens[i] = network.Token{Address: addr}
+		}
+	}
+	// 只查 ETH 时不需要 Multicall3
+	var agg *multicall.Aggregator
+	if len(tokens) > 0 {
+		if agg, err = u.multicall(ctx); err != nil {
+			u.writeError(ctx, multicallErrorStatus(err), err)
+			return
+		}
+	}
+	reader, err := portfolio.New(ctx.Request.Context(), u.client(ctx), agg, tokens)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+
+	if ctx.Query("from") != "" {
+		u.portfolioSeries(ctx, reader, address)
+		return
+	}
+	block, err := parseBigInt(ctx.Query("block"))
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	snap, err := reader.Snapshot(ctx.Request.Context(), address, block)
+	var archiveErr *portfolio.ArchiveError
+	if errors.As(err, &archiveErr) {
+		ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
+			"msg":             err.Error(),
+			"block":           archiveErr.Block,
+			"archiveRequired": true,
+		})
+		return
+	}
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"address":   address.Hex(),
+		"portfolio": snap,
+	})
+}
+
+// portfolioSeries 时间序列模式，to 默认（最多）到最新区块，step 默认把范围分成 10 段
+func (u *UserHandler) portfolioSeries(ctx *gin.Context, reader *portfolio.Reader, address common.Address) {
+	from, err := strconv.ParseUint(ctx.Query("from"), 10, 64)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid from: %w", err))
+		return
+	}
+	to, err := strconv.ParseUint(ctx.DefaultQuery("to", "0"), 10, 64)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid to: %w", err))
+		return
+	}
+	// to 不能超过最新区块，没有指定时就是最新区块
+	head, err := u.client(ctx).BlockNumber(ctx.Request.Context())
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	if to == 0 || to > head {
+		to = head
+	}
+	if from > to {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("from %d is after to %d", from, to))
+		return
+	}
+	step, err := strconv.ParseUint(ctx.DefaultQuery("step", "0"), 10, 64)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid step: %w", err))
+		return
+	}
+	if step == 0 {
+		step = (to - from + 9) / 10
+		if step == 0 {
+			step = 1
+		}
+	}
+
+	snaps, err := reader.Series(ctx.Request.Context(), address, from, to, step)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	archiveRequired := false
+	for _, s := range snaps {
+		if s.Archive {
+			archiveRequired = true
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"address":         address.Hex(),
+		"from":            from,
+		"to":              to,
+		"step":            step,
+		"archiveRequired": archiveRequired, // 有采样点因为节点不是归档节点而失败
+		"series":          snaps,
+	})
+}
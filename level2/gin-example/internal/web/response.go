@@ -0,0 +1,23 @@
+package web
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/revert"
+)
+
+// writeError 统一的错误返回格式 {"msg": "..."}，如果错误来自合约回滚，附带解码后的原因 "revert"
+func (u *UserHandler) writeError(ctx *gin.Context, status int, err error, abis ...abi.ABI) {
+	body := gin.H{
+		"msg": err.Error(),
+	}
+	if reason, ok := u.revertDecoder(ctx, abis...).DecodeError(err); ok {
+		body["revert"] = reason
+	}
+	ctx.AbortWithStatusJSON(status, body)
+}
+
+// revertDecoder 使用当前网络注册表里的所有 ABI 以及调用方额外给出的 ABI（优先）解码自定义错误
+func (u *UserHandler) revertDecoder(ctx *gin.Context, abis ...abi.ABI) *revert.Decoder {
+	return revert.NewDecoder(append(u.registry(ctx).ABIs(), abis...)...)
+}
@@ -0,0 +1,310 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/account"
+	"level2/gin-example/internal/auth"
+	"level2/gin-example/internal/signature"
+)
+
+// messageReq 待签名或待校验的消息；encoding 为 hex 时 message 是 0x 开头的原始字节，默认按 UTF-8 文本处理
+type messageReq struct {
+	Message  string `json:"message"`
+	Encoding string `json:"encoding"`
+}
+
+func (r *messageReq) bytes() ([]byte, error) {
+	switch r.Encoding {
+	case "", "text", "utf8":
+		return []byte(r.Message), nil
+	case "hex":
+		b, err := hexutil.Decode(r.Message)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex message: %w", err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unknown encoding %q, want text or hex", r.Encoding)
+}
+
+// SignMessage 用托管账户做 EIP-191 personal_sign，返回的签名 v 为 27/28（与钱包一致）
+// POST /signatures/sign {"from": "0x...", "message": "hello", "encoding": "text"}
+func (u *UserHandler) SignMessage(ctx *gin.Context) {
+	var req struct {
+		messageReq
+		From *common.Address `json:"from"` // 可选，默认第一个托管账户
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	msg, err := req.bytes()
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	from, err := u.accounts.Resolve(req.From)
+	if err != nil {
+		u.writeError(ctx, http.StatusForbidden, err)
+		return
+	}
+	hash := signature.PersonalHash(msg)
+	sig, err := u.accounts.SignHash(from, hash.Bytes())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, account.ErrNoAccount) {
+			status = http.StatusNotFound
+		}
+		u.writeError(ctx, status, err)
+		return
+	}
+	// keystore 签出的 s 总是低位，v 为 0/1
+	sig[64] += 27
+	ctx.JSON(http.StatusOK, gin.H{
+		"address":   from,
+		"hash":      hash,
+		"signature": hexutil.Encode(sig),
+		"r":         hexutil.Encode(sig[:32]),
+		"s":         hexutil.Encode(sig[32:64]),
+		"v":         sig[64],
+	})
+}
+
+// VerifyMessage 校验 EIP-191 签名：ECDSA 恢复签名者（v 接受 0/1 和 27/28），不匹配时对合约地址调用 EIP-1271。
+// s 偏高的可延展签名默认判为无效，allowHighS 为 true 时接受并给出对应的低 s 签名
+// POST /signatures/verify {"address": "0x...", "message": "hello", "signature": "0x..."}
+func (u *UserHandler) VerifyMessage(ctx *gin.Context) {
+	var req struct {
+		messageReq
+		Address    string `json:"address"`
+		Signature  string `json:"signature"`
+		AllowHighS bool   `json:"allowHighS"`
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if !common.IsHexAddress(req.Address) {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid address %q", req.Address))
+		return
+	}
+	address := common.HexToAddress(req.Address)
+	msg, err := req.bytes()
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	sig, err := hexutil.Decode(req.Signature)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid signature hex: %w", err))
+		return
+	}
+
+	result, err := signature.Verify(ctx.Request.Context(), u.client(ctx), address, signature.PersonalHash(msg), sig)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	if result.HighS && req.AllowHighS && result.Recovered != nil && *result.Recovered == address {
+		result.Valid = true
+		result.Method = signature.MethodECDSA
+		result.Reason = ""
+	}
+
+	body := gin.H{
+		"address": address,
+		"result":  result,
+		"verdict": verdict(result),
+	}
+	if len(sig) == crypto.SignatureLength {
+		body["v"] = sig[64]
+		if normalized, err := signature.NormalizeV(sig); err == nil {
+			body["normalized"] = hexutil.Encode(normalized)
+		} else {
+			body["vError"] = err.Error()
+		}
+		if result.HighS {
+			if canonical, err := signature.LowS(sig); err == nil {
+				body["canonical"] = hexutil.Encode(canonical)
+			}
+		}
+	} else if result.Method != signature.MethodEIP1271 {
+		body["lengthError"] = signature.ErrLength.Error()
+	}
+	ctx.JSON(http.StatusOK, body)
+}
+
+// verdict 一句话结论
+func verdict(r *signature.Result) string {
+	switch {
+	case r.Valid && r.HighS:
+		return "valid (malleable high-s signature accepted)"
+	case r.Valid && r.Method == signature.MethodEIP1271:
+		return "valid (contract signer approved via EIP-1271)"
+	case r.Valid:
+		return "valid"
+	case r.Reason == signature.ErrHighS.Error():
+		return "rejected: malleable high-s signature, use the canonical low-s form"
+	}
+	return "invalid: " + r.Reason
+}
+
+// typedDataReq typedData 可以是 JSON 对象，也可以是钱包 eth_signTypedData_v4 用的 JSON 字符串
+type typedDataReq struct {
+	TypedData json.RawMessage `json:"typedData"`
+	Debug     bool            `json:"debug"` // 返回每一层的 encodeType、typeHash、encodedData 和 hash
+}
+
+func (r *typedDataReq) parse() (*apitypes.TypedData, error) {
+	raw := r.TypedData
+	if len(raw) == 0 {
+		return nil, errors.New("typedData is required")
+	}
+	if raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		raw = []byte(s)
+	}
+	return signature.ParseTypedData(raw)
+}
+
+// checkTypedChainID domain 里写了 chainId 时必须和当前网络一致
+func (u *UserHandler) checkTypedChainID(ctx *gin.Context, td *apitypes.TypedData) error {
+	if td.Domain.ChainId == nil {
+		return nil
+	}
+	want := u.pool(ctx).Network.ChainID
+	if got := (*big.Int)(td.Domain.ChainId); !got.IsUint64() || got.Uint64() != want {
+		return fmt.Errorf("domain chainId %s does not match network %s (chain id %d)", got, u.pool(ctx).Network.Name, want)
+	}
+	return nil
+}
+
+// SignTypedData 计算 EIP-712 摘要并用托管账户签名，v 为 27/28
+// POST /signatures/typed/sign {"from": "0x...", "typedData": {...}, "debug": true}
+func (u *UserHandler) SignTypedData(ctx *gin.Context) {
+	var req struct {
+		typedDataReq
+		From *common.Address `json:"from"` // 可选，默认第一个托管账户
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	td, err := req.parse()
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	// 为别的链签名多半是参数写错了，签出的签名在这条链上也用不了
+	if err := u.checkTypedChainID(ctx, td); err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	hash, err := signature.HashTypedData(td, req.Debug)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	from, err := u.accounts.Resolve(req.From)
+	if err != nil {
+		u.writeError(ctx, http.StatusForbidden, err)
+		return
+	}
+	// Permit、SafeTx、ForwardRequest、Seaport 订单等很多类型的消息签出来就能转走资金，按类型名没法可靠区分，
+	// 所以托管账户签任何 typedData 都和转账一样需要 PermTransfer，范围是 domain.verifyingContract
+	var contract *common.Address
+	if td.Domain.VerifyingContract != "" {
+		addr := common.HexToAddress(td.Domain.VerifyingContract)
+		contract = &addr
+	}
+	if !u.authz.Authorize(ctx, auth.Request{Permission: auth.PermTransfer, Account: &from, Contract: contract}) {
+		return
+	}
+	sig, err := u.accounts.SignHash(from, hash.Digest.Bytes())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, account.ErrNoAccount) {
+			status = http.StatusNotFound
+		}
+		u.writeError(ctx, status, err)
+		return
+	}
+	sig[64] += 27
+	ctx.JSON(http.StatusOK, gin.H{
+		"address":   from,
+		"signature": hexutil.Encode(sig),
+		"r":         hexutil.Encode(sig[:32]),
+		"s":         hexutil.Encode(sig[32:64]),
+		"v":         sig[64],
+		"hash":      hash,
+	})
+}
+
+// VerifyTypedData 校验 EIP-712 签名是否由 address 签出，合约地址走 EIP-1271；规则与 VerifyMessage 相同
+// POST /signatures/typed/verify {"address": "0x...", "typedData": {...}, "signature": "0x...", "debug": true}
+func (u *UserHandler) VerifyTypedData(ctx *gin.Context) {
+	var req struct {
+		typedDataReq
+		Address    string `json:"address"`
+		Signature  string `json:"signature"`
+		AllowHighS bool   `json:"allowHighS"`
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if !common.IsHexAddress(req.Address) {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid address %q", req.Address))
+		return
+	}
+	address := common.HexToAddress(req.Address)
+	td, err := req.parse()
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	sig, err := hexutil.Decode(req.Signature)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid signature hex: %w", err))
+		return
+	}
+	hash, err := signature.HashTypedData(td, req.Debug)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	result, err := signature.Verify(ctx.Request.Context(), u.client(ctx), address, hash.Digest, sig)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	if result.HighS && req.AllowHighS && result.Recovered != nil && *result.Recovered == address {
+		result.Valid = true
+		result.Method = signature.MethodECDSA
+		result.Reason = ""
+	}
+	body := gin.H{
+		"address": address,
+		"result":  result,
+		"verdict": verdict(result),
+		"hash":    hash,
+	}
+	// 校验时只提示，签名可能本来就是给别的链用的
+	if err := u.checkTypedChainID(ctx, td); err != nil {
+		body["warning"] = err.Error()
+	}
+	ctx.JSON(http.StatusOK, body)
+}
@@ -0,0 +1,106 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/account"
+	"level2/gin-example/internal/auth"
+	"level2/gin-example/internal/registry"
+	"level2/gin-example/internal/web/middleware"
+)
+
+// safeTx Gnosis Safe 的交易消息：不是 Permit，但签出来就能从 Safe 转走资金
+const safeTx = `{
+	"types": {
+		"EIP712Domain": [{"name": "verifyingContract", "type": "address"}],
+		"SafeTx": [
+			{"name": "to", "type": "address"},
+			{"name": "value", "type": "uint256"},
+			{"name": "data", "type": "bytes"},
+			{"name": "operation", "type": "uint8"},
+			{"name": "safeTxGas", "type": "uint256"},
+			{"name": "baseGas", "type": "uint256"},
+			{"name": "gasPrice", "type": "uint256"},
+			{"name": "gasToken", "type": "address"},
+			{"name": "refundReceiver", "type": "address"},
+			{"name": "nonce", "type": "uint256"}
+		]
+	},
+	"primaryType": "SafeTx",
+	"domain": {"verifyingContract": "0x00000000000000000000000000000000000005af"},
+	"message": {
+		"to": "0x00000000000000000000000000000000000000b0",
+		"value": "1000000000000000000",
+		"data": "0x",
+		"operation": 0,
+		"safeTxGas": 0,
+		"baseGas": 0,
+		"gasPrice": 0,
+		"gasToken": "0x0000000000000000000000000000000000000000",
+		"refundReceiver": "0x0000000000000000000000000000000000000000",
+		"nonce": 7
+	}
+}`
+
+// newSigningHandler 只带一个已解锁托管账户和默认角色策略的 UserHandler，够 SignTypedData 用
+func newSigningHandler(t *testing.T) (*UserHandler, common.Address) {
+	t.Helper()
+	dir := t.TempDir()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc, err := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP).ImportECDSA(key, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts := account.NewManager(dir)
+	if errs := accounts.UnlockAll("test"); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	return &UserHandler{
+		accounts:   accounts,
+		authz:      middleware.NewAuthorizer(auth.DefaultPolicy()),
+		registries: map[string]*registry.Registry{testNetwork.Name: registry.New()},
+	}, acc.Address
+}
+
+func TestSignTypedDataRequiresTransfer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	u, from := newSigningHandler(t)
+	body, _ := json.Marshal(map[string]interface{}{"from": from, "typedData": json.RawMessage(safeTx)})
+
+	tests := []struct {
+		name   string
+		roles  []string
+		status int
+	}{
+		// operator 有 tx:sign 没有 funds:transfer，以前只有 Permit 开头的类型才会检查
+		{"sign only", []string{auth.RoleOperator}, http.StatusForbidden},
+		{"sign and transfer", []string{auth.RoleOperator, auth.RoleTreasurer}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, w := newTestContext(http.MethodPost, "/signatures/typed/sign", string(body))
+			ctx.Set(middleware.ClaimsKey, &auth.Claims{UserID: "test", Roles: tt.roles})
+			u.SignTypedData(ctx)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status == http.StatusForbidden && !strings.Contains(w.Body.String(), string(auth.PermTransfer)) {
+				t.Fatalf("body = %s, want a %s denial", w.Body, auth.PermTransfer)
+			}
+		})
+	}
+	denials := u.authz.Denials(0)
+	if len(denials) != 1 || denials[0].Contract == nil || *denials[0].Contract != common.HexToAddress("0x5af") {
+		t.Fatalf("denials = %+v, want one scoped to the verifying contract", denials)
+	}
+}
@@ -0,0 +1,195 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/inspect"
+	"level2/gin-example/internal/multicall"
+)
+
+// maxTokenCalls 单次请求最多打包的合约调用数（代币数 × 持有人数，或代币数 × 4）
+const maxTokenCalls = 10000
+
+// tokensReq 批量读取代币的请求体
+type tokensReq struct {
+	Tokens    []string `json:"tokens"`
+	Holders   []string `json:"holders"`   // 只有 /tokens/balances 使用
+	Block     string   `json:"block"`     // 区块号，默认 latest
+	ChunkSize int      `json:"chunkSize"` // 每个 aggregate3 的调用数，默认 500
+}
+
+// TokenBalances 用 Multicall3 一次读取多个代币上多个持有人的余额
+// POST /tokens/balances
+func (u *UserHandler) TokenBalances(ctx *gin.Context) {
+	req, tokens, opts, err := u.parseTokensReq(ctx)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	holders, err := parseAddresses(req.Holders)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if len(holders) == 0 || len(tokens)*len(holders) > maxTokenCalls {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("tokens × holders must be 1-%d", maxTokenCalls))
+		return
+	}
+	var balances []*multicall.Balance
+	var chunks int
+	agg, err := u.withMulticall(ctx, func(agg *multicall.Aggregator) (err error) {
+		balances, chunks, err = agg.Balances(ctx.Request.Context(), tokens, holders, opts)
+		return err
+	})
+	if err != nil {
+		u.writeError(ctx, multicallErrorStatus(err), err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"multicall": agg.Address(),
+		"calls":     len(balances),
+		"ethCalls":  chunks,
+		"balances":  balances,
+	})
+}
+
+// TokenMetadata 用 Multicall3 一次读取多个代币的 name/symbol/decimals/totalSupply
+// POST /tokens/metadata
+func (u *UserHandler) TokenMetadata(ctx *gin.Context) {
+	_, tokens, opts, err := u.parseTokensReq(ctx)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if len(tokens)*4 > maxTokenCalls {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("at most %d tokens", maxTokenCalls/4))
+		return
+	}
+	var infos []*multicall.TokenInfo
+	var chunks int
+	agg, err := u.withMulticall(ctx, func(agg *multicall.Aggregator) (err error) {
+		infos, chunks, err = agg.TokenMetadata(ctx.Request.Context(), tokens, opts)
+		return err
+	})
+	if err != nil {
+		u.writeError(ctx, multicallErrorStatus(err), err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"multicall": agg.Address(),
+		"ethCalls":  chunks,
+		"tokens":    infos,
+	})
+}
+
+// parseTokensReq 解析请求体中的代币地址和区块号
+func (u *UserHandler) parseTokensReq(ctx *gin.Context) (*tokensReq, []common.Address, multicall.Options, error) {
+	var req tokensReq
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		return nil, nil, multicall.Options{}, err
+	}
+	tokens, err := parseAddresses(req.Tokens)
+	if err != nil {
+		return nil, nil, multicall.Options{}, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil, multicall.Options{}, errors.New("tokens is required")
+	}
+	block, err := parseBigInt(req.Block)
+	if err != nil {
+		return nil, nil, multicall.Options{}, err
+	}
+	return &req, tokens, multicall.Options{Block: block, ChunkSize: req.ChunkSize}, nil
+}
+
+// parseAddresses 校验一组地址，错误信息带上下标
+func parseAddresses(raw []string) ([]common.Address, error) {
+	addrs := make([]common.Address, 0, len(raw))
+	for i, s := range raw {
+		addr, _, err := inspect.ParseAddress(s)
+		if err != nil {
+			return nil, fmt.Errorf("address %d: %w", i, err)
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// withMulticall 用当前网络上的 Multicall3 执行 fn。缓存的地址上没有代码了（开发链重启过）时，
+// 清掉缓存重新查找（开发链上重新部署）后再执行一次
+func (u *UserHandler) withMulticall(ctx *gin.Context, fn func(agg *multicall.Aggregator) error) (*multicall.Aggregator, error) {
+	agg, err := u.multicall(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = fn(agg)
+	if !errors.Is(err, multicall.ErrNotDeployed) {
+		return agg, err
+	}
+	u.mu.Lock()
+	delete(u.multicalls, u.pool(ctx).Network.Name)
+	u.mu.Unlock()
+	if agg, err = u.multicall(ctx); err != nil {
+		return nil, err
+	}
+	return agg, fn(agg)
+}
+
+// multicall 当前网络上的 Multicall3；本地开发链上没有时用默认托管账户部署一份。
+// 找到的地址按网络缓存；查找和部署不持有锁，同一网络上并发的请求共用一次查找，避免重复部署
+func (u *UserHandler) multicall(ctx *gin.Context) (*multicall.Aggregator, error) {
+	n := u.pool(ctx).Network
+	u.mu.Lock()
+	addr, ok := u.multicalls[n.Name]
+	u.mu.Unlock()
+	if !ok {
+		v, err, _ := u.multicallLookups.Do(n.Name, func() (interface{}, error) {
+			addr, err := u.resolveMulticall(ctx)
+			if err != nil {
+				return nil, err
+			}
+			u.mu.Lock()
+			u.multicalls[n.Name] = addr
+			u.mu.Unlock()
+			return addr, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		addr = v.(common.Address)
+	}
+	return multicall.New(addr, u.client(ctx), u.registry(ctx).ABIs()...)
+}
+
+// resolveMulticall 在链上查找 Multicall3，开发链上找不到时部署
+func (u *UserHandler) resolveMulticall(ctx *gin.Context) (common.Address, error) {
+	n := u.pool(ctx).Network
+	addr, err := multicall.Resolve(ctx.Request.Context(), u.client(ctx))
+	if !errors.Is(err, multicall.ErrNotDeployed) || !n.Devnet {
+		return addr, err
+	}
+	opts, err := u.transactor(ctx, nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	addr, err = multicall.Ensure(ctx.Request.Context(), opts, u.client(ctx))
+	if err != nil {
+		return common.Address{}, err
+	}
+	log.Printf("deployed multicall3 on %s at %s", n.Name, addr.Hex())
+	return addr, nil
+}
+
+// multicallErrorStatus 网络上没有 Multicall3 时返回 501，其它错误来自节点
+func multicallErrorStatus(err error) int {
+	if errors.Is(err, multicall.ErrNotDeployed) {
+		return http.StatusNotImplemented
+	}
+	return http.StatusBadGateway
+}
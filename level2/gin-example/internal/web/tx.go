@@ -0,0 +1,80 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/contract"
+	"level2/gin-example/internal/explorer"
+)
+
+var txHashRe = regexp.MustCompile("^0x[0-9a-fA-F]{64}$")
+
+// GetTransaction 交易详情：交易字段、是否 pending、恢复出来的发送者、收据（状态、gasUsed、实际 gas 价格），
+// 目标合约的 ABI 已知时解码 calldata，日志用注册表里的 ABI 解码
+// GET /txs/:hash
+func (u *UserHandler) GetTransaction(ctx *gin.Context) {
+	u.txJSON(ctx, ctx.Param("hash"))
+}
+
+func (u *UserHandler) txJSON(ctx *gin.Context, raw string) {
+	if !txHashRe.MatchString(raw) {
+		u.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid transaction hash %q", raw))
+		return
+	}
+	hash := common.HexToHash(raw)
+	c := ctx.Request.Context()
+
+	tx, pending, err := u.client(ctx).TransactionByHash(c, hash)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, ethereum.NotFound) {
+			status = http.StatusNotFound
+		}
+		u.writeError(ctx, status, err)
+		return
+	}
+	chainID, err := u.client(ctx).ChainID(c)
+	if err != nil {
+		u.writeError(ctx, http.StatusBadGateway, err)
+		return
+	}
+
+	detail := &explorer.TxDetail{TxView: explorer.NewTxView(tx, explorer.Signer(chainID)), Pending: pending}
+	if to := tx.To(); to != nil {
+		if entry, err := u.registry(ctx).ByAddress(*to); err == nil {
+			detail.Contract = entry.Name
+			detail.Call = contract.DecodeCalldata(entry.ABI, tx.Data())
+		}
+	}
+	if !pending {
+		receipt, err := u.client(ctx).TransactionReceipt(c, hash)
+		if err != nil {
+			u.writeError(ctx, http.StatusBadGateway, err)
+			return
+		}
+		detail.Receipt = explorer.NewReceiptView(receipt, u.logDecoder(ctx))
+		idx := receipt.TransactionIndex
+		detail.Index = &idx
+	}
+	ctx.JSON(http.StatusOK, detail)
+}
+
+// logDecoder 先用日志地址绑定的 ABI，再尝试当前网络注册表里的其它 ABI（如 ERC-20 的 Transfer）
+func (u *UserHandler) logDecoder(ctx *gin.Context) func(*types.Log) *contract.DecodedEvent {
+	reg := u.registry(ctx)
+	return func(log *types.Log) *contract.DecodedEvent {
+		var abis []abi.ABI
+		if entry, err := reg.ByAddress(log.Address); err == nil {
+			abis = append(abis, entry.ABI)
+		}
+		return contract.DecodeLog(log, append(abis, reg.ABIs()...)...)
+	}
+}
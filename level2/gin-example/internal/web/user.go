@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/ethereum/go-ethereum"
@@ -14,87 +13,235 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/gin-gonic/gin"
-	hdwallet "github.com/miguelmota/go-ethereum-hdwallet"
 	"golang.org/x/crypto/sha3"
+	"golang.org/x/sync/singleflight"
+	"level2/gin-example/internal/account"
+	"level2/gin-example/internal/auth"
+	"level2/gin-example/internal/chaincache"
+	"level2/gin-example/internal/registry"
+	"level2/gin-example/internal/scanner"
+	"level2/gin-example/internal/vault"
+	"level2/gin-example/internal/web/middleware"
+	"level2/network"
 	pkgStore "level2/pkg"
 	"log"
 	"math/big"
 	"net/http"
-	"regexp"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 )
 
+const (
+	// 默认的 keystore 目录和 ABI 目录，路径相对于 level2（服务从这里启动）
+	walletsDir   = "./wallets"
+	artifactsDir = "../task2/artifacts"
+	// 地址交易记录扫描结果，每个网络一个文件
+	historyFile = "./data/history-%s.json"
+	// 链上数据缓存的内存条目数；设置环境变量 CHAIN_CACHE_DIR 后会同时缓存到磁盘（按网络分子目录）
+	chainCacheSize = 4096
+	// 已部署的 Store 合约地址，见 LoadContract
+	storeAddress = "0x135765bEC9A17B12841389a727092552598ed6D5"
+	storeNetwork = "sepolia"
+	// 示例接口（transferETH、contractDeploy 等）里写死的私钥对应的账户，权限范围按它检查
+	demoSigner = "0xE8b8990f266299545f0a8bA03db8D7D5609c818F"
+	// TokenTransfer 接口里写死的代币合约
+	demoToken = "0xfD2da79adb9109fe8fe66b5270cf2e68b59e6237"
+)
+
+// artifactDirs 部署时查找编译产物的目录
+var artifactDirs = []string{artifactsDir, "./pkg"}
+
 type UserHandler struct {
-	ethClient *ethclient.Client
+	pools      *network.Pools
+	registries map[string]*registry.Registry // 按网络名
+	accounts   *account.Manager
+	authz      *middleware.Authorizer
+	vault      *vault.Vault                  // 命名助记词，HD 钱包接口按名字引用
+	caches     map[string]*chaincache.Client // 按网络名
+	scanners   map[string]*scanner.Scanner   // 按网络名
+
+	mu               sync.Mutex
+	multicalls       map[string]common.Address // 按网络名缓存已找到的 Multicall3 地址
+	multicallLookups singleflight.Group        // 按网络名合并并发的查找和部署
 }
 
-// NewUserHandler 函数，连接到以太坊客户端并返回 UserHandler
-func NewUserHandler(infuraURL string) (*UserHandler, error) {
-	client, err := ethclient.Dial(infuraURL)
-	if err != nil {
-		return nil, err // 返回错误而不是 panic，方便外部处理
+// NewUserHandler 使用已连接的各网络客户端创建 UserHandler，请求通过 ?network= 选择网络；
+// authz 检查每个路由在 RegisterRoutes 里声明的权限，mnemonics 是 HD 钱包接口使用的助记词保险库
+func NewUserHandler(pools *network.Pools, authz *middleware.Authorizer, mnemonics *vault.Vault) (*UserHandler, error) {
+	accounts := account.NewManager(walletsDir)
+	// keystore 账户的密码从环境变量读取，不写在代码里
+	if pass := os.Getenv("WALLET_PASSWORD"); pass != "" {
+		for _, err := range accounts.UnlockAll(pass) {
+			log.Println(err)
+		}
 	}
-	return &UserHandler{ethClient: client}, nil
-}
+	registries := make(map[string]*registry.Registry)
+	caches := make(map[string]*chaincache.Client)
+	scanners := make(map[string]*scanner.Scanner)
+	for name, pool := range pools.All() {
+		reg, err := newDefaultRegistry(name)
+		if err != nil {
+			return nil, err
+		}
+		registries[name] = reg
 
-func (u *UserHandler) RegisterRoutes(server *gin.Engine) {
-	ug := server.Group("/users")
-	ug.GET("/index", u.Index)
-	ug.GET("/wallet", u.Wallet)
-	ug.GET("/transaction", u.Transaction)
-	ug.GET("/checkAddress", u.CheckAddress)
-	ug.GET("/checkBlock", u.CheckBlock)
-	ug.GET("/checkTransactions", u.CheckTransactions)
-	ug.GET("/transferETH", u.TransferETH)
-	ug.GET("/tokenTransfer", u.TokenTransfer)
-	ug.GET("/subscribe", u.Subscribe)
-	ug.GET("/transactionRawCreate", u.TransactionRawCreate)
-	ug.GET("/transactionRawSendreate", u.TransactionRawSendreate)
-	ug.GET("/contractDeploy", u.ContractDeploy)
-	ug.GET("/loadContract", u.LoadContract)
-	ug.GET("/writeContract", u.WriteContract)
-	ug.GET("/readContract", u.ReadContract)
-	ug.GET("/subLog", u.SubLog)
+		opts := chaincache.Options{Size: chainCacheSize, FinalityDepth: pool.Network.FinalityDepth, Devnet: pool.Network.Devnet}
+		if dir := os.Getenv("CHAIN_CACHE_DIR"); dir != "" {
+			opts.Dir = filepath.Join(dir, name)
+		}
+		caches[name] = chaincache.New(pool.Client(), opts)
+
+		store, err := scanner.OpenStore(fmt.Sprintf(historyFile, name))
+		if err != nil {
+			return nil, err
+		}
+		scanners[name] = scanner.New(caches[name], store)
+	}
+	return &UserHandler{
+		pools:      pools,
+		registries: registries,
+		accounts:   accounts,
+		authz:      authz,
+		vault:      mnemonics,
+		caches:     caches,
+		scanners:   scanners,
+		multicalls: make(map[string]common.Address),
+	}, nil
 }
 
-// 连接到 Infura 通过构造函数初始化的客户端
-func (u *UserHandler) connectToInfura() (*ethclient.Client, error) {
-	if u.ethClient != nil {
-		return u.ethClient, nil
+// newDefaultRegistry 加载 level2、pkg 和 task2/artifacts 下的 ABI；Store 合约只部署在 storeNetwork 上，只在那个网络登记地址
+func newDefaultRegistry(networkName string) (*registry.Registry, error) {
+	reg := registry.New()
+	for _, dir := range []string{".", "./pkg", artifactsDir} {
+		if err := reg.LoadDir(dir); err != nil {
+			if os.IsNotExist(err) {
+				log.Printf("abi dir %s not found, skipped", dir)
+				continue
+			}
+			return nil, err
+		}
+	}
+	if _, err := reg.Register("Store", []byte(pkgStore.StoreABI), "pkg/Store.go"); err != nil {
+		return nil, err
 	}
-	return nil, errors.New("ethClient is not initialized")
+	if networkName == storeNetwork {
+		if _, err := reg.Bind("Store", common.HexToAddress(storeAddress)); err != nil {
+			return nil, err
+		}
+	}
+	return reg, nil
+}
+
+func (u *UserHandler) RegisterRoutes(server *gin.Engine) {
+	// 每个路由声明需要的权限；签名类接口同时声明签名账户和目标合约从哪里取，授权可以限定到具体地址
+	read := u.authz.Require(auth.PermRead)
+	signer := middleware.Or(middleware.BodyField("from"), u.defaultSigner)
+	demo := middleware.Account(middleware.Fixed(common.HexToAddress(demoSigner)))
+	store := middleware.Contract(middleware.Fixed(common.HexToAddress(storeAddress)))
+
+	ug := server.Group("/users", u.selectNetwork)
+	ug.GET("/index", u.Index)
+	ug.GET("/wallet", read, u.Wallet)
+	ug.GET("/transaction", u.authz.Require(auth.PermSign, middleware.Account(u.vaultSigner)), u.Transaction)
+	ug.GET("/checkAddress", read, u.CheckAddress)
+	ug.GET("/checkBlock", read, u.CheckBlock)
+	ug.GET("/checkTransactions", read, u.CheckTransactions)
+	ug.GET("/transferETH", u.authz.Require(auth.PermTransfer, demo), u.TransferETH)
+	ug.GET("/tokenTransfer", u.authz.Require(auth.PermTransfer, demo, middleware.Contract(middleware.Fixed(common.HexToAddress(demoToken)))), u.TokenTransfer)
+	ug.GET("/subscribe", read, u.Subscribe)
+	ug.GET("/transactionRawCreate", u.authz.Require(auth.PermSign, demo), u.TransactionRawCreate)
+	ug.GET("/transactionRawSendreate", u.authz.Require(auth.PermSign, demo), u.TransactionRawSendreate)
+	ug.GET("/contractDeploy", u.authz.Require(auth.PermDeploy, demo), u.ContractDeploy)
+	ug.GET("/loadContract", read, u.LoadContract)
+	ug.GET("/writeContract", u.authz.Require(auth.PermWrite, demo, store), u.WriteContract)
+	ug.GET("/readContract", read, u.ReadContract)
+	ug.GET("/subLog", read, u.SubLog)
+
+	server.GET("/networks", u.ListNetworks)
+	server.GET("/cache/stats", u.selectNetwork, read, u.CacheStats)
+	server.POST("/balances", u.selectNetwork, read, u.Balances)
+
+	tkg := server.Group("/tokens", u.selectNetwork)
+	tkg.POST("/balances", read, u.TokenBalances)
+	tkg.POST("/metadata", read, u.TokenMetadata)
+	tkg.POST("/permit/test-token", u.authz.Require(auth.PermDeploy, middleware.Account(signer)), u.DeployPermitToken)
+	tkg.GET("/:address/permit", read, u.PermitInfo)
+	tkg.POST("/:address/permit/sign", u.authz.Require(auth.PermTransfer, middleware.Account(middleware.Or(middleware.BodyField("owner"), u.defaultSigner)), middleware.Contract(middleware.Param("address"))), u.SignPermit)
+	tkg.POST("/:address/permit/relay", u.authz.Require(auth.PermWrite, middleware.Account(middleware.Or(middleware.BodyField("relayer"), u.defaultSigner)), middleware.Contract(middleware.Param("address"))), u.RelayPermit)
+
+	bg := server.Group("/blocks", u.selectNetwork)
+	bg.GET("/latest", read, u.LatestBlock)
+	bg.GET("/:block", read, u.GetBlock)
+	bg.GET("/:block/txs", read, u.BlockTransactions)
+
+	tg := server.Group("/txs", u.selectNetwork)
+	tg.GET("/:hash", read, u.GetTransaction)
+
+	ag := server.Group("/addresses", u.selectNetwork)
+	ag.GET("/:addr", read, u.InspectAddress)
+	ag.GET("/:addr/txs", read, u.AddressHistory)
+	ag.GET("/:addr/portfolio", read, u.Portfolio)
+	ag.POST("/:addr/watch", u.authz.Require(auth.PermScan), u.WatchAddress)
+
+	sg := server.Group("/scanner", u.selectNetwork)
+	sg.GET("/status", read, u.ScannerStatus)
+	sg.POST("/scan", u.authz.Require(auth.PermScan), u.StartScan)
+
+	cg := server.Group("/contracts", u.selectNetwork)
+	cg.POST("/deploy", u.authz.Require(auth.PermDeploy, middleware.Account(signer)), u.DeployContract)
+	cg.POST("/create2/predict", read, u.PredictCreate2)
+	cg.POST("/create2/deploy", u.authz.Require(auth.PermDeploy, middleware.Account(signer)), u.DeployCreate2)
+	cg.POST("/create2/factory", u.authz.Require(auth.PermDeploy, middleware.Account(signer)), u.EnsureCreate2Factory)
+	cg.POST("/:address/call/:method", read, u.CallContract)
+	cg.POST("/:address/send/:method", u.authz.Require(auth.PermWrite, middleware.Account(signer), middleware.Contract(middleware.Param("address"))), u.SendContract)
+	cg.GET("/:address/verify", read, u.VerifyContract)
+	cg.GET("/:address/disasm", read, u.DisassembleContract)
+
+	sig := server.Group("/signatures", u.selectNetwork)
+	sig.POST("/sign", u.authz.Require(auth.PermSign, middleware.Account(signer)), u.SignMessage)
+	sig.POST("/verify", read, u.VerifyMessage)
+	sig.POST("/typed/sign", u.authz.Require(auth.PermSign, middleware.Account(signer), middleware.Contract(u.typedDataContract)), u.SignTypedData)
+	sig.POST("/typed/verify", read, u.VerifyTypedData)
+
+	mg := server.Group("/mnemonics")
+	mg.POST("/generate", read, u.GenerateMnemonic)
+	mg.POST("/validate", read, u.ValidateMnemonic)
+	// 按任意路径派生并写入保险库的审计，和 /vault 一样需要管理员
+	mg.POST("/derive", u.authz.Require(auth.PermAdmin), u.DeriveMnemonic)
+
+	zg := server.Group("/authz", u.authz.Require(auth.PermAdmin))
+	zg.GET("/roles", u.AuthzRoles)
+	zg.GET("/denials", u.AuthzDenials)
 }
 
 func (u *UserHandler) Index(ctx *gin.Context) {
 	ctx.HTML(http.StatusOK, "index.html", nil) // 渲染模板
 }
 
+// Wallet 用保险库里的助记词派生前两个地址，?vault= 为助记词的名字，需要先通过 /vault/:name/unlock 解锁。
+// 只读查询，不记入审计
 func (u *UserHandler) Wallet(ctx *gin.Context) {
-	mnemonic := "tag volcano eight thank tide danger coast health above argue embrace heavy"
-	//使用助记词创建钱包
-	wallet, err := hdwallet.NewFromMnemonic(mnemonic)
-	if err != nil {
-		log.Fatal(err)
-	}
+	name := ctx.Query("vault")
 
-	//定义了一个“派生路径”（derivation path），这个路径遵循 BIP-44 标准，代表了从助记词生成的第一个以太坊账户。
-	path := hdwallet.MustParseDerivationPath("m/44'/60'/0'/0/0")
-	account, err := wallet.Derive(path, false)
+	//派生路径（derivation path）遵循 BIP-44 标准，m/44'/60'/0'/0/0 代表从助记词生成的第一个以太坊账户。
+	account, err := u.vault.Address(name, defaultDerivationBase+"/0")
 	if err != nil {
-		log.Fatal(err)
+		u.writeError(ctx, vaultErrorStatus(err), err)
+		return
 	}
-	address1 := account.Address.Hex()
+	address1 := account.Hex()
 
 	//第二个地址生成 派生路径为 m/44'/60'/0'/0/1，与第一个地址的路径类似，只不过最后的索引 0/1 表示生成第二个地址。
-	path = hdwallet.MustParseDerivationPath("m/44'/60'/0'/0/1")
-	account, err = wallet.Derive(path, false)
+	account, err = u.vault.Address(name, defaultDerivationBase+"/1")
 	if err != nil {
-		log.Fatal(err)
+		u.writeError(ctx, vaultErrorStatus(err), err)
+		return
 	}
-	address2 := account.Address.Hex()
+	address2 := account.Hex()
 	//fmt.Println(account.Address.Hex())
 	ctx.JSON(http.StatusOK, gin.H{
 		"功能描述：": "基于一个助记词（mnemonic）生成以太坊的两个地址",
@@ -103,20 +250,9 @@ func (u *UserHandler) Wallet(ctx *gin.Context) {
 	})
 }
 
-// Transaction 签署交易
+// Transaction 签署交易，?vault= 为保险库里助记词的名字，用它的第一个账户 m/44'/60'/0'/0/0 签名
 func (u *UserHandler) Transaction(ctx *gin.Context) {
-	//通过助记词创建钱包
-	mnemonic := "tag volcano eight thank tide danger coast health above argue embrace heavy"
-	wallet, err := hdwallet.NewFromMnemonic(mnemonic)
-	if err != nil {
-		log.Fatal(err)
-	}
-	//派生账户
-	path := hdwallet.MustParseDerivationPath("m/44'/60'/0'/0/0")
-	account, err := wallet.Derive(path, true)
-	if err != nil {
-		log.Fatal(err)
-	}
+	name := ctx.Query("vault")
 	//设置交易参数
 	nonce := uint64(0)                       //交易序号
 	value := big.NewInt(1000000000000000000) //交易的金额
@@ -127,137 +263,29 @@ func (u *UserHandler) Transaction(ctx *gin.Context) {
 
 	//创建交易
 	tx := types.NewTransaction(nonce, toAddress, value, gasLimit, gasPrice, data)
-	//签署交易 wallet.SignTx 对交易进行签名
-	signedTx, err := wallet.SignTx(account, tx, nil)
+	//签署交易，保险库派生账户并签名，同时记入审计
+	signedTx, err := u.vault.SignTx(name, defaultDerivationBase+"/0", tx, nil)
 	if err != nil {
-		log.Fatal(err)
+		u.writeError(ctx, vaultErrorStatus(err), err)
+		return
 	}
 
 	spew.Dump(signedTx)
 }
 
-// CheckAddress 检查地址
+// CheckAddress 检查地址，地址通过 ?address= 传入，结果与 GET /addresses/:addr 相同
 func (u *UserHandler) CheckAddress(ctx *gin.Context) {
-	//正则表达式验证以太坊地址
-	re := regexp.MustCompile("^0x[0-9a-fA-F]{40}$")
-	fmt.Printf("is valid: %v\n", re.MatchString("0x323b5d4c32345ced77393b3530b1eed0f346429d")) // is valid: true
-	fmt.Printf("is valid: %v\n", re.MatchString("0xZYXb5d4c32345ced77393b3530b1eed0f346429d")) // is valid: false
-	//验证输入的地址
-	if !re.MatchString("0x00000000219ab540356cBB839Cbe05303d7705Fa") {
-		errors.New("以太坊地址不正确！")
-		ctx.JSON(http.StatusOK, gin.H{
-			"msg": "以太坊地址不正确",
-		})
-	}
-	//获取地址字节码，检查是否为合约地址
-	address := common.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa")
-	bytecode, err := u.ethClient.CodeAt(context.Background(), address, nil)
-	if err != nil {
-		log.Fatal(err)
-	}
-	//如果返回的 bytecode 长度大于0，说明该地址部署了智能合约。如果 bytecode 长度为0，说明该地址是一个普通钱包地址。
-	isContract := len(bytecode) > 0
-	fmt.Printf("is contract %V\n", isContract)
+	u.inspectAddress(ctx, ctx.Query("address"))
 }
 
-// CheckBlock 查看区块
+// CheckBlock 查看区块，?block= 可以是区块号或区块哈希，默认最新区块
 func (u *UserHandler) CheckBlock(ctx *gin.Context) {
-	// 传入 nil 返回最新的区块头
-	header, err := u.ethClient.HeaderByNumber(context.Background(), nil)
-	if err != nil {
-		log.Fatal(err)
-	}
-	fmt.Println("最新的区块号是：", header.Number.String()) //7146892
-
-	//获取完整区块 如：区块号，区块时间戳，区块摘要，区块难度以及交易列表
-	blockNumber := big.NewInt(7146892)
-	block, err := u.ethClient.BlockByNumber(context.Background(), blockNumber)
-	if err != nil {
-		log.Fatal(err)
-	}
-	fmt.Printf("区块号: %d\n", block.Number().Uint64())
-	fmt.Printf("区块时间戳: %d (Unix 时间戳)\n", block.Time())
-	fmt.Printf("区块难度: %d\n", block.Difficulty().Uint64())
-	fmt.Printf("区块哈希: %s\n", block.Hash().Hex())
-	fmt.Printf("交易数量: %d\n", len(block.Transactions()))
-
-	//调用 Transaction 只返回一个区块的交易数目。
-	count, err := u.ethClient.TransactionCount(context.Background(), block.Hash())
-	if err != nil {
-		log.Fatal(err)
-	}
-	fmt.Println("区块的交易数目", count)
+	u.blockJSON(ctx, ctx.DefaultQuery("block", "latest"))
 }
 
-// CheckTransactions 查询交易
+// CheckTransactions 查询交易详情，?hash= 为交易哈希
 func (u *UserHandler) CheckTransactions(ctx *gin.Context) {
-	blockNumber := big.NewInt(7146892)
-	block, err := u.ethClient.BlockByNumber(context.Background(), blockNumber)
-	if err != nil {
-		log.Fatal(err)
-	}
-	fmt.Println("区块哈希:", block.Hash().Hex()) // 打印区块哈希
-	for i, tx := range block.Transactions() {
-		if i == 4 {
-			break
-		}
-		fmt.Printf("交易 %d:\n", i+1)
-		fmt.Printf("  哈希: %s\n", tx.Hash().Hex())
-		fmt.Printf("  数量 (Value): %s wei\n", tx.Value().String())
-		fmt.Printf("  Gas 限制: %d\n", tx.Gas())
-		fmt.Printf("  Gas 价格: %d wei\n", tx.GasPrice().Uint64())
-		fmt.Printf("  Nonce: %d\n", tx.Nonce())
-		fmt.Printf("  数据 (Data): %x\n", tx.Data())
-		if tx.To() != nil {
-			fmt.Printf("  接收地址: %s\n", tx.To().Hex())
-		} else {
-			fmt.Println("  接收地址: (合约创建交易)")
-		}
-		fmt.Println("-------------------------")
-
-		//获取发送者地址
-		chainID, err := u.ethClient.NetworkID(context.Background())
-		if err != nil {
-			log.Fatal(err)
-		}
-		// types.Sender 方法获取交易的发送者地址. 使用 EIP155Signer 来根据网络链 ID 来签名交易，从而提取发送者地址。
-		if sender, err := types.Sender(types.NewEIP155Signer(chainID), tx); err == nil {
-			fmt.Println("sender", sender.Hex())
-		}
-		// 获取交易数据 TransactionReceipt 获取交易的收据，它包含了交易的执行结果和状态信息
-		receipt, err := u.ethClient.TransactionReceipt(context.Background(), tx.Hash())
-		if err != nil {
-			log.Fatal(err)
-		}
-		fmt.Println("你是啥：", receipt.Status) //执行结果
-	}
-	// 获取区块中的交易数量 TransactionCount 获取指定区块（通过 blockHash 指定）的交易数量。该方法返回区块中包含的交易总数。
-	blockHash := common.HexToHash("0xf7219b984ead83cf52243d70292302cb2d9d80c08298a29e061925174e75d429") //这里的哈希是区块哈希，不是交易哈希
-	count, err := u.ethClient.TransactionCount(context.Background(), blockHash)
-	if err != nil {
-		log.Fatal(err)
-	}
-	fmt.Println("Transaction Count:", count)
-	//遍历区块中的所有交易
-	for idx := uint(0); idx < count; idx++ {
-		if idx == 4 {
-			break
-		}
-		tx, err := u.ethClient.TransactionInBlock(context.Background(), blockHash, idx)
-		if err != nil {
-			log.Fatal(err)
-		}
-		//fmt.Printf("Transaction: %+v\n", tx)
-		fmt.Println("就是你", tx.Hash().Hex())
-	}
-	//获取单个交易的详细信息
-	txHash := common.HexToHash("0x3802c067d3b57db2e8b82c9dc3f263ef97046ea613c0a0e5eb531bdee1dfb6ea") // 这里是交易哈希
-	tx, isPending, err := u.ethClient.TransactionByHash(context.Background(), txHash)
-	if err != nil {
-		log.Fatal(err)
-	}
-	fmt.Println(tx.Hash().Hex()) // 打印交易哈希
-	fmt.Println(isPending)       // 打印交易是否在待处理队列中
+	u.txJSON(ctx, ctx.Query("hash"))
 }
 
 // TransferETH 以太坊
@@ -283,7 +311,7 @@ func (u *UserHandler) TransferETH(ctx *gin.Context) {
 	使用 PendingNonceAt 获取待处理交易的 nonce 值。
 	如果获取失败，会抛出错误。
 	*/
-	nonce, err := u.ethClient.PendingNonceAt(context.Background(), fromAddress)
+	nonce, err := u.client(ctx).PendingNonceAt(ctx.Request.Context(), fromAddress)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -295,7 +323,7 @@ func (u *UserHandler) TransferETH(ctx *gin.Context) {
 	*/
 	value := big.NewInt(10000000000000000) // in wei (1 eth)
 	gasLimit := uint64(21000)
-	gasPrice, err := u.ethClient.SuggestGasPrice(context.Background())
+	gasPrice, err := u.client(ctx).SuggestGasPrice(ctx.Request.Context())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -308,7 +336,7 @@ func (u *UserHandler) TransferETH(ctx *gin.Context) {
 	获取网络的链 ID（主网、测试网等）。
 	使用 types.SignTx 方法，使用发送者的私钥和链 ID 对交易进行签名，生成已签名的交易 signedTx。
 	*/
-	chainID, err := u.ethClient.NetworkID(context.Background())
+	chainID, err := u.client(ctx).NetworkID(ctx.Request.Context())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -322,7 +350,7 @@ func (u *UserHandler) TransferETH(ctx *gin.Context) {
 	如果交易发送失败，会抛出错误。
 	最后，打印出交易的哈希（交易的唯一标识符），表示交易已发送。
 	*/
-	err = u.ethClient.SendTransaction(context.Background(), signedTx)
+	err = u.client(ctx).SendTransaction(ctx.Request.Context(), signedTx)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -354,14 +382,14 @@ func (u *UserHandler) TokenTransfer(ctx *gin.Context) {
 	//使用 crypto.PubkeyToAddress 将公钥转化为地址，即交易的发送方地址。  如果公钥类型无法转换为 *ecdsa.PublicKey，则报错。
 	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
 	//交易账户的随机数
-	nonce, err := u.ethClient.PendingNonceAt(context.Background(), fromAddress)
+	nonce, err := u.client(ctx).PendingNonceAt(ctx.Request.Context(), fromAddress)
 	if err != nil {
 		log.Fatal(err)
 	}
 	//交易细节
 	value := big.NewInt(0) // in wei (1 eth)
 	//gasLimit := uint64(21000)
-	gasPrice, err := u.ethClient.SuggestGasPrice(context.Background())
+	gasPrice, err := u.client(ctx).SuggestGasPrice(ctx.Request.Context())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -398,7 +426,7 @@ func (u *UserHandler) TokenTransfer(ctx *gin.Context) {
 	使用 EstimateGas 方法估算执行这笔交易所需要的 Gas 数量。
 	ethereum.CallMsg 包含了目标地址和数据，EstimateGas 会计算并返回适当的 Gas 限制。
 	*/
-	//gasLimit, err := u.ethClient.EstimateGas(context.Background(), ethereum.CallMsg{
+	//gasLimit, err := u.client(ctx).EstimateGas(ctx.Request.Context(), ethereum.CallMsg{
 	//	To:   &toAddress,
 	//	Data: data,
 	//})
@@ -418,7 +446,7 @@ func (u *UserHandler) TokenTransfer(ctx *gin.Context) {
 	*/
 	tx := types.NewTransaction(nonce, tokenAddress, value, adjustedGasLimit, gasPrice, data)
 
-	chainID, err := u.ethClient.NetworkID(context.Background())
+	chainID, err := u.client(ctx).NetworkID(ctx.Request.Context())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -428,7 +456,7 @@ func (u *UserHandler) TokenTransfer(ctx *gin.Context) {
 		log.Fatal(err)
 	}
 	//发送交易 使用 client.SendTransaction 将已签名的交易广播到网络中。
-	err = u.ethClient.SendTransaction(context.Background(), signedTx)
+	err = u.client(ctx).SendTransaction(ctx.Request.Context(), signedTx)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -438,18 +466,27 @@ func (u *UserHandler) TokenTransfer(ctx *gin.Context) {
 
 // Subscribe 订阅新区块
 func (u *UserHandler) Subscribe(ctx *gin.Context) {
-	client, err := ethclient.Dial("wss://sepolia.infura.io/ws/v3/5cfcf36740804b5f92e934d6a2ba77c8")
+	client, err := u.pool(ctx).WS(ctx.Request.Context())
 	if err != nil {
-		log.Fatal(err)
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	// WS 连接上的调用不经过 HTTP Transport，API 密钥的 RPC 额度在这里计数
+	reqCtx := ctx.Request.Context()
+	if err := network.Charge(reqCtx, network.Call{Method: "eth_subscribe"}); err != nil {
+		u.writeError(ctx, http.StatusTooManyRequests, err)
+		return
 	}
 
 	//创建一个新的通道，用于接收最新的区块头。
 	headers := make(chan *types.Header)
 	//SubscribeNewHead 方法用来订阅新的区块头（即区块链上新增的区块）。每当一个新区块被挖矿成功并广播到网络时，这个订阅会接收到新区块的头部信息，并将其放入 headers 通道。
-	sub, err := client.SubscribeNewHead(context.Background(), headers)
+	sub, err := client.SubscribeNewHead(reqCtx, headers)
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer sub.Unsubscribe()
 	//循环处理新区块头和区块信息：
 	/**
 	使用 select 语句等待 sub.Err() 和 headers 通道中的数据
@@ -459,18 +496,28 @@ func (u *UserHandler) Subscribe(ctx *gin.Context) {
 	*/
 	for {
 		select {
+		case <-reqCtx.Done():
+			// 客户端断开，停止订阅
+			return
 		case err := <-sub.Err():
 			log.Fatal(err)
 		case header := <-headers:
 			fmt.Println(header.Hash().Hex())
-			block, err := client.BlockByHash(context.Background(), header.Hash())
+			if err := network.Charge(reqCtx, network.Call{Method: "eth_getBlockByHash"}); err != nil {
+				log.Printf("subscribe: %v", err)
+				return
+			}
+			block, err := client.BlockByHash(reqCtx, header.Hash())
 			if err != nil {
+				if reqCtx.Err() != nil {
+					return
+				}
 				log.Fatal(err)
 			}
 			fmt.Printf("区块哈希值: %s\n", block.Hash().Hex())           // 使用 %s 输出区块哈希值，格式化为字符串
 			fmt.Printf("区块编号 (高度): %d\n", block.Number().Uint64())  // 输出区块编号
 			fmt.Printf("区块时间戳 (UNIX 时间): %d\n", block.Time())       // 输出区块的时间戳（UNIX格式）
-			fmt.Printf("区块的 Nonce: %s\n", block.Nonce())            // 输出区块的 nonce 值
+			fmt.Printf("区块的 Nonce: %d\n", block.Nonce())            // 输出区块的 nonce 值
 			fmt.Printf("区块中的交易数量: %d\n", len(block.Transactions())) // 输出区块中交易的数量
 		}
 	}
@@ -498,7 +545,7 @@ func (u *UserHandler) TransactionRawCreate(ctx *gin.Context) {
 	使用 PendingNonceAt 获取待处理交易的 nonce 值。
 	如果获取失败，会抛出错误。
 	*/
-	nonce, err := u.ethClient.PendingNonceAt(context.Background(), fromAddress)
+	nonce, err := u.client(ctx).PendingNonceAt(ctx.Request.Context(), fromAddress)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -510,14 +557,14 @@ func (u *UserHandler) TransactionRawCreate(ctx *gin.Context) {
 	*/
 	value := big.NewInt(10000000000000000) // in wei (1 eth)
 	gasLimit := uint64(60000)
-	gasPrice, err := u.ethClient.SuggestGasPrice(context.Background())
+	gasPrice, err := u.client(ctx).SuggestGasPrice(ctx.Request.Context())
 	if err != nil {
 		log.Fatal(err)
 	}
 	// 设置目标地址 将 ETH 发送给谁。
 	toAddress := common.HexToAddress("0xCA690381a3Ea245BfA6a3DE8823133260bCA572A")
 	tx := types.NewTransaction(nonce, toAddress, value, gasLimit, gasPrice, nil)
-	chainID, err := u.ethClient.NetworkID(context.Background())
+	chainID, err := u.client(ctx).NetworkID(ctx.Request.Context())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -564,7 +611,7 @@ func (u *UserHandler) TransactionRawSendreate(ctx *gin.Context) {
 	// Step 4: 使用 RLP 解码字节数据到 Transaction 对象
 	rlp.DecodeBytes(rawTxBytes, &tx)
 	// Step 5: 通过以太坊客户端发送交易
-	err = u.ethClient.SendTransaction(context.Background(), tx)
+	err = u.client(ctx).SendTransaction(ctx.Request.Context(), tx)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -597,11 +644,11 @@ func (u *UserHandler) ContractDeploy(ctx *gin.Context) {
 	//使用 crypto.PubkeyToAddress 将公钥转化为地址，即交易的发送方地址。  如果公钥类型无法转换为 *ecdsa.PublicKey，则报错。
 	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
 	fmt.Println("看看地址", fromAddress)
-	nonce, err := u.ethClient.PendingNonceAt(context.Background(), fromAddress)
+	nonce, err := u.client(ctx).PendingNonceAt(ctx.Request.Context(), fromAddress)
 	if err != nil {
 		log.Fatal(err)
 	}
-	gasPrice, err := u.ethClient.SuggestGasPrice(context.Background())
+	gasPrice, err := u.client(ctx).SuggestGasPrice(ctx.Request.Context())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -611,9 +658,10 @@ func (u *UserHandler) ContractDeploy(ctx *gin.Context) {
 	auth.Value = big.NewInt(0)
 	auth.GasLimit = uint64(300000)
 	auth.GasPrice = gasPrice
+	auth.Context = ctx.Request.Context() // API 密钥的额度按请求计数
 
 	input := "1.0"
-	address, tx, instance, err := pkgStore.DeployStore(auth, u.ethClient, input)
+	address, tx, instance, err := pkgStore.DeployStore(auth, u.client(ctx), input)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -625,11 +673,11 @@ func (u *UserHandler) ContractDeploy(ctx *gin.Context) {
 // LoadContract 加载智能合约 + 查询智能合约
 func (u *UserHandler) LoadContract(ctx *gin.Context) {
 	address := common.HexToAddress("0x135765bEC9A17B12841389a727092552598ed6D5")
-	instance, err := pkgStore.NewStore(address, u.ethClient)
+	instance, err := pkgStore.NewStore(address, u.client(ctx))
 	if err != nil {
 		log.Fatal(err)
 	}
-	version, err := instance.Version(nil)
+	version, err := instance.Version(&bind.CallOpts{Context: ctx.Request.Context()})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -648,11 +696,11 @@ func (u *UserHandler) WriteContract(ctx *gin.Context) {
 		log.Fatal("cannot assert type: publicKey is not of type *ecdsa.PublicKey")
 	}
 	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := u.ethClient.PendingNonceAt(context.Background(), fromAddress)
+	nonce, err := u.client(ctx).PendingNonceAt(ctx.Request.Context(), fromAddress)
 	if err != nil {
 		log.Fatal(err)
 	}
-	gasPrice, err := u.ethClient.SuggestGasPrice(context.Background())
+	gasPrice, err := u.client(ctx).SuggestGasPrice(ctx.Request.Context())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -663,9 +711,10 @@ func (u *UserHandler) WriteContract(ctx *gin.Context) {
 	auth.Value = big.NewInt(0)
 	auth.GasLimit = uint64(300000)
 	auth.GasPrice = gasPrice
+	auth.Context = ctx.Request.Context() // API 密钥的额度按请求计数
 
 	address := common.HexToAddress("0x135765bEC9A17B12841389a727092552598ed6D5")
-	instance, err := pkgStore.NewStore(address, u.ethClient)
+	instance, err := pkgStore.NewStore(address, u.client(ctx))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -680,7 +729,7 @@ func (u *UserHandler) WriteContract(ctx *gin.Context) {
 	}
 	fmt.Printf("tx sent: %s\n", tx.Hash().Hex())
 	//验证键/值是否已设置，我们可以读取智能合约中的值。
-	result, err := instance.Items(nil, key)
+	result, err := instance.Items(&bind.CallOpts{Context: ctx.Request.Context()}, key)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -691,7 +740,7 @@ func (u *UserHandler) WriteContract(ctx *gin.Context) {
 func (u *UserHandler) ReadContract(ctx *gin.Context) {
 	contractAddress := common.HexToAddress("0x135765bEC9A17B12841389a727092552598ed6D5")
 
-	bytecode, err := u.ethClient.CodeAt(context.Background(), contractAddress, nil)
+	bytecode, err := u.client(ctx).CodeAt(ctx.Request.Context(), contractAddress, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -701,23 +750,33 @@ func (u *UserHandler) ReadContract(ctx *gin.Context) {
 
 // SubLog 订阅事件日志
 func (u *UserHandler) SubLog(ctx *gin.Context) {
-	client, err := ethclient.Dial("wss://sepolia.infura.io/ws/v3/5cfcf36740804b5f92e934d6a2ba77c8")
+	client, err := u.pool(ctx).WS(ctx.Request.Context())
 	if err != nil {
-		log.Fatal(err)
+		u.writeError(ctx, http.StatusBadRequest, err)
+		return
 	}
 	contractAddress := common.HexToAddress("0x147B8eb97fD247D06C4006D269c90C1908Fb5D54")
 	query := ethereum.FilterQuery{
 		Addresses: []common.Address{contractAddress},
 	}
+	// WS 连接上的调用不经过 HTTP Transport，API 密钥的 RPC 额度在这里计数
+	reqCtx := ctx.Request.Context()
+	if err := network.Charge(reqCtx, network.Call{Method: "eth_subscribe"}); err != nil {
+		u.writeError(ctx, http.StatusTooManyRequests, err)
+		return
+	}
 	//接收事件的方式是通过 Go channel
 	logs := make(chan types.Log)
-	sub, err := client.SubscribeFilterLogs(context.Background(), query, logs)
+	sub, err := client.SubscribeFilterLogs(reqCtx, query, logs)
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer sub.Unsubscribe()
 	//连续循环来读入新的日志事件或订阅错误。
 	for {
 		select {
+		case <-reqCtx.Done():
+			return
 		case err := <-sub.Err():
 			log.Fatal(err)
 		case vLog := <-logs:
@@ -728,7 +787,7 @@ func (u *UserHandler) SubLog(ctx *gin.Context) {
 
 // ReadLogsEvent 读取日志事件
 func (u *UserHandler) ReadLogsEvent() {
-	client, err := ethclient.Dial("wss://sepolia.infura.io/ws/v3/5cfcf36740804b5f92e934d6a2ba77c8")
+	client, err := u.pools.Default().WS(context.Background())
 	if err != nil {
 		log.Fatal(err)
 	}
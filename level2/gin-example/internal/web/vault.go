@@ -0,0 +1,191 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"level2/gin-example/internal/auth"
+	"level2/gin-example/internal/mnemonic"
+	"level2/gin-example/internal/vault"
+	"level2/gin-example/internal/web/middleware"
+)
+
+// VaultHandler 管理加密保存的助记词，接口需要 admin 权限。
+// 助记词原文只在生成时返回一次，之后 HD 钱包相关的接口都按名字引用
+type VaultHandler struct {
+	vault *vault.Vault
+	authz *middleware.Authorizer
+}
+
+func NewVaultHandler(v *vault.Vault, authz *middleware.Authorizer) *VaultHandler {
+	return &VaultHandler{vault: v, authz: authz}
+}
+
+func (h *VaultHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/vault", h.authz.Require(auth.PermAdmin))
+	g.GET("", h.List)
+	g.POST("", h.Generate)
+	g.POST("/import", h.Import)
+	g.GET("/:name", h.Get)
+	g.POST("/:name/unlock", h.Unlock)
+	g.POST("/:name/lock", h.Lock)
+	g.POST("/:name/rotate", h.Rotate)
+	g.DELETE("/:name", h.Delete)
+}
+
+// Generate 生成新的助记词并加密保存，响应里的 mnemonic 只出现这一次，需要离线备份
+// POST /vault {"name": "ops", "password": "...", "words": 24}
+func (h *VaultHandler) Generate(ctx *gin.Context) {
+	var req struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+		Words    int    `json:"words"` // 默认 12
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	words, view, err := h.vault.Generate(req.Name, req.Password, req.Words)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{
+		"mnemonic": words,
+		"entry":    view,
+	})
+}
+
+// Import 加密保存已有的助记词，passphrase 为可选的 BIP-39 密码短语（同样加密保存）
+// POST /vault/import {"name": "ops", "password": "...", "mnemonic": "...", "passphrase": ""}
+func (h *VaultHandler) Import(ctx *gin.Context) {
+	var req struct {
+		Name       string `json:"name"`
+		Password   string `json:"password"`
+		Mnemonic   string `json:"mnemonic"`
+		Passphrase string `json:"passphrase"`
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	view, err := h.vault.Import(req.Name, req.Password, req.Mnemonic, req.Passphrase)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, view)
+}
+
+// List 所有助记词的名字、锁定状态和派生账户的使用记录
+// GET /vault
+func (h *VaultHandler) List(ctx *gin.Context) {
+	entries := h.vault.List()
+	ctx.JSON(http.StatusOK, gin.H{
+		"count":   len(entries),
+		"entries": entries,
+	})
+}
+
+// Get 一个助记词的状态和审计记录
+// GET /vault/:name
+func (h *VaultHandler) Get(ctx *gin.Context) {
+	view, err := h.vault.Get(ctx.Param("name"))
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, view)
+}
+
+// Unlock 解锁，timeoutSeconds 后自动锁定（默认 5 分钟，最长 1 小时）
+// POST /vault/:name/unlock {"password": "...", "timeoutSeconds": 600}
+func (h *VaultHandler) Unlock(ctx *gin.Context) {
+	var req struct {
+		Password       string `json:"password"`
+		TimeoutSeconds int64  `json:"timeoutSeconds"`
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	view, err := h.vault.Unlock(ctx.Param("name"), req.Password, time.Duration(req.TimeoutSeconds)*time.Second)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, view)
+}
+
+// Lock 立即锁定
+// POST /vault/:name/lock
+func (h *VaultHandler) Lock(ctx *gin.Context) {
+	view, err := h.vault.Lock(ctx.Param("name"))
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, view)
+}
+
+// Rotate 更换保险库密码，完成后条目被锁定
+// POST /vault/:name/rotate {"password": "...", "newPassword": "..."}
+func (h *VaultHandler) Rotate(ctx *gin.Context) {
+	var req struct {
+		Password    string `json:"password"`
+		NewPassword string `json:"newPassword"`
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	view, err := h.vault.Rotate(ctx.Param("name"), req.Password, req.NewPassword)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, view)
+}
+
+// Delete 删除助记词，需要密码确认
+// DELETE /vault/:name {"password": "..."}
+func (h *VaultHandler) Delete(ctx *gin.Context) {
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	if err := h.vault.Delete(ctx.Param("name"), req.Password); err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"deleted": ctx.Param("name")})
+}
+
+func (h *VaultHandler) writeError(ctx *gin.Context, err error) {
+	ctx.AbortWithStatusJSON(vaultErrorStatus(err), gin.H{"msg": err.Error()})
+}
+
+// vaultErrorStatus 保险库错误对应的状态码，UserHandler 的 HD 钱包接口也用它
+func vaultErrorStatus(err error) int {
+	var merr *mnemonic.Error
+	switch {
+	case errors.Is(err, vault.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, vault.ErrExists):
+		return http.StatusConflict
+	case errors.Is(err, vault.ErrLocked):
+		return http.StatusLocked
+	case errors.Is(err, vault.ErrWrongPassword):
+		return http.StatusForbidden
+	case errors.Is(err, vault.ErrInvalidName), errors.Is(err, vault.ErrWeakPassword), errors.Is(err, vault.ErrInvalidTimeout), errors.As(err, &merr),
+		errors.Is(err, mnemonic.ErrWordCount), errors.Is(err, mnemonic.ErrEntropy):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
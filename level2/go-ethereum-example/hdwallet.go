@@ -3,12 +3,18 @@ package go_ethereum_example
 import (
 	"fmt"
 	hdwallet "github.com/miguelmota/go-ethereum-hdwallet"
+	"github.com/tyler-smith/go-bip39"
 	"log"
 )
 
 func wallet() {
-	mnemonic := "tag volcano eight thank"
-	wallet, err := hdwallet.NewFromMnemonic(mnemonic)
+	// BIP-39 助记词必须是 12/15/18/21/24 个词表里的单词，最后一个单词带校验和；只有 4 个单词会直接报错
+	mnemonic := "tag volcano eight thank tide danger coast health above argue embrace heavy"
+	if !bip39.IsMnemonicValid(mnemonic) {
+		log.Fatal("invalid mnemonic")
+	}
+	// 第二个参数是可选的 BIP-39 密码短语，不同的密码短语派生出完全不同的账户
+	wallet, err := hdwallet.NewFromMnemonic(mnemonic, "")
 	if err != nil {
 		log.Fatal(err)
 	}
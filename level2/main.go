@@ -10,6 +10,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"golang.org/x/crypto/sha3"
+	"level2/network"
 	token "level2/pkg"
 	"log"
 	"math"
@@ -18,7 +19,7 @@ import (
 
 func main() {
 	// 02.链接客户端
-	client, err := connectToInfura()
+	client, err := connectToNetwork()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -40,12 +41,21 @@ func main() {
 
 }
 
-// 02.链接客户端
-func connectToInfura() (*ethclient.Client, error) {
-	//infuraUrl := "https://mainnet.infura.io/v3/5cfcf36740804b5f92e934d6a2ba77c8"
-	infuraUrl := "https://sepolia.infura.io/v3/5cfcf36740804b5f92e934d6a2ba77c8"
-
-	return ethclient.Dial(infuraUrl)
+// 02.链接客户端 节点地址来自 networks.json，ETH_NETWORK=mainnet 可以切换网络
+func connectToNetwork() (*ethclient.Client, error) {
+	cfg, err := network.Load("")
+	if err != nil {
+		return nil, err
+	}
+	n := cfg.Networks[cfg.Default]
+	if n.Disabled != "" {
+		return nil, fmt.Errorf("network %s: %s", n.Name, n.Disabled)
+	}
+	pool, err := network.Dial(context.Background(), n)
+	if err != nil {
+		return nil, err
+	}
+	return pool.Client(), nil
 }
 
 // 03账户
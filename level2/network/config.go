@@ -0,0 +1,229 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// DefaultConfigPath 默认的网络配置文件，路径相对于 level2（程序从这里启动）
+	DefaultConfigPath = "./networks.json"
+	// DefaultFinalityDepth 没有配置 finalityDepth 的公共网络使用的确认深度（两个 epoch）
+	DefaultFinalityDepth = 64
+	// DefaultMaxBatchSize 没有配置 maxBatchSize 时单个 JSON-RPC 批量请求最多包含的调用数
+	DefaultMaxBatchSize = 100
+)
+
+// Explorer 区块浏览器信息
+type Explorer struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// TxURL 交易在浏览器上的链接
+func (e *Explorer) TxURL(hash string) string {
+	if e == nil || e.URL == "" {
+		return ""
+	}
+	return strings.TrimRight(e.URL, "/") + "/tx/" + hash
+}
+
+// AddressURL 地址在浏览器上的链接
+func (e *Explorer) AddressURL(address string) string {
+	if e == nil || e.URL == "" {
+		return ""
+	}
+	return strings.TrimRight(e.URL, "/") + "/address/" + address
+}
+
+// Network 一个命名网络：HTTP/WS 节点地址、期望的链 ID 和浏览器信息
+type Network struct {
+	Name    string `json:"name"`
+	HTTP    string `json:"http"`
+	WS      string `json:"ws,omitempty"`
+	ChainID uint64 `json:"chainId"`
+	// Fallbacks 备用 HTTP 节点，主节点（HTTP）出错或落后时只读请求会切换过去
+	Fallbacks []string `json:"fallbacks,omitempty"`
+	// Broadcast 发送交易成功后是否同时广播给备用节点
+	Broadcast bool      `json:"broadcast,omitempty"`
+	Explorer  *Explorer `json:"explorer,omitempty"`
+	Devnet    bool      `json:"devnet,omitempty"` // 本地开发链，可以随意部署辅助合约
+	// FinalityDepth 距离最新区块至少这么多块的数据才会被缓存；开发链不会重组，可以配置为 0
+	FinalityDepth uint64 `json:"finalityDepth,omitempty"`
+	// MaxBatchSize 节点允许的单个批量请求最大调用数，客户端指定的 chunkSize 不能超过它
+	MaxBatchSize int `json:"maxBatchSize,omitempty"`
+	// Tokens 资产组合默认统计的 ERC-20 代币
+	Tokens []Token `json:"tokens,omitempty"`
+
+	// Disabled 配置不完整（如引用的环境变量没有设置）时的原因，这样的网络不会连接
+	Disabled string `json:"-"`
+}
+
+// Token 代币列表中的一项，symbol 和 decimals 没有配置时从链上读取
+type Token struct {
+	Address  common.Address `json:"address"`
+	Symbol   string         `json:"symbol,omitempty"`
+	Decimals *uint8         `json:"decimals,omitempty"`
+}
+
+// Config 网络配置文件
+type Config struct {
+	Default  string              `json:"default"`
+	Networks map[string]*Network `json:"networks"`
+}
+
+// Names 按名字排序的网络列表
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Networks))
+	for name := range c.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load 读取配置文件，再应用环境变量覆盖：
+//   - NETWORKS_CONFIG    配置文件路径（path 为空时使用），默认 ./networks.json
+//   - ETH_NETWORK        默认网络
+//   - <NAME>_HTTP_URL    覆盖某个网络的 HTTP 地址，如 SEPOLIA_HTTP_URL
+//   - <NAME>_WS_URL      覆盖 WS 地址
+//   - <NAME>_CHAIN_ID    覆盖期望的链 ID
+//   - <NAME>_FALLBACK_URLS 备用 HTTP 节点，逗号分隔，覆盖配置文件中的 fallbacks
+//   - <NAME>_TOKENS      代币地址，逗号分隔，覆盖配置文件中的 tokens
+//
+// 节点地址里的 ${VAR} 会替换成环境变量（如 ${INFURA_API_KEY}），没有设置的变量会让该网络被禁用，
+// 这样 API key 不用写进配置文件。
+func Load(path string) (*Config, error) {
+	if path == "" {
+		path = os.Getenv("NETWORKS_CONFIG")
+	}
+	if path == "" {
+		path = DefaultConfigPath
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := json.Unmarshal(raw, cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, cfg.validate()
+}
+
+func (c *Config) applyEnv() error {
+	if name := os.Getenv("ETH_NETWORK"); name != "" {
+		c.Default = name
+	}
+	for name, n := range c.Networks {
+		n.Name = name
+		prefix := envPrefix(name)
+		if v := os.Getenv(prefix + "_HTTP_URL"); v != "" {
+			n.HTTP = v
+		}
+		if v := os.Getenv(prefix + "_WS_URL"); v != "" {
+			n.WS = v
+		}
+		if v := os.Getenv(prefix + "_CHAIN_ID"); v != "" {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s_CHAIN_ID: %w", prefix, err)
+			}
+			n.ChainID = id
+		}
+
+		if v := os.Getenv(prefix + "_FALLBACK_URLS"); v != "" {
+			n.Fallbacks = strings.Split(v, ",")
+		}
+		if v := os.Getenv(prefix + "_TOKENS"); v != "" {
+			n.Tokens = n.Tokens[:0]
+			for _, addr := range strings.Split(v, ",") {
+				if addr = strings.TrimSpace(addr); !common.IsHexAddress(addr) {
+					return fmt.Errorf("%s_TOKENS: invalid address %q", prefix, addr)
+				}
+				n.Tokens = append(n.Tokens, Token{Address: common.HexToAddress(addr)})
+			}
+		}
+
+		var missing []string
+		n.HTTP = expandEnv(n.HTTP, &missing)
+		n.WS = expandEnv(n.WS, &missing)
+		if len(missing) > 0 {
+			n.Disabled = "missing environment variable " + strings.Join(missing, ", ")
+		}
+		// 备用节点引用的变量没有设置时只丢掉这个备用节点
+		fallbacks := n.Fallbacks[:0]
+		for _, u := range n.Fallbacks {
+			var missing []string
+			if u = expandEnv(strings.TrimSpace(u), &missing); len(missing) == 0 && u != "" {
+				fallbacks = append(fallbacks, u)
+			}
+		}
+		n.Fallbacks = fallbacks
+	}
+	return nil
+}
+
+func (c *Config) validate() error {
+	if len(c.Networks) == 0 {
+		return errors.New("no network configured")
+	}
+	if _, ok := c.Networks[c.Default]; !ok {
+		return fmt.Errorf("default network %q is not configured", c.Default)
+	}
+	for _, n := range c.Networks {
+		if n.HTTP == "" {
+			return fmt.Errorf("network %s: http endpoint is required", n.Name)
+		}
+		if n.ChainID == 0 {
+			return fmt.Errorf("network %s: chainId is required", n.Name)
+		}
+		for _, t := range n.Tokens {
+			if t.Address == (common.Address{}) {
+				return fmt.Errorf("network %s: token address is required", n.Name)
+			}
+		}
+		if n.FinalityDepth == 0 && !n.Devnet {
+			n.FinalityDepth = DefaultFinalityDepth
+		}
+		if n.MaxBatchSize <= 0 {
+			n.MaxBatchSize = DefaultMaxBatchSize
+		}
+	}
+	return nil
+}
+
+// envPrefix sepolia -> SEPOLIA，base-sepolia -> BASE_SEPOLIA
+func envPrefix(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// expandEnv 替换 ${VAR}，记录没有设置的变量
+func expandEnv(s string, missing *[]string) string {
+	return os.Expand(s, func(key string) string {
+		v, ok := os.LookupEnv(key)
+		if (!ok || v == "") && !contains(*missing, key) {
+			*missing = append(*missing, key)
+		}
+		return v
+	})
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
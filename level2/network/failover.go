@@ -0,0 +1,442 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	// ewmaAlpha 延迟和错误率的指数加权系数，越大越看重最近的请求
+	ewmaAlpha = 0.2
+	// maxErrorRate 错误率超过它的节点视为不健康
+	maxErrorRate = 0.5
+	// maxConsecutiveFailures 连续失败这么多次后暂停使用，直到健康检查成功
+	maxConsecutiveFailures = 3
+	// defaultMaxLag 落后最高区块超过这么多块的节点视为不健康
+	defaultMaxLag = 3
+)
+
+// writeMethods 会改变链上状态的方法，只发给主节点，失败也不重试
+var writeMethods = map[string]bool{
+	"eth_sendRawTransaction": true,
+	"eth_sendTransaction":    true,
+}
+
+// Endpoint 一个 JSON-RPC 节点及其健康统计
+type Endpoint struct {
+	URL     string
+	Primary bool
+
+	mu          sync.Mutex
+	latency     time.Duration // EWMA
+	errorRate   float64       // EWMA，0~1
+	requests    uint64
+	failures    uint64
+	consecutive int
+	head        uint64
+	lag         uint64
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// EndpointStats 节点健康统计快照，URL 只保留主机名（路径里可能有 API key）
+type EndpointStats struct {
+	Host      string     `json:"host"`
+	Primary   bool       `json:"primary"`
+	Healthy   bool       `json:"healthy"`
+	LatencyMs float64    `json:"latencyMs"`
+	ErrorRate float64    `json:"errorRate"`
+	Requests  uint64     `json:"requests"`
+	Failures  uint64     `json:"failures"`
+	Head      uint64     `json:"head"`
+	Lag       uint64     `json:"lag"`
+	LastError string     `json:"lastError,omitempty"`
+	LastErrAt *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+func (e *Endpoint) record(latency time.Duration, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests++
+	if err != nil {
+		e.failures++
+		e.consecutive++
+		e.errorRate = e.errorRate*(1-ewmaAlpha) + ewmaAlpha
+		e.lastError = strings.ReplaceAll(err.Error(), e.URL, redactURL(e.URL))
+		e.lastErrorAt = time.Now()
+		return
+	}
+	e.consecutive = 0
+	e.errorRate *= 1 - ewmaAlpha
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency = time.Duration(float64(e.latency)*(1-ewmaAlpha) + float64(latency)*ewmaAlpha)
+	}
+}
+
+func (e *Endpoint) healthy(maxLag uint64) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.consecutive < maxConsecutiveFailures && e.errorRate < maxErrorRate && e.lag <= maxLag
+}
+
+// Stats 健康统计快照
+func (e *Endpoint) Stats(maxLag uint64) EndpointStats {
+	healthy := e.healthy(maxLag)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats := EndpointStats{
+		Host:      redactURL(e.URL),
+		Primary:   e.Primary,
+		Healthy:   healthy,
+		LatencyMs: float64(e.latency.Microseconds()) / 1000,
+		ErrorRate: e.errorRate,
+		Requests:  e.requests,
+		Failures:  e.failures,
+		Head:      e.head,
+		Lag:       e.lag,
+		LastError: e.lastError,
+	}
+	if !e.lastErrorAt.IsZero() {
+		at := e.lastErrorAt
+		stats.LastErrAt = &at
+	}
+	return stats
+}
+
+// Transport 多节点的 http.RoundTripper，交给 rpc.WithHTTPClient 使用，ethclient 的用法完全不变：
+//   - 只读请求按健康度和延迟选择节点，失败（网络错误、401/403、429/5xx、限流、节点落后）时换下一个节点重试
+//   - 发送交易只走主节点；Broadcast 打开时成功后再异步广播给其它节点
+//   - Start 定期用 eth_blockNumber 检查每个节点的延迟和区块高度（落后多少块）
+type Transport struct {
+	// Base 实际发请求的 Transport，默认 http.DefaultTransport
+	Base http.RoundTripper
+	// Broadcast 发送交易成功后是否广播给其它节点
+	Broadcast bool
+	// MaxLag 允许落后最高区块的块数
+	MaxLag uint64
+
+	endpoints []*Endpoint // 第一个是主节点
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewTransport 第一个地址是主节点，其余是备用节点
+func NewTransport(urls ...string) (*Transport, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no endpoint")
+	}
+	t := &Transport{MaxLag: defaultMaxLag, stop: make(chan struct{})}
+	for i, u := range urls {
+		if _, err := url.Parse(u); err != nil {
+			return nil, fmt.Errorf("invalid endpoint %s: %w", redactURL(u), err)
+		}
+		t.endpoints = append(t.endpoints, &Endpoint{URL: u, Primary: i == 0})
+	}
+	return t, nil
+}
+
+// Endpoints 所有节点，第一个是主节点
+func (t *Transport) Endpoints() []*Endpoint {
+	return t.endpoints
+}
+
+// Stats 所有节点的健康统计
+func (t *Transport) Stats() []EndpointStats {
+	out := make([]EndpointStats, len(t.endpoints))
+	for i, e := range t.endpoints {
+		out[i] = e.Stats(t.MaxLag)
+	}
+	return out
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if hook := callHookFrom(req.Context()); hook != nil {
+		if err := hook(rpcCalls(body)); err != nil {
+			return nil, err
+		}
+	}
+	methods := rpcMethods(body)
+	write := false
+	for _, m := range methods {
+		if writeMethods[m] {
+			write = true
+		}
+	}
+
+	if write {
+		primary := t.endpoints[0]
+		resp, err := t.try(req, primary, body)
+		if err == nil && t.Broadcast {
+			t.broadcast(req, body)
+		}
+		return resp, err
+	}
+
+	var lastErr error
+	for _, e := range t.candidates() {
+		resp, err := t.try(req, e, body)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if req.Context().Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// candidates 健康的节点按延迟排序（相同或还没有测过延迟时主节点优先），不健康的放在最后兜底
+func (t *Transport) candidates() []*Endpoint {
+	var healthy, unhealthy []*Endpoint
+	for _, e := range t.endpoints {
+		if e.healthy(t.MaxLag) {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+	sort.SliceStable(healthy, func(i, j int) bool {
+		return latencyOf(healthy[i]) < latencyOf(healthy[j])
+	})
+	return append(healthy, unhealthy...)
+}
+
+func latencyOf(e *Endpoint) time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.latency == 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return e.latency
+}
+
+// try 把请求发给一个节点，响应体读完后重新包装，失败时返回错误并计入统计
+func (t *Transport) try(req *http.Request, e *Endpoint, body []byte) (*http.Response, error) {
+	out, err := endpointRequest(req, e.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := t.base().RoundTrip(out)
+	if err != nil {
+		e.record(0, err)
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err == nil {
+		err = retryableError(resp.StatusCode, respBody)
+	}
+	e.record(time.Since(start), err)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", redactURL(e.URL), err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	resp.ContentLength = int64(len(respBody))
+	return resp, nil
+}
+
+func (t *Transport) broadcast(req *http.Request, body []byte) {
+	for _, e := range t.endpoints[1:] {
+		go func(e *Endpoint) {
+			ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+			defer cancel()
+			_, _ = t.try(req.Clone(ctx), e, body)
+		}(e)
+	}
+}
+
+// Start 每隔 interval 做一次健康检查，Close 后停止
+func (t *Transport) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				t.CheckHealth(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// Close 停止健康检查
+func (t *Transport) Close() {
+	t.stopOnce.Do(func() { close(t.stop) })
+}
+
+// CheckHealth 向每个节点查询 eth_blockNumber，更新延迟、错误率和落后的区块数
+func (t *Transport) CheckHealth(ctx context.Context) {
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`)
+	heads := make([]uint64, len(t.endpoints))
+	var wg sync.WaitGroup
+	for i, e := range t.endpoints {
+		wg.Add(1)
+		go func(i int, e *Endpoint) {
+			defer wg.Done()
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, nil)
+			if err != nil {
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := t.try(req, e, body)
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+			var msg struct {
+				Result hexutil.Uint64 `json:"result"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&msg); err == nil {
+				heads[i] = uint64(msg.Result)
+			}
+		}(i, e)
+	}
+	wg.Wait()
+
+	var best uint64
+	for _, h := range heads {
+		best = max(best, h)
+	}
+	for i, e := range t.endpoints {
+		e.mu.Lock()
+		if heads[i] > 0 {
+			e.head = heads[i]
+			e.lag = best - heads[i]
+		}
+		e.mu.Unlock()
+	}
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	if req.Body == nil {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return io.ReadAll(req.Body)
+}
+
+// endpointRequest 复制请求（包括 header），改成发给指定节点
+func endpointRequest(req *http.Request, rawURL string, body []byte) (*http.Request, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	out := req.Clone(req.Context())
+	out.URL, out.Host = u, ""
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+	out.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	return out, nil
+}
+
+// rpcMethods 取出单个或批量 JSON-RPC 请求里的方法名
+func rpcMethods(body []byte) []string {
+	type call struct {
+		Method string `json:"method"`
+	}
+	var batch []call
+	if err := json.Unmarshal(body, &batch); err == nil {
+		methods := make([]string, len(batch))
+		for i, c := range batch {
+			methods[i] = c.Method
+		}
+		return methods
+	}
+	var single call
+	if err := json.Unmarshal(body, &single); err == nil {
+		return []string{single.Method}
+	}
+	return nil
+}
+
+// retryableError 换一个节点可能会成功的错误：HTTP 401/403（这个节点的 API key 无效或被封）、429/5xx、
+// 限流（-32005）、节点还没有同步到请求的区块。这些都计为节点失败，不会重置连续失败次数。
+// 合约回滚等执行错误是正常结果，原样返回。
+func retryableError(status int, body []byte) error {
+	if status == http.StatusUnauthorized || status == http.StatusForbidden ||
+		status == http.StatusTooManyRequests || status >= 500 {
+		return fmt.Errorf("http %d: %s", status, truncate(body))
+	}
+	type rpcError struct {
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	var msgs []rpcError
+	if err := json.Unmarshal(body, &msgs); err != nil {
+		var single rpcError
+		if json.Unmarshal(body, &single) != nil {
+			return nil
+		}
+		msgs = []rpcError{single}
+	}
+	for _, m := range msgs {
+		if m.Error == nil {
+			continue
+		}
+		msg := strings.ToLower(m.Error.Message)
+		if m.Error.Code == -32005 || strings.Contains(msg, "rate limit") ||
+			strings.Contains(msg, "header not found") || strings.Contains(msg, "unknown block") {
+			return fmt.Errorf("rpc error %d: %s", m.Error.Code, m.Error.Message)
+		}
+	}
+	return nil
+}
+
+func truncate(b []byte) string {
+	if len(b) > 200 {
+		return string(b[:200]) + "..."
+	}
+	return string(b)
+}
+
+// redactURL 只保留协议和主机名，Infura/Alchemy 的 API key 在路径里
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "<invalid>"
+	}
+	return u.Scheme + "://" + u.Host
+}
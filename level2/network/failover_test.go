@@ -0,0 +1,192 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// standIn 本地替身 JSON-RPC 节点：status 不为 0 时直接返回这个 HTTP 状态码，否则回答 eth_blockNumber 等调用
+type standIn struct {
+	*httptest.Server
+	mu     sync.Mutex
+	status int
+	head   uint64
+	calls  atomic.Int64
+	sent   atomic.Int64 // eth_sendRawTransaction 次数
+}
+
+func newStandIn(t *testing.T, head uint64) *standIn {
+	s := &standIn{head: head}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *standIn) set(status int, head uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.head = status, head
+}
+
+func (s *standIn) serve(w http.ResponseWriter, r *http.Request) {
+	s.calls.Add(1)
+	s.mu.Lock()
+	status, head := s.status, s.head
+	s.mu.Unlock()
+	if status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	switch req.Method {
+	case "eth_blockNumber":
+		resp["result"] = hexutil.EncodeUint64(head)
+	case "eth_sendRawTransaction":
+		s.sent.Add(1)
+		resp["result"] = "0x" + strings.Repeat("0", 64)
+	default:
+		resp["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func dialTransport(t *testing.T, nodes ...*standIn) (*Transport, *rpc.Client) {
+	urls := make([]string, len(nodes))
+	for i, n := range nodes {
+		urls[i] = n.URL
+	}
+	tr, err := NewTransport(urls...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := rpc.DialOptions(context.Background(), urls[0], rpc.WithHTTPClient(&http.Client{Transport: tr}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return tr, client
+}
+
+func blockNumber(t *testing.T, client *rpc.Client) uint64 {
+	t.Helper()
+	n, err := ethclient.NewClient(client).BlockNumber(context.Background())
+	if err != nil {
+		t.Fatalf("eth_blockNumber: %v", err)
+	}
+	return n
+}
+
+func TestFailoverToFallback(t *testing.T) {
+	primary, fallback := newStandIn(t, 100), newStandIn(t, 101)
+	primary.set(http.StatusServiceUnavailable, 100)
+	tr, client := dialTransport(t, primary, fallback)
+
+	if n := blockNumber(t, client); n != 101 {
+		t.Fatalf("block number = %d, want 101 from fallback", n)
+	}
+	if stats := tr.Stats()[0]; stats.Failures != 1 || !stats.Healthy {
+		t.Fatalf("primary stats = %+v, want one failure", stats)
+	}
+	// 健康检查继续失败，连续失败达到上限后主节点不再健康
+	for i := 1; i < maxConsecutiveFailures; i++ {
+		tr.CheckHealth(context.Background())
+	}
+	if stats := tr.Stats()[0]; stats.Healthy {
+		t.Fatalf("primary stats = %+v, want unhealthy after %d failures", stats, maxConsecutiveFailures)
+	}
+	if n := blockNumber(t, client); n != 101 {
+		t.Fatalf("block number = %d, want 101 from fallback", n)
+	}
+}
+
+func TestRecoveryAfterHealthCheck(t *testing.T) {
+	primary, fallback := newStandIn(t, 100), newStandIn(t, 100)
+	primary.set(http.StatusBadGateway, 100)
+	tr, client := dialTransport(t, primary, fallback)
+	for i := 0; i < maxConsecutiveFailures; i++ {
+		tr.CheckHealth(context.Background())
+	}
+	if tr.Stats()[0].Healthy {
+		t.Fatal("primary should be unhealthy")
+	}
+
+	primary.set(0, 100)
+	tr.CheckHealth(context.Background())
+	if !tr.Stats()[0].Healthy {
+		t.Fatalf("primary did not recover: %+v", tr.Stats()[0])
+	}
+	fallback.set(http.StatusServiceUnavailable, 100)
+	if n := blockNumber(t, client); n != 100 {
+		t.Fatalf("block number = %d, want 100 from recovered primary", n)
+	}
+}
+
+func TestAuthErrorsAreFailures(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			primary, fallback := newStandIn(t, 100), newStandIn(t, 102)
+			primary.set(status, 100)
+			tr, client := dialTransport(t, primary, fallback)
+
+			// 401/403 换节点重试，不能把错误响应当作结果交给调用方
+			if n := blockNumber(t, client); n != 102 {
+				t.Fatalf("block number = %d, want 102 from fallback", n)
+			}
+			// 也不能被当作成功而重置连续失败次数
+			for i := 1; i < maxConsecutiveFailures+1; i++ {
+				tr.CheckHealth(context.Background())
+			}
+			stats := tr.Stats()[0]
+			if stats.Healthy || stats.Failures != maxConsecutiveFailures+1 {
+				t.Fatalf("primary stats = %+v, want unhealthy after repeated auth errors", stats)
+			}
+		})
+	}
+}
+
+func TestLaggingEndpointUnhealthy(t *testing.T) {
+	primary, fallback := newStandIn(t, 100), newStandIn(t, 100+defaultMaxLag+1)
+	tr, client := dialTransport(t, primary, fallback)
+	tr.CheckHealth(context.Background())
+
+	stats := tr.Stats()
+	if stats[0].Healthy || stats[0].Lag != defaultMaxLag+1 {
+		t.Fatalf("primary stats = %+v, want lagging", stats[0])
+	}
+	if n := blockNumber(t, client); n != 100+defaultMaxLag+1 {
+		t.Fatalf("block number = %d, want head of the fallback", n)
+	}
+}
+
+func TestSendOnlyToPrimary(t *testing.T) {
+	primary, fallback := newStandIn(t, 100), newStandIn(t, 100)
+	primary.set(http.StatusServiceUnavailable, 100)
+	_, client := dialTransport(t, primary, fallback)
+
+	var hash string
+	if err := client.CallContext(context.Background(), &hash, "eth_sendRawTransaction", "0x00"); err == nil {
+		t.Fatal("send through a failing primary should fail")
+	}
+	if fallback.sent.Load() != 0 {
+		t.Fatal("transaction was retried on a fallback")
+	}
+}
@@ -0,0 +1,49 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Call 发往节点的一个 JSON-RPC 调用
+type Call struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// CallHook 请求发往节点之前调用，可以用来计数或限额；返回错误时请求不会发出。
+// 命中缓存的查询不经过 Transport，不会触发
+type CallHook func(calls []Call) error
+
+type callHookKey struct{}
+
+// WithCallHook 给请求上下文加上 CallHook，用这个上下文发起的 RPC 请求都会先经过它
+func WithCallHook(ctx context.Context, hook CallHook) context.Context {
+	return context.WithValue(ctx, callHookKey{}, hook)
+}
+
+func callHookFrom(ctx context.Context) CallHook {
+	hook, _ := ctx.Value(callHookKey{}).(CallHook)
+	return hook
+}
+
+// rpcCalls 取出单个或批量 JSON-RPC 请求里的调用
+func rpcCalls(body []byte) []Call {
+	var batch []Call
+	if err := json.Unmarshal(body, &batch); err == nil {
+		return batch
+	}
+	var single Call
+	if err := json.Unmarshal(body, &single); err == nil {
+		return []Call{single}
+	}
+	return nil
+}
+
+// Charge 把不经过 Transport 的调用（如 WS 订阅）交给上下文里的 CallHook 计数；上下文里没有 CallHook 时直接返回
+func Charge(ctx context.Context, calls ...Call) error {
+	if hook := callHookFrom(ctx); hook != nil {
+		return hook(calls)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// ErrUnknownNetwork ?network= 指定的网络没有配置或没有连接成功
+	ErrUnknownNetwork = errors.New("unknown network")
+	// ErrChainIDMismatch 节点返回的链 ID 和配置的不一致，通常是节点地址配错了
+	ErrChainIDMismatch = errors.New("chain id mismatch")
+	// ErrNoWebsocket 网络没有配置 WS 地址，无法订阅
+	ErrNoWebsocket = errors.New("websocket endpoint not configured")
+)
+
+const (
+	// dialTimeout 启动时连接节点并查询链 ID 的超时时间
+	dialTimeout = 10 * time.Second
+	// healthInterval 节点健康检查间隔
+	healthInterval = 15 * time.Second
+)
+
+// Pool 一个网络的客户端：HTTP 客户端在主节点和备用节点之间自动切换（见 Transport），
+// WS 客户端在第一次订阅时建立
+type Pool struct {
+	Network   *Network
+	client    *ethclient.Client
+	transport *Transport
+
+	mu sync.Mutex
+	ws *ethclient.Client
+}
+
+// Dial 连接网络的 HTTP 节点并逐个检查链 ID。主节点连不上或任何节点链 ID 不一致都返回错误，
+// 备用节点连不上只记为一次失败，等健康检查恢复
+func Dial(ctx context.Context, n *Network) (*Pool, error) {
+	transport, err := NewTransport(append([]string{n.HTTP}, n.Fallbacks...)...)
+	if err != nil {
+		return nil, err
+	}
+	transport.Broadcast = n.Broadcast
+	for i, e := range transport.Endpoints() {
+		client, err := dialChecked(ctx, n, e.URL)
+		if err != nil {
+			if i == 0 || errors.Is(err, ErrChainIDMismatch) {
+				return nil, err
+			}
+			log.Printf("fallback endpoint %s of %s unavailable: %v", redactURL(e.URL), n.Name, err)
+			e.record(0, err)
+			continue
+		}
+		client.Close()
+	}
+
+	rpcClient, err := rpc.DialOptions(ctx, n.HTTP, rpc.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", n.Name, err)
+	}
+	if len(n.Fallbacks) > 0 {
+		transport.Start(healthInterval)
+	}
+	return &Pool{Network: n, client: ethclient.NewClient(rpcClient), transport: transport}, nil
+}
+
+// Endpoints 各 HTTP 节点的健康统计
+func (p *Pool) Endpoints() []EndpointStats {
+	return p.transport.Stats()
+}
+
+// Client HTTP 客户端
+func (p *Pool) Client() *ethclient.Client {
+	return p.client
+}
+
+// WS 返回 WS 客户端，第一次调用时连接并检查链 ID
+func (p *Pool) WS(ctx context.Context) (*ethclient.Client, error) {
+	if p.Network.WS == "" {
+		return nil, fmt.Errorf("%s: %w", p.Network.Name, ErrNoWebsocket)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ws != nil {
+		return p.ws, nil
+	}
+	ws, err := dialChecked(ctx, p.Network, p.Network.WS)
+	if err != nil {
+		return nil, err
+	}
+	p.ws = ws
+	return ws, nil
+}
+
+// Close 关闭所有连接
+func (p *Pool) Close() {
+	p.transport.Close()
+	p.client.Close()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ws != nil {
+		p.ws.Close()
+		p.ws = nil
+	}
+}
+
+func dialChecked(ctx context.Context, n *Network, url string) (*ethclient.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	client, err := ethclient.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", n.Name, err)
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("query chain id of %s: %w", n.Name, err)
+	}
+	if !chainID.IsUint64() || chainID.Uint64() != n.ChainID {
+		client.Close()
+		return nil, fmt.Errorf("%w: network %s expects %d, node returned %s", ErrChainIDMismatch, n.Name, n.ChainID, chainID)
+	}
+	return client, nil
+}
+
+// Pools 所有网络的客户端
+type Pools struct {
+	def    string
+	byName map[string]*Pool
+}
+
+// DialAll 连接所有启用的网络。链 ID 不一致直接返回错误；默认网络被禁用或连接失败也返回错误，
+// 其它网络连接失败（如本地开发链没有启动）只打印日志并跳过
+func DialAll(ctx context.Context, cfg *Config) (*Pools, error) {
+	ps := &Pools{def: cfg.Default, byName: make(map[string]*Pool)}
+	for _, name := range cfg.Names() {
+		n := cfg.Networks[name]
+		if n.Disabled != "" {
+			if name == cfg.Default {
+				ps.Close()
+				return nil, fmt.Errorf("default network %s: %s", name, n.Disabled)
+			}
+			log.Printf("network %s disabled: %s", name, n.Disabled)
+			continue
+		}
+		pool, err := Dial(ctx, n)
+		if err != nil {
+			if errors.Is(err, ErrChainIDMismatch) || name == cfg.Default {
+				ps.Close()
+				return nil, err
+			}
+			log.Printf("network %s unavailable, skipped: %v", name, err)
+			continue
+		}
+		ps.byName[name] = pool
+	}
+	return ps, nil
+}
+
+// Get 按名字取网络，名字为空时返回默认网络
+func (ps *Pools) Get(name string) (*Pool, error) {
+	if name == "" {
+		name = ps.def
+	}
+	if pool, ok := ps.byName[name]; ok {
+		return pool, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownNetwork, name)
+}
+
+// Default 默认网络
+func (ps *Pools) Default() *Pool {
+	return ps.byName[ps.def]
+}
+
+// All 所有连接成功的网络
+func (ps *Pools) All() map[string]*Pool {
+	return ps.byName
+}
+
+// Close 关闭所有网络的连接
+func (ps *Pools) Close() {
+	for _, pool := range ps.byName {
+		pool.Close()
+	}
+}